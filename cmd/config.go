@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/lepinkainen/videotagger/config"
+	"github.com/lepinkainen/videotagger/ui"
+)
+
+// ConfigCmd groups commands for inspecting the configuration files.
+type ConfigCmd struct {
+	Show ConfigShowCmd `cmd:"" help:"Print the effective settings merged from config files and profile"`
+}
+
+// ConfigShowCmd prints every command's flags with the value that would be used
+// and where that value comes from.
+type ConfigShowCmd struct {
+	Path string `arg:"" optional:"" name:"path" help:"Library directory whose .videotagger.toml applies (default: the working directory)" type:"path"`
+}
+
+// Run walks the command tree and resolves each flag against the loaded configuration.
+func (cmd *ConfigShowCmd) Run(ctx *kong.Context, cfg *config.Config) error {
+	profile := config.ActiveProfile(ctx)
+
+	fmt.Println(ui.HeaderStyle.Render("⚙️  Effective Configuration"))
+
+	files := cfg.Files()
+	if len(files) == 0 {
+		fmt.Printf("%s\n", ui.InfoStyle.Render("No config files found, using built-in defaults"))
+	}
+	for _, file := range files {
+		fmt.Printf("%s\n", ui.InfoStyle.Render(fmt.Sprintf("Loaded: %s", file)))
+	}
+
+	if profile != "" {
+		fmt.Printf("%s\n", ui.InfoStyle.Render(fmt.Sprintf("Active profile: %s", profile)))
+	}
+	if profiles := cfg.Profiles(); len(profiles) > 0 {
+		fmt.Printf("%s\n", ui.InfoStyle.Render(fmt.Sprintf("Available profiles: %s", strings.Join(profiles, ", "))))
+	}
+
+	printNodeSettings(ctx.Model.Node, cfg, profile)
+	return nil
+}
+
+// printNodeSettings prints the effective flag values for a node and its subcommands
+func printNodeSettings(node *kong.Node, cfg *config.Config, profile string) {
+	path := config.CommandPath(node)
+
+	var lines []string
+	for _, flag := range node.Flags {
+		if flag.Hidden || flag.Name == "help" {
+			continue
+		}
+
+		value, source := flag.Default, config.SourceDefault
+		if value == "" && flag.IsBool() {
+			value = "false"
+		}
+		if configured, src, ok := cfg.Lookup(profile, path, flag.Name); ok {
+			value, source = config.FormatValue(configured), src
+		}
+		if flag.Name == config.ProfileFlag && profile != "" && value != profile {
+			value, source = profile, config.SourceFlag
+		}
+
+		lines = append(lines, fmt.Sprintf("  %-16s = %-12q %s", flag.Name, value, ui.InfoStyle.Faint(true).Render("("+string(source)+")")))
+	}
+
+	if len(lines) > 0 {
+		section := "global"
+		if len(path) > 0 {
+			section = strings.Join(path, ".")
+		}
+		fmt.Printf("\n%s\n%s\n", ui.ProcessingStyle.Render("["+section+"]"), strings.Join(lines, "\n"))
+	}
+
+	for _, child := range node.Children {
+		if child.Type == kong.CommandNode && !child.Hidden {
+			printNodeSettings(child, cfg, profile)
+		}
+	}
+}
@@ -2,18 +2,63 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	dupes "github.com/lepinkainen/videotagger/duplicates"
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
 	"github.com/lepinkainen/videotagger/types"
 	"github.com/lepinkainen/videotagger/ui"
+	"github.com/lepinkainen/videotagger/utils"
 	"github.com/lepinkainen/videotagger/video"
 )
 
 // DuplicatesCmd finds duplicate video files by comparing CRC32 hashes embedded in filenames.
-// Files must have been previously tagged with the tag command to include hash information.
+// Files must have been previously tagged with the tag command to include hash information,
+// unless Content is set. Several directories are scanned together, and files under a
+// Reference directory are never selected for deletion.
 type DuplicatesCmd struct {
-	Directory string `arg:"" name:"directory" help:"Directory to scan for duplicates" type:"existingdir" default:"."`
-	NoTUI     bool   `name:"no-tui" help:"Disable interactive TUI and just list duplicates"`
+	Directories []string `arg:"" name:"directories" help:"Directories to scan for duplicates" type:"existingdir" default:"."`
+	Reference   []string `help:"Directory whose files are always kept; scanned even if not listed (repeatable)" type:"existingdir"`
+	NoTUI       bool     `name:"no-tui" help:"Disable interactive TUI and just list duplicates"`
+	Content     bool     `help:"Compare file contents instead of filename hashes, so untagged files are included"`
+	Confirm     bool     `help:"Compare every group byte for byte up front instead of only before deleting"`
+	TrashDir    string   `name:"trash-dir" help:"Move deleted files to this holding directory instead of the XDG trash" type:"path"`
+	Permanent   bool     `help:"Delete files permanently instead of moving them to the trash (overrides --trash-dir)"`
+	KeepRule    []string `name:"keep-rule" sep:"none" help:"Rule for choosing the file to keep, highest priority first (repeatable): path:DIR[,DIR], resolution:highest|lowest, duration:longest|shortest, size:largest|smallest, mtime:newest|oldest, codec:NAME[,NAME], name:GLOB, not-name:GLOB" placeholder:"RULE"`
+	Auto        string   `help:"Resolve every group without the TUI, keeping one file by this strategy (rules for the --keep-rule list, keep-newest, keep-oldest, keep-largest, keep-smallest, keep-first, keep-last, keep-first-position, keep-last-position, keep-highest-resolution, keep-longest, keep-best-codec, keep-highest-bits-per-pixel)" placeholder:"STRATEGY"`
+	Action      string   `help:"What --auto does with the other files: trash, delete, hardlink, reflink or script=FILE" default:"trash"`
+	Yes         bool     `short:"y" help:"Carry out the --auto action; without it the plan is only listed"`
+
+	output.Flags `embed:""`
+}
+
+// duplicatesSummary is the final record of a duplicates scan in machine-readable output
+type duplicatesSummary struct {
+	Groups         int     `json:"groups"`
+	Files          int     `json:"files"`
+	Action         string  `json:"action,omitempty"`         // Set by --auto
+	Failed         int     `json:"failed,omitempty"`         // Files --auto couldn't delete or link
+	ReclaimedBytes int64   `json:"reclaimedBytes,omitempty"` // Space freed, or freeable by a dry run or script
+	DurationSecs   float64 `json:"durationSecs"`
+}
+
+// duplicatesAction is what duplicates --auto does with the files it doesn't keep
+type duplicatesAction struct {
+	Name   string // trash, delete, hardlink, reflink or script
+	Script string // Where the script action writes its script
+}
+
+// linkResult records one duplicate replaced by a link in machine-readable output
+type linkResult struct {
+	dupes.LinkResult
+	Mode string `json:"mode"`
 }
 
 // Run executes the duplicates command and displays results either in an interactive TUI
@@ -23,34 +68,385 @@ func (cmd *DuplicatesCmd) Run(appCtx *types.AppContext) error {
 	if appCtx != nil {
 		version = appCtx.Version
 	}
-	fmt.Println(ui.HeaderStyle.Render(fmt.Sprintf("Video Tagger %s", version)))
-	fmt.Printf("Scanning %s for duplicates...\n", cmd.Directory)
+	log := logging.ForOp("duplicates")
+	logging.Header(log, fmt.Sprintf("Video Tagger %s", version))
+	start := time.Now()
 
-	duplicates, err := video.FindDuplicatesByHash(cmd.Directory)
+	policy, err := dupes.ParsePolicy(cmd.KeepRule)
 	if err != nil {
-		return fmt.Errorf("failed to find duplicates: %w", err)
+		return err
 	}
+	keep, action, err := cmd.parseAuto(policy)
+	if err != nil {
+		return err
+	}
+
+	reference, err := dupes.NewReferenceRoots(cmd.Reference)
+	if err != nil {
+		return EnvironmentError(err)
+	}
+	roots := cmd.roots()
+	location := strings.Join(roots, ", ")
 
-	if len(duplicates) == 0 {
-		fmt.Printf("%s\n", ui.SuccessStyle.Render("✅ No duplicates found"))
+	find := video.FindDuplicatesByHash
+	if cmd.Content {
+		find = video.FindDuplicatesByContent
+		log.Info("Scanning for duplicates by content", logging.KeyPath, location)
+	} else {
+		log.Info("Scanning for duplicates", logging.KeyPath, location)
+	}
+	for _, dir := range cmd.Reference {
+		log.Info("Protecting reference directory", logging.KeyPath, dir)
+	}
+	duplicates, err := find(roots...)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to find duplicates: %w", err))
+	}
+
+	groups, err := cmd.buildGroups(duplicates)
+	if err != nil {
+		return err
+	}
+	reference.Mark(groups)
+
+	if cmd.Auto != "" {
+		return cmd.runAuto(groups, keep, action, policy, reference, start, log)
+	}
+
+	// Machine-readable output replaces both the TUI and the listing
+	if cmd.MachineReadable() {
+		return cmd.emitGroups(groups, start)
+	}
+
+	if len(groups) == 0 {
+		logging.Success(log, "No duplicates found", location, logging.KeyDuration, time.Since(start))
 		return nil
 	}
 
 	// If no-tui flag is set, just list the duplicates
 	if cmd.NoTUI {
-		fmt.Printf("\n%s\n", ui.InfoStyle.Render(fmt.Sprintf("Found %d group(s) of duplicates:", len(duplicates))))
-		for hash, files := range duplicates {
-			fmt.Printf("\n🔸 Hash %s (%d files):\n", hash, len(files))
-			for _, file := range files {
-				fmt.Printf("  %s\n", file)
+		logging.Summary(log, "Found duplicate groups", "groups", len(groups), logging.KeyDuration, time.Since(start))
+		for _, group := range groups {
+			log.Info("Duplicate group", "hash", group.Hash, "files", len(group.Files), "confirmed", group.Confirmed)
+			for _, file := range group.Files {
+				if file.Protected {
+					log.Info("Duplicate", logging.KeyPath, file.Path, "hash", group.Hash, "protected", true)
+				} else {
+					log.Info("Duplicate", logging.KeyPath, file.Path, "hash", group.Hash)
+				}
 			}
 		}
 		return nil
 	}
 
 	// Launch TUI for interactive duplicate management
-	model := ui.NewDuplicatesModel(duplicates)
+	model := ui.NewDuplicatesModelFromGroups(groups, dupes.DeleteOptions{
+		Reference: reference,
+		Permanent: cmd.Permanent,
+		TrashDir:  cmd.TrashDir,
+	}).WithKeepPolicy(policy)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	_, err = p.Run()
 	return err
 }
+
+// roots returns the directories to scan: the listed ones plus any reference directory
+// not already among them
+func (cmd *DuplicatesCmd) roots() []string {
+	roots := slices.Clone(cmd.Directories)
+	for _, dir := range cmd.Reference {
+		if !slices.Contains(roots, dir) {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// buildGroups turns the scan results into groups ordered by hash, split by size.
+// Content scans have already hashed every file, so their groups start out confirmed.
+// --auto compares every group up front, as nobody reviews the groups before acting.
+func (cmd *DuplicatesCmd) buildGroups(duplicates map[string][]string) ([]dupes.DuplicateGroup, error) {
+	groups := dupes.BuildGroups(duplicates)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Hash < groups[j].Hash })
+
+	if cmd.Content {
+		for i := range groups {
+			groups[i].Confirmed = true
+		}
+		return groups, nil
+	}
+	if !cmd.Confirm && cmd.Auto == "" {
+		return groups, nil
+	}
+
+	var confirmed []dupes.DuplicateGroup
+	for _, group := range groups {
+		split, err := dupes.ConfirmGroup(group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare duplicates: %w", err)
+		}
+		confirmed = append(confirmed, split...)
+	}
+	return confirmed, nil
+}
+
+// emitGroups writes one record per duplicate group, ordered by hash, followed by a summary
+func (cmd *DuplicatesCmd) emitGroups(groups []dupes.DuplicateGroup, start time.Time) error {
+	out := output.New(os.Stdout, cmd.Output, "duplicates")
+
+	summary := duplicatesSummary{Groups: len(groups)}
+	for _, group := range groups {
+		summary.Files += len(group.Files)
+		if err := out.Emit(output.TypeGroup, group, nil); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// parseAuto parses --auto and --action into the function that picks the files to remove
+// from a group and the action taken on them. "--auto rules" uses the keep rules.
+// --permanent turns the trash action into delete.
+func (cmd *DuplicatesCmd) parseAuto(policy dupes.Policy) (dupes.Keeper, duplicatesAction, error) {
+	if cmd.Auto == "" {
+		return dupes.Keeper{}, duplicatesAction{}, nil
+	}
+
+	var keep dupes.Keeper
+	if cmd.Auto == "rules" {
+		if len(policy) == 0 {
+			return dupes.Keeper{}, duplicatesAction{}, fmt.Errorf("--auto rules needs at least one --keep-rule")
+		}
+		keep = policy.Keeper()
+	} else {
+		strategy, err := dupes.ParseAutoSelectStrategy(cmd.Auto)
+		if err != nil {
+			return dupes.Keeper{}, duplicatesAction{}, fmt.Errorf("%w; use rules for the --keep-rule list", err)
+		}
+		keep = strategy.Keeper()
+	}
+
+	action, err := parseDuplicatesAction(cmd.Action)
+	if err != nil {
+		return dupes.Keeper{}, duplicatesAction{}, err
+	}
+	if action.Name == "trash" && cmd.Permanent {
+		action.Name = "delete"
+	}
+	return keep, action, nil
+}
+
+// parseDuplicatesAction parses the --action value
+func parseDuplicatesAction(action string) (duplicatesAction, error) {
+	name, script, hasScript := strings.Cut(action, "=")
+	switch name {
+	case "trash", "delete", "hardlink", "reflink":
+		if hasScript {
+			return duplicatesAction{}, fmt.Errorf("--action %s takes no value", name)
+		}
+		return duplicatesAction{Name: name}, nil
+	case "script":
+		if script == "" {
+			return duplicatesAction{}, fmt.Errorf("--action script needs a file, e.g. script=remove-duplicates.sh")
+		}
+		return duplicatesAction{Name: name, Script: script}, nil
+	default:
+		return duplicatesAction{}, fmt.Errorf("unknown --action %q, expected trash, delete, hardlink, reflink or script=FILE", action)
+	}
+}
+
+// describe says what the action does to a file, for the dry run listing
+func (a duplicatesAction) describe() string {
+	switch a.Name {
+	case "delete":
+		return "delete"
+	case "hardlink":
+		return "replace with a hardlink"
+	case "reflink":
+		return "replace with a reflink clone"
+	case "script":
+		return "remove from the script"
+	default:
+		return "move to the trash"
+	}
+}
+
+// runAuto keeps one file of every group, as chosen by keep, and applies the action to the rest.
+// Files missing metadata keep needs are probed first. Without --yes the plan is only listed.
+// The script action writes a shell script for review and touches no files, so it needs no --yes.
+func (cmd *DuplicatesCmd) runAuto(groups []dupes.DuplicateGroup, keep dupes.Keeper, action duplicatesAction, policy dupes.Policy, reference dupes.ReferenceRoots, start time.Time, log *slog.Logger) error {
+	out := output.New(os.Stdout, cmd.Output, "duplicates")
+
+	if dupes.NeedsProbe(groups, keep.Missing) {
+		log.Info("Probing files for missing metadata", "groups", len(groups))
+		dupes.ProbeMissing(groups, keep.Missing)
+	}
+
+	sizes := make(map[string]int64)
+	for i := range groups {
+		keep.Apply(&groups[i])
+		for _, file := range groups[i].Files {
+			sizes[file.Path] = file.Size
+		}
+	}
+	targets := dupes.CollectLinkTargets(groups)
+	var reclaimable int64
+	for _, target := range targets {
+		reclaimable += sizes[target.Path]
+	}
+
+	summary := duplicatesSummary{Groups: len(groups), Files: len(targets), Action: action.Name}
+	var err error
+	switch {
+	case action.Name == "script":
+		if err := cmd.writeScript(action.Script, groups, policy, len(targets), reclaimable); err != nil {
+			return EnvironmentError(fmt.Errorf("failed to write script: %w", err))
+		}
+		summary.ReclaimedBytes = reclaimable
+		logging.Summary(log, "Wrote script", logging.KeyPath, action.Script,
+			"groups", len(groups),
+			"files", len(targets),
+			"reclaimable_bytes", reclaimable,
+			logging.KeyDuration, time.Since(start))
+
+	case !cmd.Yes:
+		for _, group := range groups {
+			reason := keep.Explain(&group)
+			for _, target := range dupes.CollectLinkTargets([]dupes.DuplicateGroup{group}) {
+				log.Info("Would "+action.describe(), logging.KeyPath, target.Path, "keep", target.Keep, "reason", reason)
+			}
+			if err := out.Emit(output.TypeGroup, group, nil); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		summary.ReclaimedBytes = reclaimable
+		logging.Summary(log, "Dry run, pass --yes to apply",
+			"groups", len(groups),
+			"files", len(targets),
+			"reclaimable_bytes", reclaimable,
+			logging.KeyDuration, time.Since(start))
+
+	case action.Name == "hardlink" || action.Name == "reflink":
+		summary.ReclaimedBytes, summary.Failed, err = linkDuplicates(targets, action, sizes, reference, out, log)
+		if err != nil {
+			return err
+		}
+		logging.Summary(log, "Duplicates resolved",
+			"groups", len(groups),
+			"linked", len(targets)-summary.Failed,
+			"failed", summary.Failed,
+			"reclaimed_bytes", summary.ReclaimedBytes,
+			logging.KeyDuration, time.Since(start))
+
+	default:
+		var skipped int
+		summary.ReclaimedBytes, summary.Failed, skipped, err = cmd.deleteDuplicates(groups, action, sizes, reference, out, log)
+		if err != nil {
+			return err
+		}
+		result := []any{
+			"groups", len(groups),
+			"deleted", len(targets) - summary.Failed - skipped,
+			"failed", summary.Failed,
+		}
+		if skipped > 0 {
+			result = append(result, "skipped", skipped)
+		}
+		result = append(result, "reclaimed_bytes", summary.ReclaimedBytes, logging.KeyDuration, time.Since(start))
+		logging.Summary(log, "Duplicates resolved", result...)
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(summary.Failed, len(targets))
+}
+
+// deleteDuplicates trashes or deletes the selected files, returning the bytes freed and the
+// number of files that failed or were skipped
+func (cmd *DuplicatesCmd) deleteDuplicates(groups []dupes.DuplicateGroup, action duplicatesAction, sizes map[string]int64, reference dupes.ReferenceRoots, out *output.Writer, log *slog.Logger) (reclaimed int64, failed, skipped int, err error) {
+	results := dupes.DeleteFiles(dupes.CollectSelected(groups), dupes.DeleteOptions{
+		Reference: reference,
+		Permanent: action.Name == "delete",
+		TrashDir:  cmd.TrashDir,
+	})
+
+	for _, result := range results {
+		switch result.Status {
+		case dupes.StatusDeleted:
+			reclaimed += sizes[result.Path]
+			if result.TrashedTo != "" {
+				logging.Success(log, "Moved to the trash", result.Path, "trashed", result.TrashedTo)
+			} else {
+				logging.Success(log, "Deleted", result.Path)
+			}
+		case dupes.StatusFailed:
+			failed++
+			logging.Failure(log, "Error deleting file", result.Path, result.Error)
+		case dupes.StatusMissing:
+			skipped++
+			logging.Skipped(log, "Already gone", result.Path)
+		default:
+			skipped++
+			logging.Skipped(log, "Left in place", result.Path, "reason", result.Error)
+		}
+
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return reclaimed, failed, skipped, fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return reclaimed, failed, skipped, nil
+}
+
+// linkDuplicates replaces each duplicate with a link to the file kept in its group, returning
+// the bytes freed and the number of files that failed. A failure doesn't stop the rest.
+func linkDuplicates(targets []dupes.LinkTarget, action duplicatesAction, sizes map[string]int64, reference dupes.ReferenceRoots, out *output.Writer, log *slog.Logger) (reclaimed int64, failed int, err error) {
+	mode, err := dupes.ParseLinkMode(action.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, linked := range dupes.LinkFiles(targets, mode, reference) {
+		if linked.Error != nil {
+			failed++
+			logging.Failure(log, "Error linking file", linked.Path, linked.Error, "keep", linked.Keep)
+		} else {
+			if !linked.AlreadyLinked {
+				reclaimed += sizes[linked.Path]
+			}
+			logging.Success(log, "Linked", linked.Path, "keep", linked.Keep, "mode", mode.String())
+		}
+
+		result := linkResult{LinkResult: linked, Mode: mode.String()}
+		if err := out.Emit(output.TypeFile, result, linked.Error); err != nil {
+			return reclaimed, failed, fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	return reclaimed, failed, nil
+}
+
+// writeScript writes the removal script for --action script=FILE
+func (cmd *DuplicatesCmd) writeScript(path string, groups []dupes.DuplicateGroup, policy dupes.Policy, files int, reclaimable int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+
+	header := []string{fmt.Sprintf("Generated by videotagger duplicates --auto %s on %s", cmd.Auto, time.Now().Format(time.DateTime))}
+	if cmd.Auto == "rules" {
+		header = append(header, "Keep rules: "+policy.String())
+	}
+	header = append(header, fmt.Sprintf("Review before running: removes %d files, freeing %s", files, utils.FormatBytes(reclaimable)))
+
+	err = dupes.WriteScript(f, groups, header...)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import "fmt"
+
+// Exit codes shared by all commands so scripts can tell kinds of failure apart
+const (
+	ExitOK          = 0 // Every file was processed successfully
+	ExitFailed      = 1 // One or more files could not be processed
+	ExitMismatch    = 2 // Verification found files that don't match their hash or don't decode cleanly
+	ExitEnvironment = 3 // Missing tools, bad configuration or inaccessible inputs
+)
+
+// ExitError is returned from a command's Run method to select the process exit code.
+// kong picks the code up through its ExitCoder interface.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode implements kong.ExitCoder
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// EnvironmentError marks err as a problem with the environment rather than with individual files
+func EnvironmentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: ExitEnvironment, Err: err}
+}
+
+// filesFailedError reports that some files could not be processed, or returns nil if none failed
+func filesFailedError(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return &ExitError{Code: ExitFailed, Err: fmt.Errorf("%d of %d files failed", failed, total)}
+}
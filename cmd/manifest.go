@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/manifest"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// ManifestCmd groups commands for standard checksum manifests, which can be checked
+// with sha256sum -c or b3sum -c on machines without videotagger.
+type ManifestCmd struct {
+	Create ManifestCreateCmd `cmd:"" help:"Write a SHA256SUMS or B3SUMS manifest in each directory containing videos"`
+	Check  ManifestCheckCmd  `cmd:"" help:"Check files against SHA256SUMS or B3SUMS manifests"`
+}
+
+// ManifestCreateCmd hashes every video and writes one manifest per directory.
+type ManifestCreateCmd struct {
+	Directory string `arg:"" name:"directory" help:"Directory to create manifests for (searched recursively)" type:"existingdir" default:"."`
+	Algorithm string `help:"Checksum algorithm" default:"sha256" enum:"sha256,blake3"`
+	Force     bool   `help:"Overwrite existing manifests"`
+	Workers   int    `help:"Number of parallel workers" default:"0"`
+}
+
+// manifestFileEntry pairs a video with its manifest entry while creating manifests
+type manifestFileEntry struct {
+	path  string
+	entry manifest.Entry
+	err   error
+}
+
+// Run hashes all videos under the directory and writes manifests grouped by directory.
+// Directories that already have a manifest are skipped before hashing unless --force is given.
+func (cmd *ManifestCreateCmd) Run() error {
+	log := logging.ForOp("manifest-create")
+	start := time.Now()
+
+	algorithm, err := manifest.ParseAlgorithm(cmd.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	files, err := video.FindAllVideoFilesRecursively(cmd.Directory)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to scan directory %s: %w", cmd.Directory, err))
+	}
+	slices.Sort(files)
+
+	// Drop directories with an existing manifest first so their files aren't hashed for nothing
+	skipped := 0
+	skippedDirs := make(map[string]bool)
+	files = slices.DeleteFunc(files, func(path string) bool {
+		dir := filepath.Dir(path)
+		if _, seen := skippedDirs[dir]; !seen {
+			manifestPath := filepath.Join(dir, algorithm.FileName())
+			_, statErr := os.Stat(manifestPath)
+			skippedDirs[dir] = statErr == nil && !cmd.Force
+			if skippedDirs[dir] {
+				logging.Skipped(log, "Manifest exists, use --force to overwrite", manifestPath)
+				skipped++
+			}
+		}
+		return skippedDirs[dir]
+	})
+
+	workers := workerCount(cmd.Workers, files, log)
+	log.Info("Hashing files", logging.KeyPath, cmd.Directory, "files", len(files), "algorithm", algorithm, "workers", workers)
+
+	entries := runWorkerPool(context.Background(), files, workers, func(workerID int, path string) manifestFileEntry {
+		logging.Start(log.With(logging.KeyWorker, workerID+1), "Hashing", path)
+		hash, err := manifest.HashFile(path, algorithm)
+		return manifestFileEntry{
+			path:  path,
+			entry: manifest.Entry{Name: filepath.Base(path), Hash: hash},
+			err:   err,
+		}
+	})
+
+	// Group entries by directory, keeping the sorted order within each
+	byDir := make(map[string][]manifest.Entry)
+	var dirs []string
+	failed := 0
+	for _, e := range entries {
+		if e.err != nil {
+			logging.Failure(log, "Error hashing file", e.path, e.err)
+			failed++
+			continue
+		}
+
+		dir := filepath.Dir(e.path)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], e.entry)
+	}
+	slices.Sort(dirs)
+
+	written := 0
+	for _, dir := range dirs {
+		manifestPath := filepath.Join(dir, algorithm.FileName())
+		if err := manifest.WriteFile(manifestPath, byDir[dir]); err != nil {
+			logging.Failure(log, "Error writing manifest", manifestPath, err)
+			failed++
+			continue
+		}
+
+		logging.Success(log, "Wrote manifest", manifestPath, "entries", len(byDir[dir]))
+		written++
+	}
+
+	logging.Summary(log, "Manifest creation complete",
+		"manifests", written,
+		"entries", len(files)-failed,
+		"skipped", skipped,
+		"failed", failed,
+		"check_with", algorithm.Tool()+" -c",
+		logging.KeyDuration, time.Since(start))
+
+	return filesFailedError(failed, len(files))
+}
+
+// ManifestCheckCmd checks the files listed in manifests and reports files the manifests don't list.
+type ManifestCheckCmd struct {
+	Paths   []string `arg:"" name:"paths" help:"Manifest files, or directories to search recursively for manifests" type:"path"`
+	Workers int      `help:"Number of parallel workers" default:"0"`
+
+	output.Flags `embed:""`
+}
+
+// manifestSummary is the final record of a manifest check in machine-readable output
+type manifestSummary struct {
+	Manifests    int     `json:"manifests"`
+	Files        int     `json:"files"`
+	OK           int     `json:"ok"`
+	Mismatched   int     `json:"mismatched"`
+	Missing      int     `json:"missing"`
+	Extra        int     `json:"extra"`
+	Failed       int     `json:"failed"`
+	Interrupted  int     `json:"interrupted"`
+	DurationSecs float64 `json:"durationSecs"`
+}
+
+// manifestJob is one listed file to check
+type manifestJob struct {
+	manifest *manifest.Manifest
+	entry    manifest.Entry
+}
+
+// Run hashes every listed file in parallel, then reports results in manifest order,
+// followed by videos next to each manifest that it doesn't list.
+func (cmd *ManifestCheckCmd) Run() error {
+	log := logging.ForOp("manifest-check")
+	out := output.New(os.Stdout, cmd.Output, "manifest-check")
+	start := time.Now()
+
+	manifestPaths, err := expandPaths(cmd.Paths, manifest.FindManifests)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to find manifests: %w", err))
+	}
+
+	var manifests []*manifest.Manifest
+	var jobs []manifestJob
+	var paths []string
+	for _, path := range manifestPaths {
+		m, err := manifest.Read(path)
+		if err != nil {
+			return EnvironmentError(fmt.Errorf("failed to read manifest: %w", err))
+		}
+		manifests = append(manifests, m)
+
+		for _, entry := range m.Entries {
+			jobs = append(jobs, manifestJob{manifest: m, entry: entry})
+			paths = append(paths, m.EntryPath(entry))
+		}
+	}
+
+	workers := workerCount(cmd.Workers, paths, log)
+	log.Info("Checking manifests", "manifests", len(manifests), "files", len(paths), "workers", workers)
+
+	// Ctrl-C stops new files from starting; files being hashed finish, and the rest are
+	// reported as not checked
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results := runWorkerPool(ctx, jobs, workers, func(workerID int, job manifestJob) *manifest.CheckResult {
+		logging.Start(log.With(logging.KeyWorker, workerID+1), "Checking", job.manifest.EntryPath(job.entry))
+		return job.manifest.Check(job.entry)
+	})
+
+	for i, result := range results {
+		if result == nil {
+			// The run was cancelled before this file started
+			result = &manifest.CheckResult{Path: jobs[i].manifest.EntryPath(jobs[i].entry), Manifest: jobs[i].manifest.Path, Status: manifest.StatusInterrupted}
+		}
+		results[i] = result
+	}
+
+	for _, m := range manifests {
+		extra, err := unlistedVideos(m)
+		if err != nil {
+			logging.Failure(log, "Error listing directory", m.Dir(), err)
+			continue
+		}
+		for _, path := range extra {
+			results = append(results, m.ExtraResult(path))
+		}
+	}
+
+	summary := manifestSummary{Manifests: len(manifests), Files: len(paths)}
+	for _, result := range results {
+		reportManifestResult(result, &summary, log)
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	logging.Summary(log, "Manifest check complete",
+		"ok", summary.OK,
+		"mismatched", summary.Mismatched,
+		"missing", summary.Missing,
+		"extra", summary.Extra,
+		"failed", summary.Failed,
+		"interrupted", summary.Interrupted,
+		"workers", workers,
+		logging.KeyDuration, time.Since(start))
+
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	// Same priority as verify: changed data first, then files that weren't checked
+	if summary.Mismatched > 0 {
+		return &ExitError{
+			Code: ExitMismatch,
+			Err:  fmt.Errorf("%d of %d files do not match their manifest", summary.Mismatched, summary.Files),
+		}
+	}
+	if summary.Interrupted > 0 {
+		return &ExitError{
+			Code: ExitFailed,
+			Err:  fmt.Errorf("manifest check interrupted, %d of %d files not checked", summary.Interrupted, summary.Files),
+		}
+	}
+	return filesFailedError(summary.Failed+summary.Missing, summary.Files)
+}
+
+// unlistedVideos returns the videos in a manifest's directory that the manifest doesn't list.
+// Subdirectories are left to their own manifests.
+func unlistedVideos(m *manifest.Manifest) ([]string, error) {
+	dirEntries, err := os.ReadDir(m.Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []string
+	for _, e := range dirEntries {
+		if !e.IsDir() && video.IsVideoFile(e.Name()) {
+			videos = append(videos, filepath.Join(m.Dir(), e.Name()))
+		}
+	}
+
+	return m.Extra(videos), nil
+}
+
+// reportManifestResult logs a check result and counts it in summary
+func reportManifestResult(result *manifest.CheckResult, summary *manifestSummary, log *slog.Logger) {
+	switch result.Status {
+	case manifest.StatusOK:
+		logging.Success(log, "OK", result.Path)
+		summary.OK++
+	case manifest.StatusMismatch:
+		log.Error("Hash mismatch",
+			logging.KeyPath, result.Path,
+			logging.KeyStatus, logging.StatusFailed,
+			logging.KeyErrorClass, "mismatch",
+			"manifest", result.Manifest,
+			"expected", result.ExpectedHash,
+			"actual", result.ActualHash)
+		summary.Mismatched++
+	case manifest.StatusMissing:
+		logging.Failure(log, "Missing file", result.Path, result.Error, "manifest", result.Manifest)
+		summary.Missing++
+	case manifest.StatusExtra:
+		logging.Skipped(log, "Not in manifest", result.Path, "manifest", result.Manifest)
+		summary.Extra++
+	case manifest.StatusFailed:
+		logging.Failure(log, "Error hashing file", result.Path, result.Error, "manifest", result.Manifest)
+		summary.Failed++
+	case manifest.StatusInterrupted:
+		logging.Skipped(log, "Not checked", result.Path, "reason", "interrupted")
+		summary.Interrupted++
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/parity"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// ParityCmd groups commands for Reed-Solomon recovery data.
+type ParityCmd struct {
+	Create ParityCreateCmd `cmd:"" help:"Write recovery data next to tagged video files"`
+	Repair ParityRepairCmd `cmd:"" help:"Rebuild damaged blocks of files that fail verification"`
+}
+
+// ParityCreateCmd writes a parity file next to each tagged video file.
+type ParityCreateCmd struct {
+	Paths      []string `arg:"" name:"paths" help:"Tagged video files or directories (searched recursively for tagged files)" type:"path"`
+	Redundancy float64  `help:"Parity size as a fraction of each file (0.05 rebuilds up to 5% of the blocks)" default:"0.05"`
+	Force      bool     `help:"Replace existing parity files"`
+	Workers    int      `help:"Number of parallel workers" default:"0"`
+
+	output.Flags `embed:""`
+}
+
+// parityCreateResult is the outcome of protecting one file
+type parityCreateResult struct {
+	Path       string `json:"path"`
+	ParityPath string `json:"parityPath,omitempty"`
+	ParitySize int64  `json:"paritySize,omitempty"`
+	Blocks     int    `json:"blocks,omitempty"`
+	Skipped    bool   `json:"skipped"`
+	SkipReason string `json:"skipReason,omitempty"`
+	Error      error  `json:"-"`
+}
+
+// parityCreateSummary is the final record of a parity create run
+type parityCreateSummary struct {
+	Files        int     `json:"files"`
+	Created      int     `json:"created"`
+	Skipped      int     `json:"skipped"`
+	Failed       int     `json:"failed"`
+	ParityBytes  int64   `json:"parityBytes"`
+	DurationSecs float64 `json:"durationSecs"`
+}
+
+// Run creates parity files in parallel, reporting results in input order.
+func (cmd *ParityCreateCmd) Run() error {
+	log := logging.ForOp("parity-create")
+	out := output.New(os.Stdout, cmd.Output, "parity-create")
+	start := time.Now()
+
+	if cmd.Redundancy <= 0 || cmd.Redundancy > 1 {
+		return fmt.Errorf("--redundancy must be greater than 0 and at most 1")
+	}
+
+	files, err := expandPaths(cmd.Paths, video.FindTaggedFilesRecursively)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to expand directories: %w", err))
+	}
+
+	workers := workerCount(cmd.Workers, files, log)
+	log.Info("Creating parity files", "files", len(files), "workers", workers, "redundancy_percent", cmd.Redundancy*100)
+
+	results := runWorkerPool(context.Background(), files, workers, func(workerID int, path string) *parityCreateResult {
+		logging.Start(log.With(logging.KeyWorker, workerID+1), "Protecting", path)
+		return cmd.createParity(path)
+	})
+
+	summary := parityCreateSummary{Files: len(files)}
+	for _, result := range results {
+		switch {
+		case result.Error != nil:
+			summary.Failed++
+			logging.Failure(log, "Error creating parity", result.Path, result.Error)
+		case result.Skipped:
+			summary.Skipped++
+			logging.Skipped(log, "Skipping", result.Path, "reason", result.SkipReason)
+		default:
+			summary.Created++
+			summary.ParityBytes += result.ParitySize
+			logging.Success(log, "Protected", result.Path, "parity", result.ParityPath, "parity_bytes", result.ParitySize)
+		}
+
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	logging.Summary(log, "Parity complete",
+		"files", summary.Files,
+		"created", summary.Created,
+		"skipped", summary.Skipped,
+		"failed", summary.Failed,
+		"parity_bytes", summary.ParityBytes,
+		logging.KeyDuration, time.Since(start))
+
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(summary.Failed, summary.Files)
+}
+
+// createParity writes the parity file for one file. Tagged files must still match their
+// tag, since parity created from damaged contents would preserve the damage.
+func (cmd *ParityCreateCmd) createParity(path string) *parityCreateResult {
+	result := &parityCreateResult{Path: path}
+	parityPath := parity.Path(path)
+
+	if _, err := os.Stat(parityPath); err == nil && !cmd.Force {
+		result.Skipped = true
+		result.SkipReason = "parity file exists"
+		return result
+	}
+
+	// The old parity file is only replaced once the new one is known to be built from intact contents
+	expected, _ := video.ExtractHashFromFilename(filepath.Base(path))
+	h, err := parity.Create(path, parity.Options{Redundancy: cmd.Redundancy, Replace: cmd.Force, ExpectCRC: expected})
+	if errors.Is(err, parity.ErrCRCMismatch) {
+		result.Error = fmt.Errorf("%w; run parity create on an intact copy", err)
+		return result
+	}
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	result.ParityPath = parityPath
+	result.ParitySize = h.ParitySize()
+	result.Blocks = h.Blocks()
+	return result
+}
+
+// ParityRepairCmd rebuilds damaged files from their parity files.
+type ParityRepairCmd struct {
+	Paths []string `arg:"" name:"paths" help:"Video files, their parity files, or directories (searched recursively for parity files)" type:"path"`
+
+	output.Flags `embed:""`
+}
+
+// parityRepairResult is the outcome of checking and repairing one file
+type parityRepairResult struct {
+	Path       string               `json:"path"`
+	ParityPath string               `json:"parityPath"`
+	Intact     bool                 `json:"intact"` // Matched its CRC32 before any repair
+	Repair     *parity.RepairResult `json:"repair,omitempty"`
+	Repaired   bool                 `json:"repaired"` // Damaged, and matches its CRC32 after repair
+	Error      error                `json:"-"`
+}
+
+// parityRepairSummary is the final record of a parity repair run
+type parityRepairSummary struct {
+	Files         int     `json:"files"`
+	Intact        int     `json:"intact"`
+	Repaired      int     `json:"repaired"`
+	Unrecoverable int     `json:"unrecoverable"`
+	Failed        int     `json:"failed"`
+	DurationSecs  float64 `json:"durationSecs"`
+}
+
+// Run checks each file against its CRC32 and repairs the ones that fail. Files are handled
+// one at a time, since a repair reads the whole file and writes into it.
+func (cmd *ParityRepairCmd) Run() error {
+	log := logging.ForOp("parity-repair")
+	out := output.New(os.Stdout, cmd.Output, "parity-repair")
+	start := time.Now()
+
+	parityFiles, err := cmd.findParityFiles()
+	if err != nil {
+		return EnvironmentError(err)
+	}
+
+	summary := parityRepairSummary{Files: len(parityFiles)}
+	for _, parityPath := range parityFiles {
+		result := repairFromParity(parityPath, log)
+
+		switch {
+		case result.Error != nil:
+			summary.Failed++
+			logging.Failure(log, "Error repairing", result.Path, result.Error)
+		case result.Intact:
+			summary.Intact++
+			logging.Success(log, "Intact", result.Path)
+		case result.Repaired:
+			summary.Repaired++
+			logging.Success(log, "Repaired", result.Path,
+				"blocks", result.Repair.RepairedBlocks,
+				"crc32", result.Repair.CRC32)
+		default:
+			summary.Unrecoverable++
+			logging.Failure(log, "Unrecoverable", result.Path,
+				fmt.Errorf("%d of %d damaged blocks couldn't be rebuilt", result.Repair.UnrecoverableBlocks, result.Repair.DamagedBlocks),
+				"damaged_parity", result.Repair.DamagedParity)
+		}
+
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	logging.Summary(log, "Repair complete",
+		"files", summary.Files,
+		"intact", summary.Intact,
+		"repaired", summary.Repaired,
+		"unrecoverable", summary.Unrecoverable,
+		"failed", summary.Failed,
+		logging.KeyDuration, time.Since(start))
+
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if summary.Unrecoverable > 0 {
+		return &ExitError{Code: ExitMismatch, Err: fmt.Errorf("%d of %d files could not be repaired", summary.Unrecoverable, summary.Files)}
+	}
+	return filesFailedError(summary.Failed, summary.Files)
+}
+
+// findParityFiles resolves the arguments to parity files: directories are searched,
+// parity files are used as given and other files are looked up by name
+func (cmd *ParityRepairCmd) findParityFiles() ([]string, error) {
+	var files []string
+	for _, path := range cmd.Paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+		}
+
+		switch {
+		case fi.IsDir():
+			found, err := parity.FindParityFiles(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
+			}
+			slices.Sort(found)
+			files = append(files, found...)
+		case parity.IsParityFile(path):
+			files = append(files, path)
+		default:
+			parityPath := parity.Path(path)
+			if _, err := os.Stat(parityPath); err != nil {
+				return nil, fmt.Errorf("no parity file for %s: %w", path, err)
+			}
+			files = append(files, parityPath)
+		}
+	}
+	return files, nil
+}
+
+// repairFromParity verifies the file a parity file protects and repairs it if needed.
+// Tagged files are checked against their tag, others against the CRC32 in the parity file.
+func repairFromParity(parityPath string, log *slog.Logger) *parityRepairResult {
+	path := parity.ProtectedPath(parityPath)
+	result := &parityRepairResult{Path: path, ParityPath: parityPath}
+
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("protected file is missing (renamed or moved?): %w", err)
+		}
+		result.Error = err
+		return result
+	}
+
+	_, tagged := video.ExtractHashFromFilename(filepath.Base(path))
+	if tagged {
+		verify := video.VerifyFile(path)
+		if verify.Error != nil {
+			result.Error = verify.Error
+			return result
+		}
+		if verify.Verified {
+			result.Intact = true
+			return result
+		}
+		logging.Start(log, "Hash mismatch, repairing", path, "expected", verify.ExpectedHash, "actual", verify.ActualHash)
+	}
+
+	repair, err := parity.Repair(path, parityPath)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.Repair = repair
+
+	if !tagged && repair.DamagedBlocks == 0 && !repair.Resized {
+		result.Intact = repair.OK()
+		if !result.Intact {
+			result.Error = fmt.Errorf("no damaged blocks but CRC32 %s doesn't match %s; the parity file doesn't belong to this file", repair.CRC32, repair.ExpectedCRC32)
+		}
+		return result
+	}
+
+	// Confirm against the tag, the checksum verify will use
+	if tagged {
+		confirm := video.VerifyFile(path)
+		result.Repaired = confirm.Error == nil && confirm.Verified
+	} else {
+		result.Repaired = repair.OK()
+	}
+	return result
+}
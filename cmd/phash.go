@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/corona10/goimagehash"
-	"github.com/lepinkainen/videotagger/ui"
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
 	"github.com/lepinkainen/videotagger/video"
 )
 
@@ -14,17 +17,33 @@ import (
 type PhashCmd struct {
 	Files     []string `arg:"" name:"files" help:"Video files to compare" type:"existingfile"`
 	Threshold int      `help:"Hamming distance threshold for similarity (0-64)" default:"10"`
+
+	output.Flags `embed:""`
+}
+
+// phashSummary is the final record of a phash run in machine-readable output
+type phashSummary struct {
+	Files        int     `json:"files"`
+	Hashed       int     `json:"hashed"`
+	Failed       int     `json:"failed"`
+	SimilarPairs int     `json:"similarPairs"`
+	Threshold    int     `json:"threshold"`
+	DurationSecs float64 `json:"durationSecs"`
 }
 
 // Run executes the perceptual hash comparison command, comparing all pairs of videos
 // and reporting any that fall within the similarity threshold (lower distance = more similar).
 func (cmd *PhashCmd) Run() error {
+	log := logging.ForOp("phash")
+	out := output.New(os.Stdout, cmd.Output, "phash")
+	runStart := time.Now()
+
 	if len(cmd.Files) < 2 {
-		fmt.Printf("%s\n", ui.ErrorStyle.Render("❌ Need at least 2 files to compare"))
+		logging.Failure(log, "Need at least 2 files to compare", "", nil)
 		return nil
 	}
 
-	fmt.Printf("%s\n", ui.InfoStyle.Render(fmt.Sprintf("Calculating perceptual hashes for %d files...", len(cmd.Files))))
+	log.Info("Calculating perceptual hashes", "files", len(cmd.Files))
 
 	type FileHash struct {
 		File string
@@ -32,44 +51,56 @@ func (cmd *PhashCmd) Run() error {
 	}
 
 	var fileHashes []FileHash
+	summary := phashSummary{Files: len(cmd.Files), Threshold: cmd.Threshold}
 
 	for _, videoFile := range cmd.Files {
 		if !video.IsVideoFile(videoFile) {
-			fmt.Printf("⚠️  %s is not a video file, skipping\n", videoFile)
+			logging.Skipped(log, "Not a video file", videoFile)
 			continue
 		}
 
+		start := time.Now()
 		hash, err := video.CalculateVideoPerceptualHash(videoFile)
 		if err != nil {
-			fmt.Printf("%s\n", ui.ErrorStyle.Render(fmt.Sprintf("❌ Error calculating perceptual hash for %s: %v", videoFile, err)))
+			logging.Failure(log, "Error calculating perceptual hash", videoFile, err, logging.KeyDuration, time.Since(start))
+			summary.Failed++
 			continue
 		}
 
 		fileHashes = append(fileHashes, FileHash{File: videoFile, Hash: hash})
-		fmt.Printf("%s\n", ui.SuccessStyle.Render(fmt.Sprintf("✅ Processed %s", videoFile)))
+		summary.Hashed++
+		logging.Success(log, "Processed", videoFile, logging.KeyDuration, time.Since(start))
 	}
 
-	fmt.Printf("\n%s\n", ui.InfoStyle.Render(fmt.Sprintf("Comparing %d files for similarity (threshold: %d):", len(fileHashes), cmd.Threshold)))
+	log.Info("Comparing files for similarity", "files", len(fileHashes), "threshold", cmd.Threshold)
 
-	found := false
 	for i := range len(fileHashes) {
 		for j := i + 1; j < len(fileHashes); j++ {
 			distance, err := fileHashes[i].Hash.Distance(fileHashes[j].Hash)
 			if err != nil {
-				fmt.Printf("%s\n", ui.ErrorStyle.Render(fmt.Sprintf("❌ Error comparing %s and %s: %v", fileHashes[i].File, fileHashes[j].File, err)))
+				logging.Failure(log, "Error comparing", fileHashes[i].File, err, "other", fileHashes[j].File)
 				continue
 			}
 
 			if distance <= cmd.Threshold {
-				fmt.Printf("🎯 Similar (distance %d): %s ↔ %s\n", distance, fileHashes[i].File, fileHashes[j].File)
-				found = true
+				log.Info("Similar", logging.KeyPath, fileHashes[i].File, "other", fileHashes[j].File, "distance", distance)
+				summary.SimilarPairs++
+
+				pair := video.SimilarPair{Path: fileHashes[i].File, Other: fileHashes[j].File, Distance: distance}
+				if err := out.Emit(output.TypePair, pair, nil); err != nil {
+					return fmt.Errorf("failed to write output: %w", err)
+				}
 			}
 		}
 	}
 
-	if !found {
-		fmt.Printf("%s\n", ui.SuccessStyle.Render("✅ No similar files found within threshold"))
+	if summary.SimilarPairs == 0 {
+		logging.Summary(log, "No similar files found within threshold", "threshold", cmd.Threshold)
 	}
 
-	return nil
+	summary.DurationSecs = time.Since(runStart).Seconds()
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(summary.Failed, summary.Files)
 }
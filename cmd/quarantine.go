@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/quarantine"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// QuarantineCmd groups commands for files moved aside by verify --on-fail quarantine=DIR.
+type QuarantineCmd struct {
+	Restore QuarantineRestoreCmd `cmd:"" help:"Move quarantined files back to their original paths"`
+}
+
+// QuarantineRestoreCmd puts quarantined files back where verify found them.
+type QuarantineRestoreCmd struct {
+	Paths []string `arg:"" name:"paths" help:"Quarantine directories (searched recursively), quarantined files or their records" type:"path"`
+}
+
+// Run restores every quarantined file found in the given paths.
+func (cmd *QuarantineRestoreCmd) Run() error {
+	log := logging.ForOp("quarantine-restore")
+	start := time.Now()
+
+	var records []string
+	for _, path := range cmd.Paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return EnvironmentError(fmt.Errorf("cannot access %s: %w", path, err))
+		}
+
+		if fi.IsDir() {
+			found, err := quarantine.FindRecords(path)
+			if err != nil {
+				return EnvironmentError(fmt.Errorf("failed to scan directory %s: %w", path, err))
+			}
+			slices.Sort(found)
+			records = append(records, found...)
+			continue
+		}
+
+		record, err := quarantine.ResolveRecord(path)
+		if err != nil {
+			return EnvironmentError(err)
+		}
+		records = append(records, record)
+	}
+
+	restored, failed := 0, 0
+	for _, recordPath := range records {
+		record, err := quarantine.Restore(recordPath)
+		if err != nil {
+			logging.Failure(log, "Error restoring file", quarantine.FilePath(recordPath), err)
+			failed++
+			continue
+		}
+
+		logging.Success(log, "Restored", record.OriginalPath, "reason", record.Reason)
+		restored++
+	}
+
+	logging.Summary(log, "Restore complete",
+		"restored", restored,
+		"failed", failed,
+		logging.KeyDuration, time.Since(start))
+
+	return filesFailedError(failed, len(records))
+}
+
+// parseOnFail parses the --on-fail action, returning the quarantine directory.
+// An empty action means failing files are only reported.
+func parseOnFail(action string) (string, error) {
+	if action == "" {
+		return "", nil
+	}
+
+	name, dir, _ := strings.Cut(action, "=")
+	if name != "quarantine" {
+		return "", fmt.Errorf("unknown --on-fail action %q, expected quarantine=DIR", name)
+	}
+	if dir == "" {
+		return "", fmt.Errorf("--on-fail quarantine needs a directory, e.g. quarantine=/mnt/quarantine")
+	}
+
+	return dir, nil
+}
+
+// quarantineFailures moves files that failed verification (hash mismatches and files that don't
+// decode cleanly) into the quarantine directory, recording where each one went in its result.
+// Files that can't be moved stay in place and are reported.
+func quarantineFailures(results []*video.VerifyResult, dir string, log *slog.Logger) {
+	for _, result := range results {
+		if result == nil || (!result.Mismatch() && !result.Corrupt()) {
+			continue
+		}
+
+		path := result.Path
+		if result.RenamedTo != "" {
+			path = result.RenamedTo
+		}
+
+		record := quarantine.Record{Reason: "mismatch"}
+		if result.Mismatch() {
+			record.ExpectedHash = result.ExpectedHash
+			record.ActualHash = result.ActualHash
+		} else {
+			record.Reason = string(result.Decode.Problem)
+			record.Detail = result.Decode.Summary()
+		}
+
+		dst, err := quarantine.Move(dir, path, record)
+		if err != nil {
+			logging.Failure(log, "Error quarantining file", path, err)
+			continue
+		}
+		result.QuarantinedTo = dst
+	}
+}
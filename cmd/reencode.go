@@ -2,14 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
-	"runtime"
-	"slices"
 	"sync"
+	"time"
 
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
 	"github.com/lepinkainen/videotagger/types"
-	"github.com/lepinkainen/videotagger/ui"
-	"github.com/lepinkainen/videotagger/utils"
 	"github.com/lepinkainen/videotagger/video"
 )
 
@@ -23,6 +23,8 @@ type ReencodeCmd struct {
 	MinSavings   float64  `help:"Minimum size reduction required (0.0-1.0)" default:"0.20"`
 	KeepOriginal bool     `help:"Keep original files as .bak"`
 	DryRun       bool     `help:"Show what would be processed without making changes"`
+
+	output.Flags `embed:""`
 }
 
 // Run executes the reencode command, processing files with FFmpeg to convert to H.265.
@@ -33,11 +35,13 @@ func (cmd *ReencodeCmd) Run(appCtx *types.AppContext) error {
 	if appCtx != nil {
 		version = appCtx.Version
 	}
+	log := logging.ForOp("reencode")
+	out := output.New(os.Stdout, cmd.Output, "reencode")
 
 	// Expand directories to video files
 	expandedFiles, err := cmd.ExpandDirectories()
 	if err != nil {
-		return fmt.Errorf("failed to expand directories: %w", err)
+		return EnvironmentError(fmt.Errorf("failed to expand directories: %w", err))
 	}
 	cmd.Files = expandedFiles
 
@@ -46,26 +50,17 @@ func (cmd *ReencodeCmd) Run(appCtx *types.AppContext) error {
 		filtered, skipped := cmd.filterAlreadyH265Files()
 		cmd.Files = filtered
 		if skipped > 0 {
-			fmt.Printf("⏭️  Skipped %d files already encoded with H.265\n", skipped)
+			log.Warn("Skipped files already encoded with H.265", logging.KeyStatus, logging.StatusSkipped, "files", skipped)
 		}
 	}
 
 	if len(cmd.Files) == 0 {
-		fmt.Println("🎯 No files need re-encoding.")
-		return nil
+		logging.Summary(log, "No files need re-encoding")
+		return cmd.emitSummary(&reencodeStats{}, out)
 	}
 
 	// Set default worker count based on drive type
-	workers := cmd.Workers
-	if workers <= 0 {
-		// Check if any files are on network drives
-		if slices.ContainsFunc(cmd.Files, utils.IsNetworkDrive) {
-			workers = 1 // Use single worker for network drives
-			fmt.Printf("⚠️  Network drive detected, using 1 worker for optimal performance\n")
-		} else {
-			workers = runtime.NumCPU() // Use all CPUs for local drives
-		}
-	}
+	workers := workerCount(cmd.Workers, cmd.Files, log)
 
 	// Create re-encode options
 	options := &video.ReencodeOptions{
@@ -75,115 +70,165 @@ func (cmd *ReencodeCmd) Run(appCtx *types.AppContext) error {
 		KeepOriginal: cmd.KeepOriginal,
 	}
 
-	fmt.Println(ui.HeaderStyle.Render(fmt.Sprintf("Video Re-encoder %s", version)))
+	logging.Header(log, fmt.Sprintf("Video Re-encoder %s", version))
 
 	if cmd.DryRun {
-		fmt.Println(ui.ProcessingStyle.Render("🔍 DRY RUN MODE - No files will be modified"))
-		return cmd.runDryRun()
+		log.Info("DRY RUN MODE - No files will be modified")
+		return cmd.runDryRun(log, out)
 	}
 
-	fmt.Println(ui.ProcessingStyle.Render(fmt.Sprintf("🎬 Re-encoding %d files to H.265 with %d workers:", len(cmd.Files), workers)))
-	fmt.Printf("⚙️  Settings: CRF=%d, Preset=%s, Min Savings=%.1f%%\n",
-		cmd.CRF, cmd.Preset, cmd.MinSavings*100)
+	log.Info("Re-encoding files to H.265",
+		"files", len(cmd.Files),
+		"workers", workers,
+		"crf", cmd.CRF,
+		"preset", cmd.Preset,
+		"min_savings_percent", cmd.MinSavings*100)
 
 	if len(cmd.Files) > 1 && workers > 1 {
-		return cmd.runParallel(workers, options)
+		return cmd.runParallel(workers, options, log, out)
 	}
 
 	// Sequential processing for single file or single worker
-	return cmd.runSequential(options)
+	return cmd.runSequential(options, log, out)
 }
 
-// runDryRun analyzes files without making changes
-func (cmd *ReencodeCmd) runDryRun() error {
-	fmt.Printf("📊 Analyzing %d files:\n\n", len(cmd.Files))
+// runDryRun analyzes files without making changes.
+// Machine-readable records report files that would be re-encoded as neither re-encoded nor skipped.
+func (cmd *ReencodeCmd) runDryRun(log *slog.Logger, out *output.Writer) error {
+	log.Info("Analyzing files", "files", len(cmd.Files))
 
 	var totalOriginalSize int64
 	var estimatedSavings int64
 	processableCount := 0
 
 	for _, videoFile := range cmd.Files {
-		fmt.Printf("📹 %s\n", videoFile)
-
-		// Get basic file info
-		size, err := video.GetFileSize(videoFile)
-		if err != nil {
-			fmt.Printf("   ❌ Error: %v\n", err)
-			continue
+		result, estimatedSaving := cmd.analyzeFile(videoFile, log)
+		if result.Error == nil {
+			totalOriginalSize += result.OriginalSize
+			if !result.WasSkipped {
+				estimatedSavings += estimatedSaving
+				processableCount++
+			}
 		}
 
-		codec, err := video.GetVideoCodec(videoFile)
-		if err != nil {
-			fmt.Printf("   ❌ Error getting codec: %v\n", err)
-			continue
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
+	}
 
-		isH265, err := video.IsH265(videoFile)
-		if err != nil {
-			fmt.Printf("   ❌ Error checking codec: %v\n", err)
-			continue
-		}
+	summary := []any{
+		"files", len(cmd.Files),
+		"would_process", processableCount,
+		"total_bytes", totalOriginalSize,
+		"estimated_saving_bytes", estimatedSavings,
+	}
+	if estimatedSavings > 0 {
+		summary = append(summary, "estimated_reduction_percent", float64(estimatedSavings)/float64(totalOriginalSize)*100)
+	}
+	logging.Summary(log, "Dry run summary", summary...)
+
+	if err := out.Summary(dryRunSummary{
+		Files:            len(cmd.Files),
+		WouldProcess:     processableCount,
+		TotalSize:        totalOriginalSize,
+		EstimatedSavings: estimatedSavings,
+		DryRun:           true,
+	}); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
 
-		fmt.Printf("   📏 Size: %.1f MB\n", float64(size)/(1024*1024))
-		fmt.Printf("   🎥 Codec: %s\n", codec)
-
-		if isH265 {
-			fmt.Printf("   ⏭️  Already H.265, would skip\n")
-		} else {
-			// Rough estimate: H.265 typically saves 20-50% vs H.264
-			estimatedSaving := int64(float64(size) * 0.35) // Conservative 35% estimate
-			fmt.Printf("   💾 Estimated saving: ~%.1f MB (35%%)\n", float64(estimatedSaving)/(1024*1024))
-			estimatedSavings += estimatedSaving
-			processableCount++
-		}
+// analyzeFile inspects a file for a dry run and estimates the savings from re-encoding it
+func (cmd *ReencodeCmd) analyzeFile(videoFile string, log *slog.Logger) (*video.ReencodeResult, int64) {
+	result := &video.ReencodeResult{OriginalPath: videoFile}
 
-		totalOriginalSize += size
-		fmt.Println()
+	// Get basic file info
+	size, err := video.GetFileSize(videoFile)
+	if err != nil {
+		logging.Failure(log, "Error getting file size", videoFile, err)
+		result.Error = err
+		return result, 0
 	}
+	result.OriginalSize = size
 
-	fmt.Printf("📈 Summary:\n")
-	fmt.Printf("   Total files: %d\n", len(cmd.Files))
-	fmt.Printf("   Would process: %d files\n", processableCount)
-	fmt.Printf("   Total size: %.1f MB\n", float64(totalOriginalSize)/(1024*1024))
-	fmt.Printf("   Estimated savings: ~%.1f MB\n", float64(estimatedSavings)/(1024*1024))
+	codec, err := video.GetVideoCodec(videoFile)
+	if err != nil {
+		logging.Failure(log, "Error getting codec", videoFile, err)
+		result.Error = err
+		return result, 0
+	}
+	result.OriginalCodec = codec
 
-	if estimatedSavings > 0 {
-		savingsPercent := float64(estimatedSavings) / float64(totalOriginalSize) * 100
-		fmt.Printf("   Estimated reduction: ~%.1f%%\n", savingsPercent)
+	isH265, err := video.IsH265(videoFile)
+	if err != nil {
+		logging.Failure(log, "Error checking codec", videoFile, err)
+		result.Error = err
+		return result, 0
 	}
 
-	return nil
+	if isH265 {
+		logging.Skipped(log, "Already H.265, would skip", videoFile, "size_bytes", size, "codec", codec)
+		result.WasSkipped = true
+		result.SkipReason = "already H.265"
+		return result, 0
+	}
+
+	// Rough estimate: H.265 typically saves 20-50% vs H.264
+	estimatedSaving := int64(float64(size) * 0.35) // Conservative 35% estimate
+	log.Info("Would re-encode", logging.KeyPath, videoFile,
+		"size_bytes", size,
+		"codec", codec,
+		"estimated_saving_bytes", estimatedSaving)
+	return result, estimatedSaving
 }
 
 // runSequential processes files one by one
-func (cmd *ReencodeCmd) runSequential(options *video.ReencodeOptions) error {
+func (cmd *ReencodeCmd) runSequential(options *video.ReencodeOptions, log *slog.Logger, out *output.Writer) error {
 	stats := &reencodeStats{}
+	start := time.Now()
 
 	for i, videoFile := range cmd.Files {
-		fmt.Printf("\n[%d/%d] Processing: %s\n", i+1, len(cmd.Files), videoFile)
+		logging.Start(log, "Processing", videoFile, "index", i+1, "total", len(cmd.Files))
+		fileStart := time.Now()
 		result := video.ReencodeToH265(videoFile, options)
-		cmd.handleResult(result, stats)
+		cmd.handleResult(result, stats, log, time.Since(fileStart))
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
 	}
 
-	cmd.printSummary(stats)
-	return nil
+	stats.DurationSecs = time.Since(start).Seconds()
+	cmd.printSummary(stats, log)
+	return cmd.emitSummary(stats, out)
 }
 
 // runParallel processes files using worker pools
-func (cmd *ReencodeCmd) runParallel(workers int, options *video.ReencodeOptions) error {
+func (cmd *ReencodeCmd) runParallel(workers int, options *video.ReencodeOptions, log *slog.Logger, out *output.Writer) error {
 	jobs := make(chan string, len(cmd.Files))
-	results := make(chan *video.ReencodeResult, len(cmd.Files))
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var outErr error
+	stats := &reencodeStats{}
+	runStart := time.Now()
 
 	// Start workers
 	for i := range workers {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
+			workerLog := log.With(logging.KeyWorker, workerID+1)
 			for videoFile := range jobs {
-				fmt.Printf("Worker %d: Processing %s\n", workerID+1, videoFile)
+				logging.Start(workerLog, "Processing", videoFile)
+				start := time.Now()
 				result := video.ReencodeToH265(videoFile, options)
-				results <- result
+
+				mu.Lock()
+				cmd.handleResult(result, stats, workerLog, time.Since(start))
+				if err := out.Emit(output.TypeFile, result, result.Error); err != nil && outErr == nil {
+					outErr = err
+				}
+				mu.Unlock()
 			}
 		}(i)
 	}
@@ -196,40 +241,37 @@ func (cmd *ReencodeCmd) runParallel(workers int, options *video.ReencodeOptions)
 
 	// Wait for completion
 	wg.Wait()
-	close(results)
 
-	// Process results
-	stats := &reencodeStats{}
-	for result := range results {
-		cmd.handleResult(result, stats)
+	if outErr != nil {
+		return fmt.Errorf("failed to write output: %w", outErr)
 	}
 
-	cmd.printSummary(stats)
-	return nil
+	stats.DurationSecs = time.Since(runStart).Seconds()
+	cmd.printSummary(stats, log)
+	return cmd.emitSummary(stats, out)
 }
 
 // handleResult processes a re-encoding result and updates statistics
-func (cmd *ReencodeCmd) handleResult(result *video.ReencodeResult, stats *reencodeStats) {
+func (cmd *ReencodeCmd) handleResult(result *video.ReencodeResult, stats *reencodeStats, log *slog.Logger, elapsed time.Duration) {
 	if result.Error != nil {
-		fmt.Printf("%s\n", ui.ErrorStyle.Render(fmt.Sprintf("❌ Error: %v", result.Error)))
+		logging.Failure(log, "Error re-encoding", result.OriginalPath, result.Error, logging.KeyDuration, elapsed)
 		stats.ErrorCount++
 		return
 	}
 
 	if result.WasSkipped {
-		fmt.Printf("⏭️  Skipped: %s\n", result.SkipReason)
+		logging.Skipped(log, "Skipped", result.OriginalPath, "reason", result.SkipReason, logging.KeyDuration, elapsed)
 		stats.SkippedCount++
 		return
 	}
 
 	if result.WasReencoded {
-		sizeMB := float64(result.OriginalSize) / (1024 * 1024)
-		newSizeMB := float64(result.NewSize) / (1024 * 1024)
-		savingsMB := float64(result.SizeSavings) / (1024 * 1024)
-
-		fmt.Printf("%s\n", ui.SuccessStyle.Render(fmt.Sprintf("✅ %s → %s", result.OriginalCodec, "H.265")))
-		fmt.Printf("   📏 %.1f MB → %.1f MB (saved %.1f MB, %.1f%%)\n",
-			sizeMB, newSizeMB, savingsMB, result.SavingsPercent*100)
+		logging.Success(log, fmt.Sprintf("%s → H.265", result.OriginalCodec), result.OriginalPath,
+			"original_bytes", result.OriginalSize,
+			"new_bytes", result.NewSize,
+			"saved_bytes", result.SizeSavings,
+			"saved_percent", result.SavingsPercent*100,
+			logging.KeyDuration, elapsed)
 
 		stats.ProcessedCount++
 		stats.TotalOriginalSize += result.OriginalSize
@@ -239,23 +281,31 @@ func (cmd *ReencodeCmd) handleResult(result *video.ReencodeResult, stats *reenco
 }
 
 // printSummary displays final statistics
-func (cmd *ReencodeCmd) printSummary(stats *reencodeStats) {
-	fmt.Printf("\n%s\n", ui.HeaderStyle.Render("📊 Re-encoding Summary"))
-	fmt.Printf("   Processed: %d files\n", stats.ProcessedCount)
-	fmt.Printf("   Skipped: %d files\n", stats.SkippedCount)
-	fmt.Printf("   Errors: %d files\n", stats.ErrorCount)
+func (cmd *ReencodeCmd) printSummary(stats *reencodeStats, log *slog.Logger) {
+	summary := []any{
+		"processed", stats.ProcessedCount,
+		"skipped", stats.SkippedCount,
+		"errors", stats.ErrorCount,
+	}
 
 	if stats.ProcessedCount > 0 {
-		totalOriginalMB := float64(stats.TotalOriginalSize) / (1024 * 1024)
-		totalNewMB := float64(stats.TotalNewSize) / (1024 * 1024)
-		totalSavingsMB := float64(stats.TotalSavings) / (1024 * 1024)
-		totalSavingsPercent := float64(stats.TotalSavings) / float64(stats.TotalOriginalSize) * 100
-
-		fmt.Printf("   Total space saved: %.1f MB (%.1f%%)\n", totalSavingsMB, totalSavingsPercent)
-		fmt.Printf("   Size reduction: %.1f MB → %.1f MB\n", totalOriginalMB, totalNewMB)
+		summary = append(summary,
+			"original_bytes", stats.TotalOriginalSize,
+			"new_bytes", stats.TotalNewSize,
+			"saved_bytes", stats.TotalSavings,
+			"saved_percent", float64(stats.TotalSavings)/float64(stats.TotalOriginalSize)*100)
 	}
 
-	fmt.Printf("\n%s\n", ui.SuccessStyle.Render("🎉 Re-encoding complete!"))
+	logging.Summary(log, "Re-encoding complete", summary...)
+}
+
+// emitSummary writes the final statistics record for machine-readable output,
+// returning an exit error if any file failed
+func (cmd *ReencodeCmd) emitSummary(stats *reencodeStats, out *output.Writer) error {
+	if err := out.Summary(stats); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(stats.ErrorCount, stats.ProcessedCount+stats.SkippedCount+stats.ErrorCount)
 }
 
 // filterAlreadyH265Files removes files that are already H.265 encoded
@@ -275,37 +325,25 @@ func (cmd *ReencodeCmd) filterAlreadyH265Files() (filtered []string, skipped int
 
 // ExpandDirectories expands any directory arguments into lists of video files
 func (cmd *ReencodeCmd) ExpandDirectories() ([]string, error) {
-	var expandedFiles []string
-
-	for _, path := range cmd.Files {
-		// Check if path exists
-		fi, err := os.Stat(path)
-		if err != nil {
-			return nil, fmt.Errorf("cannot access %s: %w", path, err)
-		}
-
-		if fi.IsDir() {
-			// Directory: find all video files recursively
-			videoFiles, err := video.FindVideoFilesRecursively(path)
-			if err != nil {
-				return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
-			}
-			expandedFiles = append(expandedFiles, videoFiles...)
-		} else {
-			// Regular file: add as-is
-			expandedFiles = append(expandedFiles, path)
-		}
-	}
-
-	return expandedFiles, nil
+	return expandPaths(cmd.Files, video.FindVideoFilesRecursively)
 }
 
-// reencodeStats tracks statistics during re-encoding
+// reencodeStats tracks statistics during re-encoding and is the summary record in machine-readable output
 type reencodeStats struct {
-	ProcessedCount    int
-	SkippedCount      int
-	ErrorCount        int
-	TotalOriginalSize int64
-	TotalNewSize      int64
-	TotalSavings      int64
+	ProcessedCount    int     `json:"processed"`
+	SkippedCount      int     `json:"skipped"`
+	ErrorCount        int     `json:"errors"`
+	TotalOriginalSize int64   `json:"totalOriginalSize"`
+	TotalNewSize      int64   `json:"totalNewSize"`
+	TotalSavings      int64   `json:"totalSavings"`
+	DurationSecs      float64 `json:"durationSecs"`
+}
+
+// dryRunSummary is the summary record of a dry run in machine-readable output
+type dryRunSummary struct {
+	Files            int   `json:"files"`
+	WouldProcess     int   `json:"wouldProcess"`
+	TotalSize        int64 `json:"totalSize"`
+	EstimatedSavings int64 `json:"estimatedSavings"`
+	DryRun           bool  `json:"dryRun"`
 }
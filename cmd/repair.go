@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/types"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// RepairCmd recovers what it can from truncated or unindexed video files by remuxing them
+// into new files. Files that decode cleanly are skipped, and originals are only replaced
+// when asked to.
+type RepairCmd struct {
+	Files        []string `arg:"" name:"files" help:"Damaged video files, or directories to search for damaged video files" type:"path"`
+	Replace      bool     `help:"Replace each original with its repaired file (filename tags are dropped, since the hash no longer matches)"`
+	KeepOriginal bool     `help:"With --replace, keep originals as .bak"`
+
+	output.Flags `embed:""`
+}
+
+// repairSummary is the final record of a repair run in machine-readable output
+type repairSummary struct {
+	Files        int     `json:"files"`
+	Repaired     int     `json:"repaired"`
+	Replaced     int     `json:"replaced"`
+	Skipped      int     `json:"skipped"`
+	Failed       int     `json:"failed"`
+	DurationSecs float64 `json:"durationSecs"`
+}
+
+// Run repairs each file in turn; ffmpeg already reads as fast as the disk allows.
+func (cmd *RepairCmd) Run(appCtx *types.AppContext) error {
+	version := types.DefaultVersion
+	if appCtx != nil {
+		version = appCtx.Version
+	}
+	log := logging.ForOp("repair")
+	out := output.New(os.Stdout, cmd.Output, "repair")
+	start := time.Now()
+
+	if cmd.KeepOriginal && !cmd.Replace {
+		return fmt.Errorf("--keep-original requires --replace")
+	}
+
+	files, err := expandPaths(cmd.Files, video.FindAllVideoFilesRecursively)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to expand directories: %w", err))
+	}
+
+	logging.Header(log, fmt.Sprintf("Video Repair %s", version))
+	log.Info("Repairing files", "files", len(files), "replace", cmd.Replace)
+
+	opts := video.RepairOptions{Replace: cmd.Replace, KeepOriginal: cmd.KeepOriginal}
+	summary := repairSummary{}
+	for _, path := range files {
+		if video.IsRepairedCopy(path) {
+			// Output of an earlier run found while searching a directory
+			continue
+		}
+		summary.Files++
+
+		logging.Start(log, "Checking", path)
+		fileStart := time.Now()
+		result := video.RepairVideo(path, opts)
+
+		switch {
+		case result.WasSkipped:
+			summary.Skipped++
+			logging.Skipped(log, "Not damaged", path, "reason", result.SkipReason, logging.KeyDuration, time.Since(fileStart))
+		case result.Error != nil:
+			summary.Failed++
+			attrs := []any{logging.KeyDuration, time.Since(fileStart)}
+			if len(result.Attempts) > 0 {
+				attrs = append(attrs, "attempts", attemptSteps(result.Attempts))
+			}
+			if result.WasRepaired {
+				summary.Repaired++
+				attrs = append(attrs, "repaired", result.RepairedPath)
+			}
+			logging.Failure(log, "Could not repair", path, result.Error, attrs...)
+		default:
+			summary.Repaired++
+			attrs := []any{
+				"original", path,
+				"problem", result.Problem,
+				"step", result.Step,
+				"salvaged", formatSecs(result.SalvagedDurationSecs),
+			}
+			if result.DeclaredDurationSecs > 0 {
+				attrs = append(attrs,
+					"declared", formatSecs(result.DeclaredDurationSecs),
+					"salvaged_percent", fmt.Sprintf("%.1f", result.SalvagedRatio()*100))
+			}
+			if result.Replaced {
+				summary.Replaced++
+				attrs = append(attrs, "replaced", true)
+			}
+			if result.BackupPath != "" {
+				attrs = append(attrs, "backup", result.BackupPath)
+			}
+			logging.Success(log, "Repaired", result.RepairedPath, append(attrs, logging.KeyDuration, time.Since(fileStart))...)
+		}
+
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	summary.DurationSecs = time.Since(start).Seconds()
+	logging.Summary(log, "Repair complete",
+		"files", summary.Files,
+		"repaired", summary.Repaired,
+		"replaced", summary.Replaced,
+		"skipped", summary.Skipped,
+		"failed", summary.Failed,
+		logging.KeyDuration, time.Since(start))
+
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(summary.Failed, summary.Files)
+}
+
+// attemptSteps lists the recovery steps that were tried, e.g. "remux,reindex"
+func attemptSteps(attempts []video.RepairAttempt) string {
+	steps := make([]string, len(attempts))
+	for i, a := range attempts {
+		steps[i] = string(a.Step)
+	}
+	return strings.Join(steps, ",")
+}
+
+// formatSecs renders a duration in seconds for logs, e.g. "1h2m3s"
+func formatSecs(secs float64) string {
+	return time.Duration(secs * float64(time.Second)).Round(time.Second).String()
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/scrub"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// maxStaleLogged limits how many overdue files are logged individually, since the first
+// scrub of an archive reports every file
+const maxStaleLogged = 20
+
+// staleFile is a stale report entry in machine-readable output
+type staleFile struct {
+	Path         string    `json:"path"`
+	LastVerified time.Time `json:"lastVerified,omitzero"`
+	LastResult   string    `json:"lastResult,omitempty"`
+}
+
+// runScrub verifies the files whose last successful verification is oldest, as many as fit
+// in the budget, records the outcomes in the scrub state and reports files that are overdue.
+// Files the budget doesn't reach are deferred to a later run rather than counted as interrupted.
+func (cmd *VerifyCmd) runScrub(version, quarantineDir string, start time.Time, log *slog.Logger, out *output.Writer) error {
+	budget, err := scrub.ParseBudget(cmd.Budget)
+	if err != nil {
+		return err
+	}
+
+	statePath := cmd.State
+	if statePath == "" {
+		if statePath, err = scrub.DefaultPath(); err != nil {
+			return EnvironmentError(fmt.Errorf("failed to locate scrub state: %w", err))
+		}
+	}
+	state, err := scrub.Load(statePath)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to load scrub state: %w", err))
+	}
+
+	candidates := make([]scrub.Candidate, 0, len(cmd.Files))
+	for _, path := range cmd.Files {
+		candidate := scrub.Candidate{Path: path}
+		if fi, err := os.Stat(path); err == nil {
+			candidate.Size = fi.Size()
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	planned := scrub.Plan(candidates, state, budget)
+	files := make([]string, len(planned))
+	var plannedBytes int64
+	for i, c := range planned {
+		files[i] = c.Path
+		plannedBytes += c.Size
+	}
+
+	planAttrs := []any{"files", len(files), "deferred", len(cmd.Files) - len(files), "planned_bytes", plannedBytes}
+	switch {
+	case budget.Duration > 0:
+		planAttrs = append(planAttrs, "budget", budget.Duration)
+	case budget.Bytes > 0:
+		planAttrs = append(planAttrs, "budget_bytes", budget.Bytes)
+	}
+	log.Info("Scrub plan", append(planAttrs, "state", statePath)...)
+
+	// A time budget stops new files from starting; files already being hashed finish
+	ctx := context.Background()
+	if budget.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget.Duration)
+		defer cancel()
+	}
+
+	workers := workerCount(cmd.Workers, files, log)
+	results, err := verifyFiles(ctx, files, workers, cmd.checks(), version, log)
+	if err != nil {
+		return err
+	}
+
+	if quarantineDir != "" {
+		quarantineFailures(results, quarantineDir, log)
+	}
+
+	// Only files that were verified are reported and recorded; the rest wait for the next run
+	var reached []*video.VerifyResult
+	var reachedPaths []string
+	for i, result := range results {
+		if result != nil {
+			reached = append(reached, result)
+			reachedPaths = append(reachedPaths, files[i])
+		}
+	}
+
+	summary := verifySummary{Files: len(reached), Deferred: len(cmd.Files) - len(reached)}
+	if err := reportVerifyResults(reached, reachedPaths, &summary, log, out); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	renamed := make(map[string]string)
+	for _, result := range reached {
+		path := result.Path
+		if result.RenamedTo != "" {
+			state.Move(path, result.RenamedTo)
+			renamed[path] = result.RenamedTo
+			path = result.RenamedTo
+		}
+		state.Record(path, scrubResult(result), now)
+	}
+	if err := state.Save(); err != nil {
+		return EnvironmentError(fmt.Errorf("failed to save scrub state: %w", err))
+	}
+
+	if cmd.StaleDays > 0 {
+		current := make([]string, len(cmd.Files))
+		for i, path := range cmd.Files {
+			current[i] = path
+			if newPath, ok := renamed[path]; ok {
+				current[i] = newPath
+			}
+		}
+
+		stale := state.Stale(current, now.AddDate(0, 0, -cmd.StaleDays))
+		summary.Stale = len(stale)
+		for i, path := range stale {
+			fileState := state.Get(path)
+			entry := staleFile{Path: path, LastVerified: fileState.LastVerified, LastResult: string(fileState.LastResult)}
+
+			switch {
+			case i >= maxStaleLogged:
+				// Every entry is still in machine-readable output
+			case fileState.LastVerified.IsZero():
+				logging.Skipped(log, "Never verified", path)
+			default:
+				logging.Skipped(log, "Not verified recently", path,
+					"last_verified", fileState.LastVerified.Format(time.DateOnly),
+					"days", int(now.Sub(fileState.LastVerified).Hours()/24))
+			}
+			if err := out.Emit(output.TypeStale, entry, nil); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		if len(stale) > maxStaleLogged {
+			log.Warn("More files overdue for verification", "count", len(stale)-maxStaleLogged, "stale_days", cmd.StaleDays)
+		}
+	}
+
+	return finishVerification(summary, workers, start, log, out)
+}
+
+// scrubResult converts a verification result into the outcome stored in the scrub state
+func scrubResult(result *video.VerifyResult) scrub.Result {
+	switch {
+	case result.Error != nil:
+		return scrub.ResultFailed
+	case result.Mismatch():
+		return scrub.ResultMismatch
+	case result.Corrupt():
+		return scrub.ResultCorrupt
+	case result.WasSkipped:
+		return scrub.ResultSkipped
+	default:
+		return scrub.ResultVerified
+	}
+}
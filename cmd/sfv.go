@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/sfv"
+	"github.com/lepinkainen/videotagger/types"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// SFVCmd groups commands for exchanging checksums with other tools through SFV files.
+// SFV files list CRC32 checksums, the same hash videotagger embeds in filenames.
+type SFVCmd struct {
+	Export SFVExportCmd `cmd:"" help:"Write an .sfv file in each directory containing videos"`
+	Verify SFVVerifyCmd `cmd:"" help:"Check files against .sfv checksum files"`
+}
+
+// SFVExportCmd writes one SFV file per directory, named after the directory.
+type SFVExportCmd struct {
+	Directory string `arg:"" name:"directory" help:"Directory to export checksums for (searched recursively)" type:"existingdir" default:"."`
+	Rehash    bool   `help:"Hash file contents instead of trusting the CRC in tagged filenames (also includes untagged videos)"`
+	Force     bool   `help:"Overwrite existing SFV files"`
+	Workers   int    `help:"Number of parallel workers when rehashing" default:"0"`
+}
+
+// sfvFileEntry pairs a video with its SFV entry while exporting
+type sfvFileEntry struct {
+	path  string
+	entry sfv.Entry
+	err   error
+}
+
+// Run collects checksums for every video and writes them grouped by directory.
+// If appCtx is nil, uses default version information.
+func (cmd *SFVExportCmd) Run(appCtx *types.AppContext) error {
+	version := types.DefaultVersion
+	if appCtx != nil {
+		version = appCtx.Version
+	}
+	log := logging.ForOp("sfv-export")
+	start := time.Now()
+
+	find := video.FindTaggedFilesRecursively
+	if cmd.Rehash {
+		find = video.FindAllVideoFilesRecursively
+	}
+	files, err := find(cmd.Directory)
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to scan directory %s: %w", cmd.Directory, err))
+	}
+	slices.Sort(files)
+
+	workers := 1
+	if cmd.Rehash {
+		workers = workerCount(cmd.Workers, files, log)
+	}
+	log.Info("Collecting checksums", logging.KeyPath, cmd.Directory, "files", len(files), "rehash", cmd.Rehash)
+
+	entries := runWorkerPool(context.Background(), files, workers, func(_ int, path string) sfvFileEntry {
+		entry, err := cmd.entryFor(path)
+		return sfvFileEntry{path: path, entry: entry, err: err}
+	})
+
+	// Group entries by directory, keeping the sorted order within each
+	byDir := make(map[string][]sfv.Entry)
+	var dirs []string
+	failed := 0
+	for _, e := range entries {
+		if e.err != nil {
+			logging.Failure(log, "Error reading checksum", e.path, e.err)
+			failed++
+			continue
+		}
+
+		dir := filepath.Dir(e.path)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], e.entry)
+	}
+	slices.Sort(dirs)
+
+	written, skipped := 0, 0
+	for _, dir := range dirs {
+		sfvPath := filepath.Join(dir, sfvName(dir))
+		if _, err := os.Stat(sfvPath); err == nil && !cmd.Force {
+			logging.Skipped(log, "SFV file exists, use --force to overwrite", sfvPath)
+			skipped++
+			continue
+		}
+
+		if err := sfv.WriteFile(sfvPath, byDir[dir], "videotagger "+version); err != nil {
+			logging.Failure(log, "Error writing SFV file", sfvPath, err)
+			failed++
+			continue
+		}
+
+		logging.Success(log, "Wrote SFV file", sfvPath, "entries", len(byDir[dir]))
+		written++
+	}
+
+	logging.Summary(log, "SFV export complete",
+		"sfv_files", written,
+		"entries", len(files)-failed,
+		"skipped", skipped,
+		"failed", failed,
+		logging.KeyDuration, time.Since(start))
+
+	return filesFailedError(failed, len(files))
+}
+
+// entryFor builds the SFV entry for a video, trusting its tagged name unless rehashing
+func (cmd *SFVExportCmd) entryFor(path string) (sfv.Entry, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return sfv.Entry{}, err
+	}
+
+	entry := sfv.Entry{
+		Name:    filepath.Base(path),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}
+
+	if cmd.Rehash {
+		entry.CRC32, err = video.CalculateCRC32(path)
+		return entry, err
+	}
+
+	hash, ok := video.ExtractHashFromFilename(entry.Name)
+	if !ok {
+		return sfv.Entry{}, fmt.Errorf("no hash in filename")
+	}
+	crc, err := strconv.ParseUint(hash, 16, 32)
+	if err != nil {
+		return sfv.Entry{}, fmt.Errorf("invalid hash in filename: %w", err)
+	}
+	entry.CRC32 = uint32(crc)
+
+	return entry, nil
+}
+
+// sfvName returns the SFV filename for a directory, e.g. "Season 1.sfv"
+func sfvName(dir string) string {
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	return name + sfv.Extension
+}
+
+// SFVVerifyCmd checks the files listed in SFV files against their actual contents.
+type SFVVerifyCmd struct {
+	Files   []string `arg:"" name:"files" help:"SFV files to check" type:"existingfile"`
+	Workers int      `help:"Number of parallel workers" default:"0"`
+
+	output.Flags `embed:""`
+}
+
+// Run hashes every listed file in parallel and reports results in SFV order.
+func (cmd *SFVVerifyCmd) Run() error {
+	log := logging.ForOp("sfv-verify")
+	out := output.New(os.Stdout, cmd.Output, "sfv-verify")
+	start := time.Now()
+
+	// Entries are jobs rather than paths so a file listed in several SFV files keeps each expected hash
+	type sfvJob struct {
+		path     string
+		expected string
+	}
+
+	var jobs []sfvJob
+	var paths []string
+	for _, sfvPath := range cmd.Files {
+		file, err := sfv.Read(sfvPath)
+		if err != nil {
+			return EnvironmentError(fmt.Errorf("failed to read SFV file: %w", err))
+		}
+
+		for _, entry := range file.Entries {
+			path := file.EntryPath(entry)
+			jobs = append(jobs, sfvJob{path: path, expected: fmt.Sprintf("%08X", entry.CRC32)})
+			paths = append(paths, path)
+		}
+	}
+
+	workers := workerCount(cmd.Workers, paths, log)
+	log.Info("Verifying SFV entries", "sfv_files", len(cmd.Files), "files", len(paths), "workers", workers)
+
+	results := runWorkerPool(context.Background(), jobs, workers, func(workerID int, job sfvJob) *video.VerifyResult {
+		logging.Start(log.With(logging.KeyWorker, workerID+1), "Verifying", job.path)
+		return video.VerifyHash(job.path, job.expected, nil)
+	})
+
+	return reportVerification(results, paths, workers, start, log, out)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/stats"
+	"github.com/lepinkainen/videotagger/ui"
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+// StatsCmd aggregates a video library by resolution, codec, container, duration and year.
+// The report helps decide which collections are worth re-encoding or deduplicating.
+type StatsCmd struct {
+	Directory string `arg:"" name:"directory" help:"Directory to analyze" type:"existingdir" default:"."`
+	Format    string `help:"Report format" default:"table" enum:"table,json,html"`
+	Out       string `short:"o" help:"Write the report to a file instead of stdout" type:"path"`
+	Workers   int    `help:"Number of parallel ffprobe workers" default:"0"`
+	NoProbe   bool   `name:"no-probe" help:"Skip ffprobe and use filename tags only (codec will be unknown)"`
+}
+
+// Run executes the stats command, collecting file details and rendering the report
+// in the requested format.
+func (cmd *StatsCmd) Run() error {
+	workers := cmd.Workers
+	if workers <= 0 {
+		if utils.IsNetworkDrive(cmd.Directory) {
+			workers = 1 // Use single worker for network drives
+		} else {
+			workers = runtime.NumCPU() // Use all CPUs for local drives
+		}
+	}
+
+	log := logging.ForOp("stats")
+	start := time.Now()
+	if cmd.Format == "table" || cmd.Out != "" {
+		// Keep machine-readable reports on stdout free of progress events
+		log.Info("Scanning library", logging.KeyPath, cmd.Directory, "workers", workers)
+	}
+
+	entries, err := stats.Collect(cmd.Directory, stats.Options{
+		Workers: workers,
+		Probe:   !cmd.NoProbe,
+	})
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to collect library statistics: %w", err))
+	}
+
+	report := stats.Aggregate(cmd.Directory, entries)
+
+	var w io.Writer = os.Stdout
+	if cmd.Out != "" {
+		f, err := os.Create(cmd.Out)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	switch cmd.Format {
+	case "json":
+		err = stats.WriteJSON(w, report)
+	case "html":
+		err = stats.WriteHTML(w, report)
+	default:
+		_, err = fmt.Fprint(w, ui.RenderStatsReport(report))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if cmd.Out != "" {
+		logging.Success(log, "Report written", cmd.Out, "files", report.TotalFiles, logging.KeyDuration, time.Since(start))
+	}
+
+	return nil
+}
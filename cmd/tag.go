@@ -1,15 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
-	"runtime"
-	"slices"
-	"sync"
+	"time"
 
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/sfv"
 	"github.com/lepinkainen/videotagger/types"
-	"github.com/lepinkainen/videotagger/ui"
-	"github.com/lepinkainen/videotagger/utils"
 	"github.com/lepinkainen/videotagger/video"
 )
 
@@ -18,6 +19,35 @@ import (
 type TagCmd struct {
 	Files   []string `arg:"" name:"files" help:"Video files to process" type:"path"`
 	Workers int      `help:"Number of parallel workers" default:"0"`
+	SFV     bool     `name:"sfv" help:"Take CRCs from .sfv files next to the videos instead of rehashing, when the SFV records the same file size"`
+
+	output.Flags `embed:""`
+}
+
+// tagSummary is the final record of a tag run in machine-readable output
+type tagSummary struct {
+	Files        int     `json:"files"`
+	Tagged       int     `json:"tagged"`
+	Skipped      int     `json:"skipped"`
+	Failed       int     `json:"failed"`
+	Damaged      int     `json:"damaged"` // Failed because the file itself is damaged
+	DurationSecs float64 `json:"durationSecs"`
+}
+
+// add counts a processing result
+func (s *tagSummary) add(result *video.ProcessingResult) {
+	s.Files++
+	switch {
+	case result.Error != nil:
+		s.Failed++
+		if video.IsDamaged(result.Error) {
+			s.Damaged++
+		}
+	case result.WasSkipped:
+		s.Skipped++
+	case result.WasRenamed:
+		s.Tagged++
+	}
 }
 
 // Run executes the tag command, processing files with parallel workers.
@@ -27,103 +57,103 @@ func (cmd *TagCmd) Run(appCtx *types.AppContext) error {
 	if appCtx != nil {
 		version = appCtx.Version
 	}
+	log := logging.ForOp("tag")
+	out := output.New(os.Stdout, cmd.Output, "tag")
 
 	// Expand directories to video files
 	expandedFiles, err := cmd.ExpandDirectories()
 	if err != nil {
-		return fmt.Errorf("failed to expand directories: %w", err)
+		return EnvironmentError(fmt.Errorf("failed to expand directories: %w", err))
 	}
 	cmd.Files = expandedFiles
 	// Set default worker count based on drive type
-	workers := cmd.Workers
-	if workers <= 0 {
-		// Check if any files are on network drives
-		if slices.ContainsFunc(cmd.Files, utils.IsNetworkDrive) {
-			workers = 1 // Use single worker for network drives
-			fmt.Printf("⚠️  Network drive detected, using 1 worker for optimal performance\n")
-		} else {
-			workers = runtime.NumCPU() // Use all CPUs for local drives
-		}
-	}
+	workers := workerCount(cmd.Workers, cmd.Files, log)
+	opts := cmd.processOptions(log)
 
 	// Use TUI for multiple files with multiple workers
 	if len(cmd.Files) > 1 && workers > 1 {
-		return cmd.runWithTUI(workers, version)
+		return cmd.runWithTUI(workers, version, opts, log, out)
 	}
 
 	// Fall back to simple mode for single file or single worker
-	fmt.Println(ui.HeaderStyle.Render(fmt.Sprintf("Video Tagger %s", version)))
-	fmt.Println(ui.ProcessingStyle.Render(fmt.Sprintf("Processing %d files:", len(cmd.Files))))
+	start := time.Now()
+	logging.Header(log, fmt.Sprintf("Video Tagger %s", version))
+	log.Info("Processing files", "files", len(cmd.Files))
 
+	var summary tagSummary
 	for _, videoFile := range cmd.Files {
-		video.ProcessVideoFile(videoFile)
+		result := video.ProcessVideoFileWithOptions(videoFile, log, opts)
+		summary.add(result)
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	return cmd.finish(summary, start, log, out)
+}
+
+// finish logs and emits the run summary, returning an exit error if any file failed
+func (cmd *TagCmd) finish(summary tagSummary, start time.Time, log *slog.Logger, out *output.Writer) error {
+	summary.DurationSecs = time.Since(start).Seconds()
+	attrs := []any{
+		"files", summary.Files,
+		"tagged", summary.Tagged,
+		"skipped", summary.Skipped,
+		"failed", summary.Failed,
 	}
+	if summary.Damaged > 0 {
+		attrs = append(attrs, "damaged", summary.Damaged)
+	}
+	logging.Summary(log, "Processing complete", append(attrs, logging.KeyDuration, time.Since(start))...)
 
-	fmt.Printf("\n%s\n", ui.SuccessStyle.Render("✅ Processing complete."))
-	return nil
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return filesFailedError(summary.Failed, summary.Files)
 }
 
 // runWithTUI runs the tag command with TUI interface
-func (cmd *TagCmd) runWithTUI(workers int, version string) error {
+func (cmd *TagCmd) runWithTUI(workers int, version string, opts video.ProcessOptions, log *slog.Logger, out *output.Writer) error {
 	// For now, fall back to simple mode while we develop the TUI
 	// TODO: Implement full TUI integration
-	fmt.Println(ui.HeaderStyle.Render(fmt.Sprintf("Video Tagger %s (TUI Mode)", version)))
-	fmt.Println(ui.ProcessingStyle.Render(fmt.Sprintf("Processing %d files with %d workers:", len(cmd.Files), workers)))
+	start := time.Now()
+	logging.Header(log, fmt.Sprintf("Video Tagger %s (TUI Mode)", version))
+	log.Info("Processing files", "files", len(cmd.Files), "workers", workers)
 
 	// Process files in parallel (without TUI for now)
-	jobs := make(chan string, len(cmd.Files))
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := range workers {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for videoFile := range jobs {
-				// Don't print worker messages to avoid interfering with progress bar
-				video.ProcessVideoFile(videoFile)
-			}
-		}(i)
-	}
-
-	// Send jobs
-	for _, videoFile := range cmd.Files {
-		jobs <- videoFile
+	results := runWorkerPool(context.Background(), cmd.Files, workers, func(workerID int, videoFile string) *video.ProcessingResult {
+		return video.ProcessVideoFileWithOptions(videoFile, log.With(logging.KeyWorker, workerID+1), opts)
+	})
+
+	// Records are written in input order so output is stable between runs
+	var summary tagSummary
+	for _, result := range results {
+		summary.add(result)
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
 	}
-	close(jobs)
-
-	// Wait for completion
-	wg.Wait()
 
-	fmt.Printf("\n%s\n", ui.SuccessStyle.Render("✅ Processing complete."))
-	return nil
+	return cmd.finish(summary, start, log, out)
 }
 
 // TODO: Complete TUI implementation in future phase
 
-// ExpandDirectories expands any directory arguments into lists of video files
-func (cmd *TagCmd) ExpandDirectories() ([]string, error) {
-	var expandedFiles []string
-
-	for _, path := range cmd.Files {
-		// Check if path exists
-		fi, err := os.Stat(path)
-		if err != nil {
-			return nil, fmt.Errorf("cannot access %s: %w", path, err)
-		}
+// processOptions builds the tagging options selected by flags
+func (cmd *TagCmd) processOptions(log *slog.Logger) video.ProcessOptions {
+	var opts video.ProcessOptions
 
-		if fi.IsDir() {
-			// Directory: find all unprocessed video files recursively
-			videoFiles, err := video.FindVideoFilesRecursively(path)
-			if err != nil {
-				return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
-			}
-			expandedFiles = append(expandedFiles, videoFiles...)
-		} else {
-			// Regular file: add as-is
-			expandedFiles = append(expandedFiles, path)
-		}
+	if cmd.SFV {
+		index := sfv.NewIndex(func(path string, err error) {
+			log.Warn("Ignoring unreadable SFV file", logging.KeyPath, path, logging.KeyError, err.Error())
+		})
+		opts.KnownCRC = index.Lookup
 	}
 
-	return expandedFiles, nil
+	return opts
+}
+
+// ExpandDirectories expands any directory arguments into lists of unprocessed video files
+func (cmd *TagCmd) ExpandDirectories() ([]string, error) {
+	return expandPaths(cmd.Files, video.FindVideoFilesRecursively)
 }
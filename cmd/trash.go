@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/trash"
+)
+
+// TrashCmd groups commands for files moved to the trash by duplicates.
+type TrashCmd struct {
+	Restore TrashRestoreCmd `cmd:"" help:"Move trashed files back to where they were deleted from"`
+}
+
+// TrashRestoreCmd puts trashed files back at their original paths.
+type TrashRestoreCmd struct {
+	Paths    []string `arg:"" name:"paths" help:"Original paths of trashed files, or directories they were deleted from" type:"path"`
+	TrashDir string   `name:"trash-dir" help:"Holding directory given to duplicates --trash-dir, searched instead of the XDG trash" type:"path"`
+}
+
+// Run restores every trashed file that was deleted from one of the given paths. When a path
+// was trashed more than once, the most recently trashed file is restored.
+func (cmd *TrashRestoreCmd) Run() error {
+	log := logging.ForOp("trash-restore")
+	start := time.Now()
+
+	listed := make(map[string][]trash.Item)
+	var items []trash.Item
+	failed := 0
+
+	for _, path := range cmd.Paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return EnvironmentError(err)
+		}
+
+		dirs := []string{cmd.TrashDir}
+		if cmd.TrashDir == "" {
+			if dirs, err = trash.DirsFor(abs); err != nil {
+				return EnvironmentError(fmt.Errorf("failed to locate trash: %w", err))
+			}
+		}
+
+		matched := 0
+		for _, dir := range dirs {
+			if _, seen := listed[dir]; !seen {
+				if listed[dir], err = trash.List(dir); err != nil {
+					return EnvironmentError(fmt.Errorf("failed to read trash %s: %w", dir, err))
+				}
+			}
+
+			for _, item := range listed[dir] {
+				if item.OriginalPath == abs || strings.HasPrefix(item.OriginalPath, abs+string(filepath.Separator)) {
+					items = append(items, item)
+					matched++
+				}
+			}
+		}
+
+		if matched == 0 {
+			logging.Failure(log, "Error restoring file", path, trash.ErrNotTrashed)
+			failed++
+		}
+	}
+
+	// Newest first, so the latest copy of a path trashed several times wins
+	slices.SortStableFunc(items, func(a, b trash.Item) int { return b.DeletedAt.Compare(a.DeletedAt) })
+
+	restored, skipped := 0, 0
+	done := make(map[string]bool)
+	for _, item := range items {
+		if done[item.FilePath()] {
+			continue // Matched by more than one of the given paths
+		}
+		done[item.FilePath()] = true
+
+		if done[item.OriginalPath] {
+			logging.Skipped(log, "Older copy left in the trash", item.OriginalPath, "trashed", item.FilePath())
+			skipped++
+			continue
+		}
+		done[item.OriginalPath] = true
+
+		if err := trash.Restore(item); err != nil {
+			logging.Failure(log, "Error restoring file", item.OriginalPath, err)
+			failed++
+			continue
+		}
+
+		logging.Success(log, "Restored", item.OriginalPath, "deleted", item.DeletedAt.Format(time.DateTime))
+		restored++
+	}
+
+	summary := []any{"restored", restored, "failed", failed}
+	if skipped > 0 {
+		summary = append(summary, "skipped", skipped)
+	}
+	logging.Summary(log, "Restore complete", append(summary, logging.KeyDuration, time.Since(start))...)
+
+	return filesFailedError(failed, restored+failed)
+}
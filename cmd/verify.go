@@ -1,10 +1,20 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
 	"path/filepath"
-	"strings"
+	"sync/atomic"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/output"
+	"github.com/lepinkainen/videotagger/types"
 	"github.com/lepinkainen/videotagger/ui"
 	"github.com/lepinkainen/videotagger/video"
 )
@@ -12,44 +22,382 @@ import (
 // VerifyCmd verifies CRC32 checksums embedded in video filenames match the actual file contents.
 // Files must have been previously tagged to contain hash information in the filename.
 type VerifyCmd struct {
-	Files []string `arg:"" name:"files" help:"Video files to verify" type:"existingfile"`
+	Files    []string `arg:"" name:"files" help:"Video files or directories to verify (directories are searched recursively for tagged files)" type:"path"`
+	Workers  int      `help:"Number of parallel workers" default:"0"`
+	Decode   bool     `help:"Also decode every file with ffmpeg to find truncation and corruption a CRC can't see (slow)"`
+	Metadata bool     `help:"Also re-probe resolution and duration and compare them with the filename tags"`
+	Fix      bool     `help:"With --metadata, rename files whose resolution or duration tags are out of date"`
+	OnFail   string   `name:"on-fail" help:"Action for files that fail verification: quarantine=DIR moves them into DIR, mirroring their original paths" placeholder:"quarantine=DIR"`
+
+	Scrub     bool   `help:"Verify the files whose last successful verification is oldest, recording results in the scrub state"`
+	Budget    string `help:"Limit a scrub run by time (2h) or data read (500GB)" placeholder:"2h|500GB"`
+	State     string `help:"Scrub state file (default: $XDG_STATE_HOME/videotagger/scrub.json)" type:"path"`
+	StaleDays int    `help:"When scrubbing, report files not verified successfully in this many days" default:"30"`
+
+	output.Flags `embed:""`
+}
+
+// verifySummary is the final record of a verify run in machine-readable output
+type verifySummary struct {
+	Files        int     `json:"files"`
+	Verified     int     `json:"verified"`
+	Mismatched   int     `json:"mismatched"`
+	Corrupt      int     `json:"corrupt,omitempty"`
+	Drifted      int     `json:"drifted,omitempty"`
+	Retagged     int     `json:"retagged,omitempty"`
+	Quarantined  int     `json:"quarantined,omitempty"`
+	Skipped      int     `json:"skipped"`
+	Missing      int     `json:"missing"`
+	Failed       int     `json:"failed"`
+	Interrupted  int     `json:"interrupted"`
+	Deferred     int     `json:"deferred,omitempty"`
+	Stale        int     `json:"stale,omitempty"`
+	DurationSecs float64 `json:"durationSecs"`
 }
 
 // Run executes the verify command on all specified files, comparing embedded hashes
 // with recalculated CRC32 checksums to detect corruption or tampering.
-func (cmd *VerifyCmd) Run() error {
-	fmt.Printf("%s\n", ui.InfoStyle.Render(fmt.Sprintf("Verifying %d files...", len(cmd.Files))))
+// Files are hashed in parallel; results are reported in input order once all workers finish.
+// If appCtx is nil, uses default version information.
+func (cmd *VerifyCmd) Run(appCtx *types.AppContext) error {
+	version := types.DefaultVersion
+	if appCtx != nil {
+		version = appCtx.Version
+	}
+	log := logging.ForOp("verify")
+	out := output.New(os.Stdout, cmd.Output, "verify")
+	runStart := time.Now()
+
+	// Expand directories to tagged video files
+	expandedFiles, err := cmd.ExpandDirectories()
+	if err != nil {
+		return EnvironmentError(fmt.Errorf("failed to expand directories: %w", err))
+	}
+	cmd.Files = expandedFiles
+
+	if cmd.Fix && !cmd.Metadata {
+		return fmt.Errorf("--fix requires --metadata")
+	}
+	quarantineDir, err := parseOnFail(cmd.OnFail)
+	if err != nil {
+		return err
+	}
+	if cmd.Scrub {
+		return cmd.runScrub(version, quarantineDir, runStart, log, out)
+	}
+	if cmd.Budget != "" {
+		return fmt.Errorf("--budget requires --scrub")
+	}
+
+	workers := workerCount(cmd.Workers, cmd.Files, log)
+	results, err := verifyFiles(context.Background(), cmd.Files, workers, cmd.checks(), version, log)
+	if err != nil {
+		return err
+	}
+	if quarantineDir != "" {
+		quarantineFailures(results, quarantineDir, log)
+	}
+
+	return reportVerification(results, cmd.Files, workers, runStart, log, out)
+}
+
+// verifyChecks selects the checks run on each file in addition to the hash
+type verifyChecks struct {
+	Decode   bool // Fully decode the file
+	Metadata bool // Compare resolution and duration tags with the probed file
+	Fix      bool // Rename files whose tags are out of date
+}
+
+// checks returns the extra checks selected on the command line
+func (cmd *VerifyCmd) checks() verifyChecks {
+	return verifyChecks{Decode: cmd.Decode, Metadata: cmd.Metadata, Fix: cmd.Fix}
+}
 
-	var verified, failed int
+// verifyFiles verifies files in parallel, with the progress TUI when running in a terminal.
+// The extra checks run after each file's hash is checked.
+// Once ctx is done no new files are started and the files never reached have nil results.
+func verifyFiles(ctx context.Context, files []string, workers int, checks verifyChecks, version string, log *slog.Logger) ([]*video.VerifyResult, error) {
+	if useTUI() && len(files) > 1 && workers > 1 {
+		return runVerifyTUI(ctx, files, workers, checks, version)
+	}
+
+	log.Info("Verifying files", "files", len(files), "workers", workers, "decode", checks.Decode, "metadata", checks.Metadata)
+	return runWorkerPool(ctx, files, workers, func(workerID int, videoFile string) *video.VerifyResult {
+		logging.Start(log.With(logging.KeyWorker, workerID+1), "Verifying", videoFile)
+		result := video.VerifyFile(videoFile)
+		if checks.Decode {
+			decodeCheck(result, nil)
+		}
+		if checks.Metadata {
+			metadataCheck(result, checks.Fix)
+		}
+		return result
+	}), nil
+}
+
+// decodeCheck adds a full decode check to a verification result.
+// Files that aren't videos or couldn't be read are left alone.
+func decodeCheck(result *video.VerifyResult, progress func(decodedSecs, declaredSecs float64)) {
+	if result.Error != nil || !video.IsVideoFile(result.Path) {
+		return
+	}
+
+	decode, err := video.DecodeCheck(result.Path, progress)
+	if err != nil {
+		result.Error = fmt.Errorf("decode check failed: %w", err)
+		return
+	}
+	result.Decode = decode
+}
+
+// metadataCheck compares the resolution and duration tags of a hashed file with the probed file,
+// renaming it when fix is set. Files whose hash doesn't match aren't renamed, since their
+// contents are not what was tagged.
+func metadataCheck(result *video.VerifyResult, fix bool) {
+	if result.Error != nil || result.WasSkipped {
+		return
+	}
+
+	drift, err := video.CheckMetadata(result.Path)
+	if err != nil {
+		result.Error = fmt.Errorf("metadata check failed: %w", err)
+		return
+	}
+	result.Metadata = drift
+
+	if !fix || !drift.Drifted() || result.Mismatch() {
+		return
+	}
+
+	newPath, err := video.RetagFile(result.Path, drift)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to fix tags: %w", err)
+		return
+	}
+	result.RenamedTo = newPath
+}
+
+// reportVerification logs and emits verification results in input order, followed by the summary,
+// and returns the exit error for the run. A nil result marks a file the run never reached.
+func reportVerification(results []*video.VerifyResult, paths []string, workers int, start time.Time, log *slog.Logger, out *output.Writer) error {
+	summary := verifySummary{Files: len(paths)}
+	if err := reportVerifyResults(results, paths, &summary, log, out); err != nil {
+		return err
+	}
+	return finishVerification(summary, workers, start, log, out)
+}
 
-	for _, videoFile := range cmd.Files {
-		if !video.IsVideoFile(videoFile) {
-			fmt.Printf("⚠️  %s is not a video file, skipping\n", videoFile)
-			continue
+// reportVerifyResults logs and emits verification results in input order, counting them in summary
+func reportVerifyResults(results []*video.VerifyResult, paths []string, summary *verifySummary, log *slog.Logger, out *output.Writer) error {
+	for i, result := range results {
+		if result == nil {
+			// The run was cancelled before this file started
+			result = &video.VerifyResult{Path: paths[i], WasSkipped: true, SkipReason: "interrupted"}
+			summary.Interrupted++
 		}
 
-		expectedHash, ok := video.ExtractHashFromFilename(filepath.Base(videoFile))
-		if !ok {
-			fmt.Printf("⚠️  %s has not been processed (no hash in filename)\n", videoFile)
-			continue
+		reportVerifyResult(result, summary, log)
+		if err := out.Emit(output.TypeFile, result, result.Error); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
 		}
+	}
+	return nil
+}
 
-		actualHash, err := video.CalculateCRC32(videoFile)
-		if err != nil {
-			fmt.Printf("%s\n", ui.ErrorStyle.Render(fmt.Sprintf("❌ Error calculating hash for %s: %v", videoFile, err)))
-			failed++
-			continue
+// finishVerification logs and emits the summary, and returns the exit error for the run
+func finishVerification(summary verifySummary, workers int, start time.Time, log *slog.Logger, out *output.Writer) error {
+	summary.DurationSecs = time.Since(start).Seconds()
+	attrs := []any{
+		"verified", summary.Verified,
+		"mismatched", summary.Mismatched,
+		"missing", summary.Missing,
+		"skipped", summary.Skipped,
+		"failed", summary.Failed,
+	}
+	if summary.Corrupt > 0 {
+		attrs = append(attrs, "corrupt", summary.Corrupt)
+	}
+	if summary.Drifted > 0 {
+		attrs = append(attrs, "drifted", summary.Drifted, "retagged", summary.Retagged)
+	}
+	if summary.Quarantined > 0 {
+		attrs = append(attrs, "quarantined", summary.Quarantined)
+	}
+	if summary.Deferred > 0 || summary.Stale > 0 {
+		attrs = append(attrs, "deferred", summary.Deferred, "stale", summary.Stale)
+	}
+	logging.Summary(log, "Verification complete", append(attrs,
+		"workers", workers,
+		logging.KeyDuration, time.Since(start))...)
+
+	if err := out.Summary(summary); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	// A mismatch or damaged stream means bad data, which matters more than files that couldn't be read
+	if bad := summary.Mismatched + summary.Corrupt; bad > 0 {
+		return &ExitError{
+			Code: ExitMismatch,
+			Err:  fmt.Errorf("%d of %d files failed verification", bad, summary.Files),
+		}
+	}
+	if summary.Interrupted > 0 {
+		return &ExitError{
+			Code: ExitFailed,
+			Err:  fmt.Errorf("verification interrupted, %d of %d files not checked", summary.Interrupted, summary.Files),
 		}
+	}
+	return filesFailedError(summary.Failed+summary.Missing, summary.Files)
+}
+
+// ExpandDirectories expands any directory arguments into lists of tagged video files,
+// using the same discovery as the duplicates command
+func (cmd *VerifyCmd) ExpandDirectories() ([]string, error) {
+	return expandPaths(cmd.Files, video.FindTaggedFilesRecursively)
+}
+
+// runVerifyTUI verifies files in parallel while showing per-worker byte progress.
+// Quitting the TUI stops new files from starting and waits for files already being hashed.
+func runVerifyTUI(ctx context.Context, files []string, workers int, checks verifyChecks, version string) ([]*video.VerifyResult, error) {
+	model := ui.NewTUIModel(len(files), workers, version)
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var completed atomic.Int64
+	done := make(chan []*video.VerifyResult, 1)
 
-		if strings.EqualFold(expectedHash, fmt.Sprintf("%08X", actualHash)) {
-			fmt.Printf("%s\n", ui.SuccessStyle.Render(fmt.Sprintf("✅ %s", videoFile)))
-			verified++
-		} else {
-			fmt.Printf("%s\n", ui.ErrorStyle.Render(fmt.Sprintf("❌ %s (expected: %s, got: %08X)", videoFile, expectedHash, actualHash)))
-			failed++
+	go func() {
+		results := runWorkerPool(ctx, files, workers, func(workerID int, videoFile string) *video.VerifyResult {
+			p.Send(ui.WorkerStartedMsg{WorkerID: workerID, Filename: videoFile})
+
+			var size int64
+			if fi, err := os.Stat(videoFile); err == nil {
+				size = fi.Size()
+			}
+
+			result := video.VerifyFileWithProgress(videoFile, ui.NewProgressWriter(p.Send, workerID, size))
+			if checks.Decode {
+				// Decoding restarts the worker's bar, measured in seconds of video rather than bytes
+				p.Send(ui.WorkerStartedMsg{WorkerID: workerID, Filename: videoFile})
+				decodeCheck(result, func(decoded, declared float64) {
+					if declared > 0 {
+						p.Send(ui.WorkerProgressMsg{WorkerID: workerID, Progress: min(decoded/declared, 1)})
+					}
+				})
+			}
+			if checks.Metadata {
+				metadataCheck(result, checks.Fix)
+			}
+			p.Send(verifyCompletedMsg(workerID, result))
+			p.Send(ui.OverallProgressMsg{Completed: int(completed.Add(1)), Total: len(files)})
+			return result
+		})
+		done <- results
+		p.Send(ui.AllDoneMsg{})
+	}()
+
+	_, err := p.Run()
+	cancel()
+	results := <-done
+	if err != nil {
+		return nil, fmt.Errorf("failed to run TUI: %w", err)
+	}
+
+	return results, nil
+}
+
+// verifyCompletedMsg describes a verification result for the TUI file log
+func verifyCompletedMsg(workerID int, result *video.VerifyResult) ui.WorkerCompletedMsg {
+	msg := ui.WorkerCompletedMsg{
+		WorkerID: workerID,
+		Filename: result.Path,
+		Success:  true,
+	}
+
+	switch {
+	case result.Error != nil:
+		msg.Success = false
+		msg.Error = result.Error
+	case result.Corrupt():
+		msg.Success = false
+		msg.Error = fmt.Errorf("decode check: %s", result.Decode.Summary())
+	case result.WasSkipped:
+		msg.Note = "skipped: " + result.SkipReason
+	case result.Verified:
+		msg.Note = "verified " + result.ActualHash
+	default:
+		msg.Success = false
+		msg.Error = fmt.Errorf("hash mismatch: expected %s, got %s", result.ExpectedHash, result.ActualHash)
+	}
+
+	if msg.Success {
+		switch {
+		case result.RenamedTo != "":
+			msg.NewName = filepath.Base(result.RenamedTo)
+		case result.Drifted():
+			msg.Note += ", tags out of date"
 		}
 	}
 
-	fmt.Printf("\n%s\n", ui.InfoStyle.Render(fmt.Sprintf("✅ Verified: %d, ❌ Failed: %d", verified, failed)))
-	return nil
+	return msg
+}
+
+// reportVerifyResult logs a verification result and counts it in summary.
+// A file is counted once: a hash mismatch outranks decode problems, which outrank skipping.
+func reportVerifyResult(result *video.VerifyResult, summary *verifySummary, log *slog.Logger) {
+	var decodeAttrs []any
+	if result.Decode != nil {
+		decodeAttrs = []any{"decode", result.Decode.Summary()}
+	}
+
+	switch {
+	case errors.Is(result.Error, fs.ErrNotExist):
+		logging.Failure(log, "Missing file", result.Path, result.Error)
+		summary.Missing++
+	case result.Error != nil:
+		logging.Failure(log, "Error verifying file", result.Path, result.Error)
+		summary.Failed++
+	case result.Mismatch():
+		log.Error("Hash mismatch", append([]any{
+			logging.KeyPath, result.Path,
+			logging.KeyStatus, logging.StatusFailed,
+			logging.KeyErrorClass, "mismatch",
+			"expected", result.ExpectedHash,
+			"actual", result.ActualHash,
+		}, decodeAttrs...)...)
+		summary.Mismatched++
+	case result.Corrupt():
+		log.Error("Decode check failed",
+			logging.KeyPath, result.Path,
+			logging.KeyStatus, logging.StatusFailed,
+			logging.KeyErrorClass, string(result.Decode.Problem),
+			"decode", result.Decode.Summary(),
+			"decode_errors", result.Decode.ErrorCount)
+		summary.Corrupt++
+	case result.WasSkipped:
+		logging.Skipped(log, "Skipping", result.Path, append([]any{"reason", result.SkipReason}, decodeAttrs...)...)
+		summary.Skipped++
+	default:
+		logging.Success(log, "Verified", result.Path, decodeAttrs...)
+		summary.Verified++
+	}
+
+	// Tag drift is reported on its own, whatever the contents check found
+	if result.Drifted() {
+		drift := result.Metadata
+		logging.Warning(log, "Metadata tags out of date", result.Path, "metadata_drift",
+			"tagged", fmt.Sprintf("%s %dmin", drift.TaggedResolution, drift.TaggedDurationMins),
+			"actual", fmt.Sprintf("%s %.0fmin", drift.ActualResolution, drift.ActualDurationMins))
+		summary.Drifted++
+	}
+	if result.RenamedTo != "" {
+		logging.Success(log, "Fixed tags", result.Path, "new_name", filepath.Base(result.RenamedTo))
+		summary.Retagged++
+	}
+	if result.QuarantinedTo != "" {
+		logging.Success(log, "Quarantined", result.Path, "to", result.QuarantinedTo)
+		summary.Quarantined++
+	}
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/utils"
+	"github.com/mattn/go-isatty"
+)
+
+// useTUI reports whether a full-screen progress TUI can be shown: the pretty renderer
+// owns stdout and stdout is a terminal rather than a pipe or file
+func useTUI() bool {
+	return logging.Interactive() && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// workerCount returns the requested number of workers, or picks one based on where the files live:
+// a single worker when any file is on a network drive, where parallel reads thrash, otherwise one per CPU
+func workerCount(requested int, files []string, log *slog.Logger) int {
+	if requested > 0 {
+		return requested
+	}
+
+	if slices.ContainsFunc(files, utils.IsNetworkDrive) {
+		log.Warn("Network drive detected, using 1 worker for optimal performance")
+		return 1
+	}
+
+	return runtime.NumCPU()
+}
+
+// runWorkerPool calls process for every item using the given number of workers.
+// Results are returned in input order regardless of completion order. Once ctx is cancelled
+// no new items are started, and items that never started keep the zero value of R.
+func runWorkerPool[T, R any](ctx context.Context, items []T, workers int, process func(workerID int, item T) R) []R {
+	results := make([]R, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := range max(workers, 1) {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = process(workerID, items[index])
+			}
+		}(i)
+	}
+
+dispatch:
+	for index := range items {
+		// select picks randomly when a worker is also ready, so check for cancellation first
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- index:
+		}
+	}
+	close(jobs)
+
+	wg.Wait()
+	return results
+}
+
+// expandPaths replaces directory arguments with the files find returns for them, sorted so
+// runs are repeatable. Regular files are kept as given, in their original position.
+func expandPaths(paths []string, find func(directory string) ([]string, error)) ([]string, error) {
+	var expandedFiles []string
+
+	for _, path := range paths {
+		// Check if path exists
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+		}
+
+		if fi.IsDir() {
+			videoFiles, err := find(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
+			}
+			slices.Sort(videoFiles)
+			expandedFiles = append(expandedFiles, videoFiles...)
+		} else {
+			// Regular file: add as-is
+			expandedFiles = append(expandedFiles, path)
+		}
+	}
+
+	return expandedFiles, nil
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LibraryFileName is the per-library configuration file looked up from the processed path upwards
+const LibraryFileName = ".videotagger.toml"
+
+// Source identifies where an effective setting came from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceGlobal  Source = "global"
+	SourceLibrary Source = "library"
+	SourceProfile Source = "profile"
+	SourceFlag    Source = "command line"
+)
+
+// layer is one configuration file loaded into a generic key/value tree
+type layer struct {
+	source Source
+	path   string
+	values map[string]any
+}
+
+// Config holds the global and per-library configuration files.
+//
+// A file may set flag defaults at the top level (applying to every command that has the flag),
+// inside a table named after the command (e.g. [reencode] or [sfv.export]), and inside named
+// profiles ([profiles.archive] and [profiles.archive.reencode]).
+type Config struct {
+	layers []layer
+}
+
+// GlobalPath returns the location of the user-wide configuration file,
+// honouring XDG_CONFIG_HOME and defaulting to ~/.config/videotagger/config.toml
+func GlobalPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "videotagger", "config.toml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "videotagger", "config.toml"), nil
+}
+
+// FindLibraryFile looks for a .videotagger.toml file in dir and its parents.
+// Returns an empty string if no library file exists.
+func FindLibraryFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		candidate := filepath.Join(dir, LibraryFileName)
+		if fi, err := os.Stat(candidate); err == nil && !fi.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the global configuration file and the per-library file nearest to the first
+// of paths, the files or directories the command processes. Without paths, the library file
+// is looked up from the current working directory. Missing files are not an error.
+func Load(paths ...string) (*Config, error) {
+	globalPath, err := GlobalPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate config directory: %w", err)
+	}
+
+	dir, err := LibraryDir(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadFiles(globalPath, FindLibraryFile(dir))
+}
+
+// LibraryDir returns the directory the library file is looked up from: the first path if it
+// is a directory, the directory containing it otherwise, and the working directory without paths
+func LibraryDir(paths []string) (string, error) {
+	if len(paths) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		return cwd, nil
+	}
+
+	if fi, err := os.Stat(paths[0]); err == nil && fi.IsDir() {
+		return paths[0], nil
+	}
+	return filepath.Dir(paths[0]), nil
+}
+
+// LoadFiles reads the given global and library configuration files.
+// Empty or missing paths are skipped.
+func LoadFiles(globalPath, libraryPath string) (*Config, error) {
+	cfg := &Config{}
+
+	for _, candidate := range []struct {
+		source Source
+		path   string
+	}{
+		{SourceGlobal, globalPath},
+		{SourceLibrary, libraryPath},
+	} {
+		if candidate.path == "" {
+			continue
+		}
+
+		values := make(map[string]any)
+		if _, err := toml.DecodeFile(candidate.path, &values); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to load config %s: %w", candidate.path, err)
+		}
+
+		cfg.layers = append(cfg.layers, layer{source: candidate.source, path: candidate.path, values: values})
+	}
+
+	return cfg, nil
+}
+
+// Files returns the configuration files that were loaded, lowest precedence first
+func (c *Config) Files() []string {
+	files := make([]string, 0, len(c.layers))
+	for _, l := range c.layers {
+		files = append(files, l.path)
+	}
+	return files
+}
+
+// Profiles returns the sorted names of all profiles defined across the loaded files
+func (c *Config) Profiles() []string {
+	seen := make(map[string]struct{})
+	for _, l := range c.layers {
+		if profiles, ok := l.values["profiles"].(map[string]any); ok {
+			for name := range profiles {
+				seen[name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasProfile reports whether a named profile is defined in any loaded file
+func (c *Config) HasProfile(name string) bool {
+	for _, profile := range c.Profiles() {
+		if profile == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the configured value for a flag of the given command path
+// (e.g. ["sfv", "export"]), along with the source that supplied it.
+// Later layers take precedence: global, then library, then the active profile,
+// and within each layer a command table beats a top-level key.
+func (c *Config) Lookup(profile string, command []string, flag string) (value any, source Source, ok bool) {
+	for _, l := range c.layers {
+		if v, found := lookupInTree(l.values, command, flag); found {
+			value, source, ok = v, l.source, true
+		}
+	}
+
+	if profile == "" {
+		return value, source, ok
+	}
+
+	for _, l := range c.layers {
+		profiles, _ := l.values["profiles"].(map[string]any)
+		tree, _ := profiles[profile].(map[string]any)
+		if tree == nil {
+			continue
+		}
+		if v, found := lookupInTree(tree, command, flag); found {
+			value, source, ok = v, SourceProfile, true
+		}
+	}
+
+	return value, source, ok
+}
+
+// lookupInTree finds a flag value in a tree, preferring the most specific command table
+func lookupInTree(tree map[string]any, command []string, flag string) (any, bool) {
+	value, found := flagValue(tree, flag)
+
+	node := tree
+	for _, name := range command {
+		child, ok := node[name].(map[string]any)
+		if !ok {
+			break
+		}
+		node = child
+		if v, ok := flagValue(node, flag); ok {
+			value, found = v, true
+		}
+	}
+
+	return value, found
+}
+
+// flagValue reads a flag from a table, accepting kebab-case and snake_case keys
+func flagValue(table map[string]any, flag string) (any, bool) {
+	for _, key := range []string{flag, strings.ReplaceAll(flag, "-", "_")} {
+		if v, ok := table[key]; ok {
+			if _, isTable := v.(map[string]any); isTable {
+				continue
+			}
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// FormatValue converts a TOML value into the string form kong parses for flags
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
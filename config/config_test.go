@@ -0,0 +1,336 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+const globalConfig = `
+workers = 2
+
+[reencode]
+crf = 24
+min_savings = 0.3
+
+[profiles.archive.reencode]
+crf = 18
+preset = "veryslow"
+
+[profiles.phone]
+workers = 1
+`
+
+const libraryConfig = `
+[reencode]
+preset = "slow"
+`
+
+func writeConfigFiles(t *testing.T) (globalPath, libraryPath string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	globalPath = filepath.Join(dir, "config.toml")
+	libraryPath = filepath.Join(dir, LibraryFileName)
+
+	if err := os.WriteFile(globalPath, []byte(globalConfig), 0644); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+	if err := os.WriteFile(libraryPath, []byte(libraryConfig), 0644); err != nil {
+		t.Fatalf("Failed to write library config: %v", err)
+	}
+
+	return globalPath, libraryPath
+}
+
+func TestLoadFiles_MissingFilesAreIgnored(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := LoadFiles(filepath.Join(dir, "missing.toml"), "")
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	if len(cfg.Files()) != 0 {
+		t.Errorf("Expected no loaded files, got %v", cfg.Files())
+	}
+}
+
+func TestLoadFiles_InvalidTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("this is = = not toml"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := LoadFiles(path, ""); err == nil {
+		t.Error("Expected error for invalid TOML")
+	}
+}
+
+func TestLookup_Precedence(t *testing.T) {
+	cfg, err := LoadFiles(writeConfigFiles(t))
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		profile        string
+		command        []string
+		flag           string
+		expectedValue  string
+		expectedSource Source
+		expectedFound  bool
+	}{
+		{"Top-level key applies to any command", "", []string{"tag"}, "workers", "2", SourceGlobal, true},
+		{"Command table beats top-level", "", []string{"reencode"}, "crf", "24", SourceGlobal, true},
+		{"Snake case key matches kebab flag", "", []string{"reencode"}, "min-savings", "0.3", SourceGlobal, true},
+		{"Library file beats global file", "", []string{"reencode"}, "preset", "slow", SourceLibrary, true},
+		{"Profile command table beats files", "archive", []string{"reencode"}, "crf", "18", SourceProfile, true},
+		{"Profile beats library file", "archive", []string{"reencode"}, "preset", "veryslow", SourceProfile, true},
+		{"Profile top-level key", "phone", []string{"tag"}, "workers", "1", SourceProfile, true},
+		{"Profile does not leak to other profiles", "phone", []string{"reencode"}, "crf", "24", SourceGlobal, true},
+		{"Unknown flag", "", []string{"tag"}, "nonexistent", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, source, found := cfg.Lookup(tt.profile, tt.command, tt.flag)
+			if found != tt.expectedFound {
+				t.Fatalf("Expected found=%v, got %v", tt.expectedFound, found)
+			}
+			if !found {
+				return
+			}
+			if FormatValue(value) != tt.expectedValue {
+				t.Errorf("Expected value %s, got %s", tt.expectedValue, FormatValue(value))
+			}
+			if source != tt.expectedSource {
+				t.Errorf("Expected source %s, got %s", tt.expectedSource, source)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	cfg, err := LoadFiles(writeConfigFiles(t))
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	profiles := cfg.Profiles()
+	if len(profiles) != 2 || profiles[0] != "archive" || profiles[1] != "phone" {
+		t.Errorf("Expected [archive phone], got %v", profiles)
+	}
+
+	if err := cfg.ValidateProfile("archive"); err != nil {
+		t.Errorf("Expected archive profile to be valid, got %v", err)
+	}
+	if err := cfg.ValidateProfile("missing"); err == nil {
+		t.Error("Expected error for unknown profile")
+	}
+	if err := cfg.ValidateProfile(""); err != nil {
+		t.Errorf("Expected no profile to be valid, got %v", err)
+	}
+}
+
+func TestFindLibraryFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+
+	libraryPath := filepath.Join(root, LibraryFileName)
+	if err := os.WriteFile(libraryPath, []byte(libraryConfig), 0644); err != nil {
+		t.Fatalf("Failed to write library config: %v", err)
+	}
+
+	if found := FindLibraryFile(nested); found != libraryPath {
+		t.Errorf("Expected %s, got %s", libraryPath, found)
+	}
+}
+
+func TestLibraryDir(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "clip.mp4")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		paths []string
+		want  string
+	}{
+		{[]string{root, "/elsewhere"}, root},
+		{[]string{file}, root},
+		{[]string{filepath.Join(root, "missing.mp4")}, root},
+	} {
+		if got, err := LibraryDir(tt.paths); err != nil || got != tt.want {
+			t.Errorf("LibraryDir(%v) = %q, %v; want %q", tt.paths, got, err, tt.want)
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	if got, err := LibraryDir(nil); err != nil || got != cwd {
+		t.Errorf("Expected the working directory without paths, got %q, %v", got, err)
+	}
+}
+
+type testVerifyCmd struct {
+	Paths   []string `arg:"" type:"path"`
+	Workers int
+}
+
+type testManifestCmd struct {
+	Directory string `arg:"" type:"existingdir" default:"."`
+}
+
+func TestCommandPaths(t *testing.T) {
+	type pathsCLI struct {
+		LogFile  string          `type:"path"`
+		Verify   testVerifyCmd   `cmd:""`
+		Manifest testManifestCmd `cmd:""`
+	}
+
+	root := t.TempDir()
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--log-file", "/tmp/vt.log", "verify", "--workers", "2", root, "/b"}, []string{root, "/b"}},
+		{[]string{"manifest", root}, []string{root}},
+		{[]string{"manifest"}, []string{"."}},
+		{[]string{"bogus"}, nil},
+	}
+	for _, tt := range tests {
+		got := CommandPaths(&pathsCLI{}, tt.args)
+		if len(got) != len(tt.want) {
+			t.Errorf("CommandPaths(%v) = %v, want %v", tt.args, got, tt.want)
+			continue
+		}
+		for i := range got {
+			// kong makes given path arguments absolute, but not defaults
+			got, _ := filepath.Abs(got[i])
+			if want, _ := filepath.Abs(tt.want[i]); got != want {
+				t.Errorf("CommandPaths(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestApplyDirectoryDefaults(t *testing.T) {
+	type dirsCLI struct {
+		Manifest testManifestCmd `cmd:""`
+		Scan     struct {
+			Directories []string `arg:"" type:"existingdir" default:"."`
+		} `cmd:""`
+	}
+
+	root := t.TempDir()
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"manifest"}, []string{"."}},
+		{[]string{"manifest", root}, []string{root}},
+		{[]string{"scan"}, []string{"."}},
+		{[]string{"scan", root, root}, []string{root, root}},
+	}
+	for _, tt := range tests {
+		var cli dirsCLI
+		ctx, err := kong.Must(&cli).Parse(tt.args)
+		if err != nil {
+			t.Fatalf("Parse(%v) error = %v", tt.args, err)
+		}
+		ApplyDirectoryDefaults(ctx)
+
+		got := cli.Scan.Directories
+		if tt.args[0] == "manifest" {
+			got = []string{cli.Manifest.Directory}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ApplyDirectoryDefaults(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+type testReencodeCmd struct {
+	Workers int     `default:"0"`
+	CRF     int     `default:"23"`
+	Preset  string  `default:"medium"`
+	Savings float64 `name:"min-savings" default:"0.20"`
+}
+
+type testCLI struct {
+	Profile  string
+	Reencode testReencodeCmd `cmd:""`
+}
+
+func TestResolver(t *testing.T) {
+	cfg, err := LoadFiles(writeConfigFiles(t))
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected testReencodeCmd
+	}{
+		{"Config defaults", []string{"reencode"}, testReencodeCmd{Workers: 2, CRF: 24, Preset: "slow", Savings: 0.3}},
+		{"Profile overrides", []string{"--profile", "archive", "reencode"}, testReencodeCmd{Workers: 2, CRF: 18, Preset: "veryslow", Savings: 0.3}},
+		{"Command line wins", []string{"--profile", "archive", "reencode", "--crf", "30"}, testReencodeCmd{Workers: 2, CRF: 30, Preset: "veryslow", Savings: 0.3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cli testCLI
+			parser := kong.Must(&cli, kong.Resolvers(cfg.Resolver()))
+			if _, err := parser.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if cli.Reencode != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, cli.Reencode)
+			}
+		})
+	}
+}
+
+func TestResolverKeepsArraysForUnsplitFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	config := `
+[dupes]
+rule = ["path:/archive,/backup", "mtime:newest"]
+tags = ["a", "b"]
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFiles(path, "")
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	var cli struct {
+		Dupes struct {
+			Rule []string `sep:"none"`
+			Tags []string
+		} `cmd:""`
+	}
+	parser := kong.Must(&cli, kong.Resolvers(cfg.Resolver()))
+	if _, err := parser.Parse([]string{"dupes"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(cli.Dupes.Rule) != 2 || cli.Dupes.Rule[0] != "path:/archive,/backup" {
+		t.Errorf("Expected the array elements as they are, got %q", cli.Dupes.Rule)
+	}
+	if len(cli.Dupes.Tags) != 2 || cli.Dupes.Tags[1] != "b" {
+		t.Errorf("Expected a plain list flag to still work, got %q", cli.Dupes.Tags)
+	}
+}
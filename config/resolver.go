@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+
+	"github.com/alecthomas/kong"
+)
+
+// ProfileFlag is the name of the global flag selecting a named profile
+const ProfileFlag = "profile"
+
+// Resolver returns a kong resolver that supplies flag defaults from the configuration.
+// Values given on the command line always win over configured values.
+// Unknown profile names are ignored here and reported by ValidateProfile after parsing.
+func (c *Config) Resolver() kong.Resolver {
+	return kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+		profile := ""
+		if flag.Name != ProfileFlag {
+			profile = ActiveProfile(ctx)
+		}
+
+		value, _, ok := c.Lookup(profile, CommandPath(parent.Node()), flag.Name)
+		if !ok {
+			return nil, nil
+		}
+
+		// Flags whose values may contain commas take arrays as they are instead of joined
+		if list, isList := value.([]any); isList && flag.Tag != nil && flag.Tag.Sep == -1 {
+			return list, nil
+		}
+		return FormatValue(value), nil
+	})
+}
+
+// CommandPaths returns the path arguments of the command selected by args, so the library file
+// can be found before the real parse applies the configuration. grammar is a fresh CLI struct
+// used only for this parse. Unparseable arguments give no paths; the real parse reports them.
+func CommandPaths(grammar any, args []string) []string {
+	parser, err := kong.New(grammar, kong.Writers(io.Discard, io.Discard), kong.Exit(func(int) {}))
+	if err != nil {
+		return nil
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil || ctx.Selected() == nil {
+		return nil
+	}
+	ApplyDirectoryDefaults(ctx)
+
+	var paths []string
+	for _, arg := range ctx.Selected().Positional {
+		switch arg.Tag.Type {
+		case "path", "existingfile", "existingdir":
+		default:
+			continue
+		}
+		switch v := arg.Target.Interface().(type) {
+		case string:
+			if v != "" {
+				paths = append(paths, v)
+			}
+		case []string:
+			paths = append(paths, v...)
+		}
+	}
+	return paths
+}
+
+// ApplyDirectoryDefaults sets omitted existingdir arguments of the selected command to their
+// default. kong only checks and stores directories given on the command line, so without this
+// an omitted argument is left empty rather than falling back to, say, the working directory.
+func ApplyDirectoryDefaults(ctx *kong.Context) {
+	selected := ctx.Selected()
+	if selected == nil {
+		return
+	}
+
+	for _, arg := range selected.Positional {
+		if arg.Tag.Type != "existingdir" || arg.Default == "" {
+			continue
+		}
+		// kong marks the default as set without storing it, so an empty target is the sign
+		switch v := arg.Target.Interface().(type) {
+		case string:
+			if v == "" {
+				arg.Target.SetString(arg.Default)
+			}
+		case []string:
+			if !slices.ContainsFunc(v, func(dir string) bool { return dir != "" }) {
+				arg.Target.Set(reflect.ValueOf([]string{arg.Default}))
+			}
+		}
+	}
+}
+
+// ValidateProfile returns an error if a profile is selected but not defined in any config file
+func (c *Config) ValidateProfile(profile string) error {
+	if profile != "" && !c.HasProfile(profile) {
+		return fmt.Errorf("unknown profile %q (available: %v)", profile, c.Profiles())
+	}
+	return nil
+}
+
+// ActiveProfile returns the profile selected for this invocation, if any
+func ActiveProfile(ctx *kong.Context) string {
+	for _, flag := range ctx.Flags() {
+		if flag.Name == ProfileFlag {
+			if profile, ok := ctx.FlagValue(flag).(string); ok {
+				return profile
+			}
+		}
+	}
+	return ""
+}
+
+// CommandPath returns the command names from the root to node, e.g. ["sfv", "export"].
+// The application node itself yields an empty path.
+func CommandPath(node *kong.Node) []string {
+	var path []string
+	for n := node; n != nil && n.Type == kong.CommandNode; n = n.Parent {
+		path = append([]string{n.Name}, path...)
+	}
+	return path
+}
@@ -0,0 +1,218 @@
+package duplicates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// compareChunkSize is how much of each file sameContents reads at a time
+const compareChunkSize = 1 << 20
+
+// ErrSelectionChanged means confirming groups split them or cleared selections,
+// so the user has to review the selection before anything is deleted
+var ErrSelectionChanged = errors.New("duplicate groups changed after comparing file contents; review the selection")
+
+// ErrAlias is returned when a file would be selected that is another path to a file in its group
+var ErrAlias = errors.New("file is another path to a file in the same group")
+
+// splitBySize splits files that share a hash into groups of equal size, keeping their
+// order. Files of different sizes can't be identical, whatever their name tags say.
+func splitBySize(files []FileMetadata) [][]FileMetadata {
+	var order []int64
+	bySize := make(map[int64][]FileMetadata)
+	for _, file := range files {
+		if _, seen := bySize[file.Size]; !seen {
+			order = append(order, file.Size)
+		}
+		bySize[file.Size] = append(bySize[file.Size], file)
+	}
+
+	var groups [][]FileMetadata
+	for _, size := range order {
+		if len(bySize[size]) > 1 {
+			groups = append(groups, bySize[size])
+		}
+	}
+	return groups
+}
+
+// ConfirmGroup compares the files of a group byte for byte, returning one confirmed group
+// per set of identical files. Files identical to no other file are dropped, as are sets that
+// are all hardlinks of one file, and selections carry over. A group that was already confirmed is returned as is.
+func ConfirmGroup(group DuplicateGroup) ([]DuplicateGroup, error) {
+	if group.Confirmed {
+		return []DuplicateGroup{group}, nil
+	}
+
+	remaining := make([]int, len(group.Files))
+	for i := range remaining {
+		remaining[i] = i
+	}
+
+	var confirmed []DuplicateGroup
+	for len(remaining) > 1 {
+		reference := remaining[0]
+		same := []int{reference}
+		var different []int
+
+		for _, i := range remaining[1:] {
+			equal, err := sameContents(group.Files[reference].Path, group.Files[i].Path)
+			if err != nil {
+				return nil, err
+			}
+			if equal {
+				same = append(same, i)
+			} else {
+				different = append(different, i)
+			}
+		}
+
+		if len(same) > 1 && distinctCopies(group.Files, same) {
+			sub := DuplicateGroup{
+				Hash:         group.Hash,
+				Selected:     make([]bool, 0, len(same)),
+				DeletedFiles: group.DeletedFiles,
+				Confirmed:    true,
+			}
+			for _, i := range same {
+				sub.Files = append(sub.Files, group.Files[i])
+				sub.Selected = append(sub.Selected, i < len(group.Selected) && group.Selected[i])
+			}
+			confirmed = append(confirmed, sub)
+		}
+		remaining = different
+	}
+
+	return confirmed, nil
+}
+
+// distinctCopies reports whether the files at the given indexes are more than one file on
+// disk. Hardlinks of a single file are one copy however many names it has.
+func distinctCopies(files []FileMetadata, indexes []int) bool {
+	first, err := os.Lstat(files[indexes[0]].Path)
+	if err != nil {
+		return false
+	}
+	for _, i := range indexes[1:] {
+		info, err := os.Lstat(files[i].Path)
+		if err == nil && !os.SameFile(first, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfirmSelected confirms every unconfirmed group that has selected files, so nothing is
+// deleted on the strength of a name tag alone. When a group splits, any part with every
+// file selected has its selection cleared, since deleting it would lose those contents.
+// ErrSelectionChanged is returned with the updated groups when anything had to change.
+func ConfirmSelected(groups []DuplicateGroup) ([]DuplicateGroup, error) {
+	updated := make([]DuplicateGroup, 0, len(groups))
+	changed := false
+
+	for _, group := range groups {
+		if group.Confirmed || !hasSelection(group) {
+			updated = append(updated, group)
+			continue
+		}
+
+		confirmed, err := ConfirmGroup(group)
+		if err != nil {
+			return groups, fmt.Errorf("failed to compare files: %w", err)
+		}
+
+		if len(confirmed) != 1 || len(confirmed[0].Files) != len(group.Files) {
+			changed = true
+			for i := range confirmed {
+				if allSelected(confirmed[i]) {
+					clear(confirmed[i].Selected)
+				}
+			}
+		}
+		updated = append(updated, confirmed...)
+	}
+
+	if changed {
+		return updated, ErrSelectionChanged
+	}
+	return updated, nil
+}
+
+// hasSelection reports whether any file in the group is selected
+func hasSelection(group DuplicateGroup) bool {
+	for _, selected := range group.Selected {
+		if selected {
+			return true
+		}
+	}
+	return false
+}
+
+// allSelected reports whether every file in the group is selected
+func allSelected(group DuplicateGroup) bool {
+	for _, selected := range group.Selected {
+		if !selected {
+			return false
+		}
+	}
+	return len(group.Selected) > 0
+}
+
+// sameContents compares two files byte for byte. Symlinks and two paths to one file
+// never match: deleting either would lose the only copy. Hardlinks of one file do match,
+// since they are the same contents, but ConfirmGroup doesn't count them as a second copy.
+func sameContents(a, b string) (bool, error) {
+	infoA, err := os.Lstat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Lstat(b)
+	if err != nil {
+		return false, err
+	}
+	if !infoA.Mode().IsRegular() || !infoB.Mode().IsRegular() || resolvePath(a) == resolvePath(b) {
+		return false, nil
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+	if os.SameFile(infoA, infoB) {
+		return true, nil
+	}
+
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = fa.Close() }()
+
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = fb.Close() }()
+
+	bufA := make([]byte, compareChunkSize)
+	bufB := make([]byte, compareChunkSize)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+
+		doneA := errors.Is(errA, io.EOF) || errors.Is(errA, io.ErrUnexpectedEOF)
+		doneB := errors.Is(errB, io.EOF) || errors.Is(errB, io.ErrUnexpectedEOF)
+		switch {
+		case errA != nil && !doneA:
+			return false, errA
+		case errB != nil && !doneB:
+			return false, errB
+		case doneA || doneB:
+			return doneA && doneB, nil
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package duplicates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, contents string) FileMetadata {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FileMetadata{Path: path, Size: info.Size(), ModTime: info.ModTime().Unix()}
+}
+
+func TestBuildGroupsSplitsBySize(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a_[ABCD1234].mp4", "same")
+	b := writeFile(t, dir, "b_[ABCD1234].mp4", "same")
+	c := writeFile(t, dir, "c_[ABCD1234].mp4", "longer contents")
+
+	groups := BuildGroups(map[string][]string{"ABCD1234": {a.Path, c.Path, b.Path}})
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group after dropping the odd-sized file, got %d", len(groups))
+	}
+	if len(groups[0].Files) != 2 || groups[0].Files[0].Path != a.Path || groups[0].Files[1].Path != b.Path {
+		t.Errorf("Unexpected group files: %+v", groups[0].Files)
+	}
+	if groups[0].Confirmed {
+		t.Error("Expected a group built from names to be name-only")
+	}
+}
+
+func TestConfirmGroup(t *testing.T) {
+	dir := t.TempDir()
+	group := DuplicateGroup{
+		Hash: "ABCD1234",
+		Files: []FileMetadata{
+			writeFile(t, dir, "a.mp4", "aaaa"),
+			writeFile(t, dir, "b.mp4", "bbbb"),
+			writeFile(t, dir, "c.mp4", "aaaa"),
+			writeFile(t, dir, "d.mp4", "bbbb"),
+			writeFile(t, dir, "e.mp4", "cccc"),
+		},
+		Selected: []bool{false, true, true, false, true},
+	}
+
+	confirmed, err := ConfirmGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 2 {
+		t.Fatalf("Expected 2 confirmed groups, got %d", len(confirmed))
+	}
+
+	want := []struct {
+		names    []string
+		selected []bool
+	}{
+		{[]string{"a.mp4", "c.mp4"}, []bool{false, true}},
+		{[]string{"b.mp4", "d.mp4"}, []bool{true, false}},
+	}
+	for i, w := range want {
+		got := confirmed[i]
+		if !got.Confirmed {
+			t.Errorf("Group %d: expected confirmed", i)
+		}
+		for j, name := range w.names {
+			if filepath.Base(got.Files[j].Path) != name || got.Selected[j] != w.selected[j] {
+				t.Errorf("Group %d file %d: got %s selected=%v, want %s selected=%v",
+					i, j, filepath.Base(got.Files[j].Path), got.Selected[j], name, w.selected[j])
+			}
+		}
+	}
+}
+
+func TestConfirmGroupMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	group := DuplicateGroup{
+		Files: []FileMetadata{
+			writeFile(t, dir, "a.mp4", "aaaa"),
+			{Path: filepath.Join(dir, "missing.mp4"), Size: 4},
+		},
+		Selected: []bool{false, true},
+	}
+
+	if _, err := ConfirmGroup(group); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestConfirmSelected(t *testing.T) {
+	dir := t.TempDir()
+
+	identical := DuplicateGroup{
+		Hash:     "11111111",
+		Files:    []FileMetadata{writeFile(t, dir, "a.mp4", "same"), writeFile(t, dir, "b.mp4", "same")},
+		Selected: []bool{false, true},
+	}
+	unselected := DuplicateGroup{
+		Hash:     "22222222",
+		Files:    []FileMetadata{writeFile(t, dir, "c.mp4", "xxxx"), writeFile(t, dir, "d.mp4", "yyyy")},
+		Selected: []bool{false, false},
+	}
+
+	groups, err := ConfirmSelected([]DuplicateGroup{identical, unselected})
+	if err != nil {
+		t.Fatalf("Expected no change, got %v", err)
+	}
+	if !groups[0].Confirmed || groups[1].Confirmed {
+		t.Errorf("Expected only the selected group to be confirmed, got %v and %v", groups[0].Confirmed, groups[1].Confirmed)
+	}
+
+	// Two pairs that only share a name tag: one file of each pair is selected,
+	// which would delete the only copy of one of them
+	mixed := DuplicateGroup{
+		Hash: "33333333",
+		Files: []FileMetadata{
+			writeFile(t, dir, "e.mp4", "eeee"),
+			writeFile(t, dir, "f.mp4", "ffff"),
+			writeFile(t, dir, "g.mp4", "eeee"),
+		},
+		Selected: []bool{false, true, true},
+	}
+
+	groups, err = ConfirmSelected([]DuplicateGroup{mixed})
+	if !errors.Is(err, ErrSelectionChanged) {
+		t.Fatalf("Expected ErrSelectionChanged, got %v", err)
+	}
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("Expected the unmatched file to be dropped, got %+v", groups)
+	}
+	if files := CollectSelectedFiles(groups); len(files) != 1 || filepath.Base(files[0]) != "g.mp4" {
+		t.Errorf("Expected only g.mp4 to stay selected, got %v", files)
+	}
+}
+
+func TestConfirmSelectedClearsFullySelectedGroups(t *testing.T) {
+	dir := t.TempDir()
+	group := DuplicateGroup{
+		Hash: "44444444",
+		Files: []FileMetadata{
+			writeFile(t, dir, "a.mp4", "aaaa"),
+			writeFile(t, dir, "b.mp4", "bbbb"),
+			writeFile(t, dir, "c.mp4", "bbbb"),
+		},
+		Selected: []bool{false, true, true},
+	}
+
+	groups, err := ConfirmSelected([]DuplicateGroup{group})
+	if !errors.Is(err, ErrSelectionChanged) {
+		t.Fatalf("Expected ErrSelectionChanged, got %v", err)
+	}
+	if files := CollectSelectedFiles(groups); len(files) != 0 {
+		t.Errorf("Expected selections to be cleared when every copy was selected, got %v", files)
+	}
+}
+
+func TestSameContents(t *testing.T) {
+	dir := t.TempDir()
+	big := make([]byte, compareChunkSize+10)
+	other := make([]byte, compareChunkSize+10)
+	other[compareChunkSize+5] = 1
+
+	a := writeFile(t, dir, "a", string(big))
+	b := writeFile(t, dir, "b", string(big))
+	c := writeFile(t, dir, "c", string(other))
+	d := writeFile(t, dir, "d", string(big[:10]))
+	symlink := filepath.Join(dir, "symlink")
+	if err := os.Symlink(a.Path, symlink); err != nil {
+		t.Fatal(err)
+	}
+	hardlink := filepath.Join(dir, "hardlink")
+	if err := os.Link(a.Path, hardlink); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a.Path, b.Path, true},
+		{a.Path, a.Path, false},
+		{a.Path, filepath.Join(dir, ".", "a"), false},
+		{a.Path, symlink, false},
+		{symlink, a.Path, false},
+		{a.Path, hardlink, true},
+		{a.Path, c.Path, false},
+		{a.Path, d.Path, false},
+	}
+	for _, tt := range tests {
+		got, err := sameContents(tt.a, tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("sameContents(%s, %s) = %v, want %v", filepath.Base(tt.a), filepath.Base(tt.b), got, tt.want)
+		}
+	}
+}
+
+func TestConfirmGroupSymlink(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a_[DEADBEEF].mp4", "only copy")
+	link := filepath.Join(dir, "b_[DEADBEEF].mp4")
+	if err := os.Symlink(a.Path, link); err != nil {
+		t.Fatal(err)
+	}
+
+	group := DuplicateGroup{
+		Hash:     "DEADBEEF",
+		Files:    []FileMetadata{a, {Path: link, Size: a.Size}},
+		Selected: []bool{false, false},
+	}
+	confirmed, err := ConfirmGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 0 {
+		t.Errorf("Expected a file and its symlink not to be confirmed duplicates, got %+v", confirmed)
+	}
+}
+
+func TestConfirmGroupHardlinksOnly(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.mp4", "aaaa")
+	hardlink := filepath.Join(dir, "b.mp4")
+	if err := os.Link(a.Path, hardlink); err != nil {
+		t.Fatal(err)
+	}
+	c := writeFile(t, dir, "c.mp4", "aaaa")
+
+	group := DuplicateGroup{Files: []FileMetadata{a, {Path: hardlink, Size: a.Size}}, Selected: []bool{false, true}}
+	confirmed, err := ConfirmGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 0 {
+		t.Errorf("Expected hardlinks of one file not to count as copies, got %+v", confirmed)
+	}
+
+	// With a real copy alongside, the hardlinks stay in the group
+	group = DuplicateGroup{Files: []FileMetadata{a, {Path: hardlink, Size: a.Size}, c}, Selected: make([]bool, 3)}
+	confirmed, err = ConfirmGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 1 || len(confirmed[0].Files) != 3 {
+		t.Errorf("Expected one group of three files, got %+v", confirmed)
+	}
+}
+
+func TestBuildGroupsSkipsAliases(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a_[ABCD1234].mp4", "same")
+	b := writeFile(t, dir, "b_[ABCD1234].mp4", "same")
+	link := filepath.Join(dir, "c_[ABCD1234].mp4")
+	if err := os.Symlink(a.Path, link); err != nil {
+		t.Fatal(err)
+	}
+
+	groups := BuildGroups(map[string][]string{"ABCD1234": {a.Path, link, filepath.Join(dir, ".", "a_[ABCD1234].mp4"), b.Path}})
+	if len(groups) != 1 || len(groups[0].Files) != 2 || groups[0].Files[0].Path != a.Path || groups[0].Files[1].Path != b.Path {
+		t.Errorf("Expected only a and b in the group, got %+v", groups)
+	}
+
+	// The symlink alone is no duplicate of its target
+	if groups := BuildGroups(map[string][]string{"ABCD1234": {a.Path, link}}); len(groups) != 0 {
+		t.Errorf("Expected no group for a file and its symlink, got %+v", groups)
+	}
+}
+
+func TestSelectionRefusesAliases(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a_[DEADBEEF].mp4", "only copy")
+	alias := FileMetadata{Path: filepath.Join(dir, ".", "a_[DEADBEEF].mp4"), Size: a.Size}
+	b := writeFile(t, dir, "b_[DEADBEEF].mp4", "only copy")
+
+	group := DuplicateGroup{Files: []FileMetadata{a, alias, b}, Selected: make([]bool, 3), Confirmed: true}
+	if err := SetSelected(&group, 0, true); !errors.Is(err, ErrAlias) {
+		t.Errorf("Expected ErrAlias, got %v", err)
+	}
+
+	ApplyAutoSelectStrategy(&group, KeepLastPosition)
+	if want := []bool{false, false, false}; !slices.Equal(group.Selected, want) {
+		t.Errorf("Expected no path to the aliased file to be selected, got %v", group.Selected)
+	}
+
+	SelectAll(&group)
+	if want := []bool{false, false, true}; !slices.Equal(group.Selected, want) {
+		t.Errorf("Expected SelectAll to skip the aliased paths, got %v", group.Selected)
+	}
+}
@@ -0,0 +1,190 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/lepinkainen/videotagger/trash"
+)
+
+// DeleteOptions controls what deleting a duplicate does
+type DeleteOptions struct {
+	Reference ReferenceRoots // Files here are never deleted
+	Permanent bool           // Remove files instead of moving them to the trash
+	TrashDir  string         // Holding directory used instead of the XDG trash
+}
+
+// DeletionStatus is the outcome of deleting one file
+type DeletionStatus string
+
+const (
+	StatusDeleted   DeletionStatus = "deleted"   // Moved to the trash, or removed
+	StatusFailed    DeletionStatus = "failed"    // Deleting returned an error; the file is still there
+	StatusChanged   DeletionStatus = "changed"   // Size or modification time differ from the scan; left alone
+	StatusMissing   DeletionStatus = "missing"   // Already gone from disk
+	StatusProtected DeletionStatus = "protected" // In a reference directory; left alone
+)
+
+// ErrChangedSinceScan is the reason given for files skipped because they changed since the scan
+var ErrChangedSinceScan = errors.New("file changed since the scan")
+
+// DeletionResult records what happened to one file selected for deletion
+type DeletionResult struct {
+	Path      string         `json:"path"`
+	Status    DeletionStatus `json:"status"`
+	TrashedTo string         `json:"trashedTo,omitempty"` // Where the file went, unless removed permanently
+	Error     error          `json:"-"`
+}
+
+// Gone reports whether the file no longer exists at its path
+func (r DeletionResult) Gone() bool {
+	return r.Status == StatusDeleted || r.Status == StatusMissing
+}
+
+// CollectSelected returns the metadata of the files selected across all groups,
+// as recorded by the scan.
+func CollectSelected(groups []DuplicateGroup) []FileMetadata {
+	var selected []FileMetadata
+	for _, group := range groups {
+		for i, isSelected := range group.Selected {
+			if isSelected && i < len(group.Files) {
+				selected = append(selected, group.Files[i])
+			}
+		}
+	}
+
+	return selected
+}
+
+// DeleteFiles moves the given files to the trash, or removes them when Permanent is set, and
+// reports the outcome for every file. Files in a reference directory, and files whose size or
+// modification time no longer match the scan, are left alone. A failure doesn't stop the rest.
+func DeleteFiles(files []FileMetadata, opts DeleteOptions) []DeletionResult {
+	results := make([]DeletionResult, 0, len(files))
+	for _, file := range files {
+		results = append(results, deleteFile(file, opts))
+	}
+
+	return results
+}
+
+// deleteFile disposes of a single file as the options say
+func deleteFile(file FileMetadata, opts DeleteOptions) DeletionResult {
+	result := DeletionResult{Path: file.Path}
+
+	if opts.Reference.Contains(file.Path) {
+		result.Status, result.Error = StatusProtected, ErrProtected
+		return result
+	}
+
+	info, err := os.Lstat(file.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		result.Status = StatusMissing
+		return result
+	case err != nil:
+		result.Status, result.Error = StatusFailed, err
+		return result
+	case info.Size() != file.Size || info.ModTime().Unix() != file.ModTime:
+		result.Status, result.Error = StatusChanged, ErrChangedSinceScan
+		return result
+	}
+
+	switch {
+	case opts.Permanent:
+		err = os.Remove(file.Path)
+	case opts.TrashDir != "":
+		var item *trash.Item
+		if item, err = trash.MoveTo(opts.TrashDir, file.Path); err == nil {
+			result.TrashedTo = item.FilePath()
+		}
+	default:
+		var item *trash.Item
+		if item, err = trash.Move(file.Path); err == nil {
+			result.TrashedTo = item.FilePath()
+		}
+	}
+
+	if err != nil {
+		result.Status, result.Error = StatusFailed, err
+		return result
+	}
+	result.Status = StatusDeleted
+	return result
+}
+
+// ApplyDeletion updates groups from deletion results. Deleted files move to DeletedFiles and
+// files found missing are dropped. A file that changed since the scan stays, unselected, with
+// fresh metadata, and its group has to be confirmed again. Failed and protected files stay as
+// they were. Groups with <= 1 file left are dropped.
+func ApplyDeletion(groups []DuplicateGroup, results []DeletionResult) []DuplicateGroup {
+	outcome := make(map[string]DeletionStatus, len(results))
+	var gone []string
+	for _, result := range results {
+		outcome[result.Path] = result.Status
+		if result.Gone() {
+			gone = append(gone, result.Path)
+		}
+	}
+
+	for g := range groups {
+		for i := range groups[g].Files {
+			file := &groups[g].Files[i]
+			if outcome[file.Path] != StatusChanged {
+				continue
+			}
+			if info, err := os.Stat(file.Path); err == nil {
+				file.Size = info.Size()
+				file.ModTime = info.ModTime().Unix()
+			}
+			if i < len(groups[g].Selected) {
+				groups[g].Selected[i] = false
+			}
+			groups[g].Confirmed = false
+		}
+	}
+
+	return dropFiles(groups, gone, func(group *DuplicateGroup, file FileMetadata) {
+		if outcome[file.Path] == StatusDeleted {
+			group.DeletedFiles = append(group.DeletedFiles, file)
+		}
+	})
+}
+
+// SummarizeDeletion describes deletion results in one line, naming the first failure
+func SummarizeDeletion(results []DeletionResult) string {
+	counts := make(map[DeletionStatus]int)
+	var firstFailure *DeletionResult
+	for i, result := range results {
+		counts[result.Status]++
+		if result.Status == StatusFailed && firstFailure == nil {
+			firstFailure = &results[i]
+		}
+	}
+
+	var parts []string
+	for _, status := range []DeletionStatus{StatusDeleted, StatusMissing, StatusChanged, StatusProtected, StatusFailed} {
+		if counts[status] == 0 {
+			continue
+		}
+		label := string(status)
+		switch status {
+		case StatusMissing:
+			label = "already gone"
+		case StatusChanged:
+			label = "changed since the scan and kept"
+		case StatusProtected:
+			label = "protected and kept"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[status], label))
+	}
+
+	summary := strings.Join(parts, ", ")
+	if firstFailure != nil {
+		summary += fmt.Sprintf(" (%s: %v)", firstFailure.Path, firstFailure.Error)
+	}
+	return summary
+}
@@ -0,0 +1,160 @@
+package duplicates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lepinkainen/videotagger/trash"
+)
+
+func TestDeleteFilesMovesToTrashDir(t *testing.T) {
+	base := t.TempDir()
+	holding := filepath.Join(base, "holding")
+	file := writeFile(t, base, "a.mp4", "data")
+
+	results := DeleteFiles([]FileMetadata{file}, DeleteOptions{TrashDir: holding})
+	if len(results) != 1 || results[0].Status != StatusDeleted {
+		t.Fatalf("Expected the file to be deleted, got %+v", results)
+	}
+	if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", file.Path, err)
+	}
+
+	items, err := trash.List(holding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != file.Path {
+		t.Fatalf("Expected the file in the holding directory, got %+v", items)
+	}
+	if results[0].TrashedTo != items[0].FilePath() {
+		t.Errorf("Expected TrashedTo %s, got %s", items[0].FilePath(), results[0].TrashedTo)
+	}
+}
+
+func TestDeleteFilesRefusesProtected(t *testing.T) {
+	base := t.TempDir()
+	archive := filepath.Join(base, "archive")
+	if err := os.Mkdir(archive, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	loose := writeFile(t, base, "a.mp4", "data")
+	kept := writeFile(t, archive, "a.mp4", "data")
+
+	roots, err := NewReferenceRoots([]string{archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := DeleteFiles([]FileMetadata{kept, loose}, DeleteOptions{Reference: roots, Permanent: true})
+	if len(results) != 2 {
+		t.Fatalf("Expected a result per file, got %+v", results)
+	}
+	if results[0].Status != StatusProtected || !errors.Is(results[0].Error, ErrProtected) {
+		t.Errorf("Expected %s to be protected, got %+v", kept.Path, results[0])
+	}
+	if results[1].Status != StatusDeleted {
+		t.Errorf("Expected the protected file not to stop the rest, got %+v", results[1])
+	}
+	if _, err := os.Stat(kept.Path); err != nil {
+		t.Errorf("Expected %s to be left alone, got %v", kept.Path, err)
+	}
+	if _, err := os.Stat(loose.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be deleted, got %v", loose.Path, err)
+	}
+}
+
+func TestDeleteFilesSkipsChangedAndMissing(t *testing.T) {
+	dir := t.TempDir()
+	changed := writeFile(t, dir, "changed.mp4", "data")
+	missing := writeFile(t, dir, "missing.mp4", "data")
+	if err := os.WriteFile(changed.Path, []byte("rewritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(missing.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	results := DeleteFiles([]FileMetadata{changed, missing}, DeleteOptions{Permanent: true})
+	if results[0].Status != StatusChanged || !errors.Is(results[0].Error, ErrChangedSinceScan) {
+		t.Errorf("Expected the rewritten file to be skipped, got %+v", results[0])
+	}
+	if _, err := os.Stat(changed.Path); err != nil {
+		t.Errorf("Expected %s to be left alone, got %v", changed.Path, err)
+	}
+	if results[1].Status != StatusMissing || !results[1].Gone() {
+		t.Errorf("Expected the removed file to be reported missing, got %+v", results[1])
+	}
+}
+
+func TestApplyDeletion(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "data")
+	deleted := writeFile(t, dir, "deleted.mp4", "data")
+	changed := writeFile(t, dir, "changed.mp4", "data")
+	failed := writeFile(t, dir, "failed.mp4", "data")
+	if err := os.WriteFile(changed.Path, []byte("rewritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	groups := []DuplicateGroup{
+		{
+			Hash:      "11111111",
+			Files:     []FileMetadata{keep, deleted, changed},
+			Selected:  []bool{false, true, true},
+			Confirmed: true,
+		},
+		{
+			Hash:     "22222222",
+			Files:    []FileMetadata{failed, {Path: filepath.Join(dir, "missing.mp4")}},
+			Selected: []bool{true, true},
+		},
+	}
+	results := []DeletionResult{
+		{Path: deleted.Path, Status: StatusDeleted},
+		{Path: changed.Path, Status: StatusChanged, Error: ErrChangedSinceScan},
+		{Path: failed.Path, Status: StatusFailed, Error: errors.New("permission denied")},
+		{Path: filepath.Join(dir, "missing.mp4"), Status: StatusMissing},
+	}
+
+	groups = ApplyDeletion(groups, results)
+	if len(groups) != 1 {
+		t.Fatalf("Expected the group with only the failed file left to be dropped, got %+v", groups)
+	}
+	group := groups[0]
+	if len(group.Files) != 2 || group.Files[0].Path != keep.Path || group.Files[1].Path != changed.Path {
+		t.Fatalf("Expected keep and changed files to remain, got %+v", group.Files)
+	}
+	if group.Selected[1] {
+		t.Error("Expected the changed file to be unselected")
+	}
+	if group.Confirmed {
+		t.Error("Expected the group to need confirming again")
+	}
+	if group.Files[1].Size != int64(len("rewritten")) {
+		t.Errorf("Expected refreshed size for the changed file, got %d", group.Files[1].Size)
+	}
+	if len(group.DeletedFiles) != 1 || group.DeletedFiles[0].Path != deleted.Path {
+		t.Errorf("Expected only the deleted file in DeletedFiles, got %+v", group.DeletedFiles)
+	}
+}
+
+func TestSummarizeDeletion(t *testing.T) {
+	summary := SummarizeDeletion([]DeletionResult{
+		{Path: "a.mp4", Status: StatusDeleted},
+		{Path: "b.mp4", Status: StatusDeleted},
+		{Path: "c.mp4", Status: StatusChanged, Error: ErrChangedSinceScan},
+		{Path: "d.mp4", Status: StatusFailed, Error: errors.New("permission denied")},
+	})
+
+	want := "2 deleted, 1 changed since the scan and kept, 1 failed (d.mp4: permission denied)"
+	if summary != want {
+		t.Errorf("SummarizeDeletion() = %q, want %q", summary, want)
+	}
+	if strings.Contains(SummarizeDeletion(nil), "failed") {
+		t.Error("Expected no failure in an empty summary")
+	}
+}
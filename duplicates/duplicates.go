@@ -1,27 +1,35 @@
 package duplicates
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/lepinkainen/videotagger/video"
 )
 
 // FileMetadata represents metadata for a single file.
 type FileMetadata struct {
-	Path         string `json:"path"`
-	Size         int64  `json:"size"`
-	ModTime      int64  `json:"modTime"`
-	Resolution   string `json:"resolution"`
-	DurationMins int    `json:"durationMins"`
+	Path         string  `json:"path"`
+	Size         int64   `json:"size"`
+	ModTime      int64   `json:"modTime"`
+	Resolution   string  `json:"resolution"`
+	DurationMins int     `json:"durationMins"`
+	Codec        string  `json:"codec"`        // Filled in by probing, empty until then
+	BitsPerPixel float64 `json:"bitsPerPixel"` // Filled in by probing, 0 until then
+	Probed       bool    `json:"probed"`       // ffprobe has been run on the file, whether or not it worked
+	Protected    bool    `json:"protected"`    // In a reference directory, never selected or deleted
 }
 
 // DuplicateGroup represents a group of duplicate files with the same hash.
+// Groups are name-only until their contents have been compared, which sets Confirmed.
 type DuplicateGroup struct {
 	Hash         string         `json:"hash"`
 	Files        []FileMetadata `json:"files"`
 	Selected     []bool         `json:"selected"`
 	DeletedFiles []FileMetadata `json:"deletedFiles"`
+	Confirmed    bool           `json:"confirmed"`
 }
 
 // AutoSelectStrategy defines strategies for auto-selecting files to delete.
@@ -36,22 +44,78 @@ const (
 	KeepLast                                    // Keep last file alphabetically.
 	KeepFirstPosition                           // Keep first file in list.
 	KeepLastPosition                            // Keep last file in list.
+
+	// Quality strategies. Files are probed for whatever the filename doesn't give.
+	KeepHighestResolution   // Keep the file with the most pixels.
+	KeepLongest             // Keep the longest file.
+	KeepBestCodec           // Keep HEVC or AV1 over H.264 over MPEG-4.
+	KeepHighestBitsPerPixel // Keep the file with the most bits per pixel per frame.
 )
 
+// strategyNames are the names used for strategies on the command line, in order
+var strategyNames = []string{
+	KeepNewest:        "keep-newest",
+	KeepOldest:        "keep-oldest",
+	KeepLargest:       "keep-largest",
+	KeepSmallest:      "keep-smallest",
+	KeepFirst:         "keep-first",
+	KeepLast:          "keep-last",
+	KeepFirstPosition: "keep-first-position",
+	KeepLastPosition:  "keep-last-position",
+
+	KeepHighestResolution:   "keep-highest-resolution",
+	KeepLongest:             "keep-longest",
+	KeepBestCodec:           "keep-best-codec",
+	KeepHighestBitsPerPixel: "keep-highest-bits-per-pixel",
+}
+
+// String returns the name used for the strategy on the command line
+func (s AutoSelectStrategy) String() string {
+	if s >= 0 && int(s) < len(strategyNames) {
+		return strategyNames[s]
+	}
+	return fmt.Sprintf("AutoSelectStrategy(%d)", int(s))
+}
+
+// ParseAutoSelectStrategy parses a strategy name such as "keep-newest"
+func ParseAutoSelectStrategy(s string) (AutoSelectStrategy, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	for i, strategyName := range strategyNames {
+		if name == strategyName {
+			return AutoSelectStrategy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown strategy: %q (want one of %s)", s, strings.Join(strategyNames, ", "))
+}
+
 // BuildGroups converts duplicate path groups into enriched duplicate groups.
+// Files sharing a hash but not a size are split into separate groups, and files
+// left without a same-sized partner are dropped. Symlinks are left out, and a file
+// listed under several paths is kept once.
 func BuildGroups(duplicates map[string][]string) []DuplicateGroup {
 	groups := make([]DuplicateGroup, 0, len(duplicates))
 
 	for hash, filePaths := range duplicates {
 		fileMetadata := make([]FileMetadata, 0, len(filePaths))
+		seen := make(map[string]bool, len(filePaths))
 		for _, path := range filePaths {
+			// A symlink or a second path to a listed file is not another copy of it
+			resolved := resolvePath(path)
+			if seen[resolved] {
+				continue
+			}
+			seen[resolved] = true
+
 			metadata := FileMetadata{
 				Path: path,
 			}
 
 			metadata.Resolution, metadata.DurationMins = ExtractMetadataFromFilename(path)
 
-			if stat, err := os.Stat(path); err == nil {
+			if stat, err := os.Lstat(path); err == nil {
+				if stat.Mode()&os.ModeSymlink != 0 {
+					continue
+				}
 				metadata.Size = stat.Size()
 				metadata.ModTime = stat.ModTime().Unix()
 			}
@@ -59,13 +123,14 @@ func BuildGroups(duplicates map[string][]string) []DuplicateGroup {
 			fileMetadata = append(fileMetadata, metadata)
 		}
 
-		group := DuplicateGroup{
-			Hash:         hash,
-			Files:        fileMetadata,
-			Selected:     make([]bool, len(fileMetadata)),
-			DeletedFiles: make([]FileMetadata, 0),
+		for _, files := range splitBySize(fileMetadata) {
+			groups = append(groups, DuplicateGroup{
+				Hash:         hash,
+				Files:        files,
+				Selected:     make([]bool, len(files)),
+				DeletedFiles: make([]FileMetadata, 0),
+			})
 		}
-		groups = append(groups, group)
 	}
 
 	return groups
@@ -104,21 +169,72 @@ func RecalculateSelectionStats(groups []DuplicateGroup) (totalSelected int, grou
 }
 
 // ApplyAutoSelectStrategy applies an auto-selection strategy to a group.
+// Protected files are never selected; when a group has one, it is the copy
+// that is kept and every other file is selected.
 func ApplyAutoSelectStrategy(group *DuplicateGroup, strategy AutoSelectStrategy) {
 	if len(group.Files) == 0 {
 		return
 	}
 
+	selectAllExcept(group, FindKeepIndex(group, strategy))
+}
+
+// selectAllExcept selects every file but the one at keepIndex. A protected file is kept
+// instead when the group has one, and every unprotected file is selected. Paths to the
+// same file are never selected.
+func selectAllExcept(group *DuplicateGroup, keepIndex int) {
+	if hasProtected(group) {
+		keepIndex = -1
+	}
+
 	for i := range group.Selected {
-		group.Selected[i] = false
+		group.Selected[i] = i != keepIndex && !group.Files[i].Protected && !isAlias(group, i)
+	}
+}
+
+// SetSelected selects or deselects a file in a group, refusing to select a protected file
+// or another path to a file in the same group
+func SetSelected(group *DuplicateGroup, index int, selected bool) error {
+	if index < 0 || index >= len(group.Files) || index >= len(group.Selected) {
+		return fmt.Errorf("file index out of range: %d", index)
+	}
+	if selected && group.Files[index].Protected {
+		return fmt.Errorf("%w: %s", ErrProtected, group.Files[index].Path)
 	}
+	if selected && isAlias(group, index) {
+		return fmt.Errorf("%w: %s", ErrAlias, group.Files[index].Path)
+	}
+	group.Selected[index] = selected
+	return nil
+}
 
-	keepIndex := FindKeepIndex(group, strategy)
+// SelectAll selects every file in a group that isn't protected or another path to a file in it
+func SelectAll(group *DuplicateGroup) {
 	for i := range group.Selected {
-		if i != keepIndex {
-			group.Selected[i] = true
+		group.Selected[i] = i < len(group.Files) && !group.Files[i].Protected && !isAlias(group, i)
+	}
+}
+
+// isAlias reports whether a file resolves to the same path as another file in its group,
+// through a symlink or an overlapping directory, so deleting it would delete both
+func isAlias(group *DuplicateGroup, index int) bool {
+	resolved := resolvePath(group.Files[index].Path)
+	for i, file := range group.Files {
+		if i != index && resolvePath(file.Path) == resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProtected reports whether any file in the group is protected
+func hasProtected(group *DuplicateGroup) bool {
+	for _, file := range group.Files {
+		if file.Protected {
+			return true
 		}
 	}
+	return false
 }
 
 // FindKeepIndex determines which file to keep based on the strategy.
@@ -188,6 +304,9 @@ func FindKeepIndex(group *DuplicateGroup, strategy AutoSelectStrategy) int {
 	case KeepLastPosition:
 		return len(group.Files) - 1
 
+	case KeepHighestResolution, KeepLongest, KeepBestCodec, KeepHighestBitsPerPixel:
+		return bestIndex(group.Files, qualityCompare(strategy))
+
 	default:
 		return 0
 	}
@@ -207,25 +326,15 @@ func CollectSelectedFiles(groups []DuplicateGroup) []string {
 	return selectedFiles
 }
 
-// DeleteFiles removes the selected files, stopping on the first failure.
-func DeleteFiles(paths []string) (string, error) {
-	for _, filePath := range paths {
-		if err := os.Remove(filePath); err != nil {
-			return filePath, err
-		}
-	}
-
-	return "", nil
-}
-
-// ApplyDeletion removes deleted files from groups and drops groups with <= 1 file.
-func ApplyDeletion(groups []DuplicateGroup, deletedPaths []string) []DuplicateGroup {
-	if len(deletedPaths) == 0 {
+// dropFiles removes the given files from groups, calling dropped for each, and drops
+// groups with <= 1 file left.
+func dropFiles(groups []DuplicateGroup, paths []string, dropped func(*DuplicateGroup, FileMetadata)) []DuplicateGroup {
+	if len(paths) == 0 {
 		return groups
 	}
 
-	deletedSet := make(map[string]struct{}, len(deletedPaths))
-	for _, path := range deletedPaths {
+	deletedSet := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
 		deletedSet[path] = struct{}{}
 	}
 
@@ -236,7 +345,9 @@ func ApplyDeletion(groups []DuplicateGroup, deletedPaths []string) []DuplicateGr
 
 		for i, file := range group.Files {
 			if _, deleted := deletedSet[file.Path]; deleted {
-				group.DeletedFiles = append(group.DeletedFiles, file)
+				if dropped != nil {
+					dropped(&group, file)
+				}
 				continue
 			}
 
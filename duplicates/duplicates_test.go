@@ -260,3 +260,19 @@ func TestApplyAutoSelectStrategy(t *testing.T) {
 		t.Error("Expected file 2 to be selected")
 	}
 }
+
+func TestParseAutoSelectStrategy(t *testing.T) {
+	for strategy := KeepNewest; strategy <= KeepHighestBitsPerPixel; strategy++ {
+		parsed, err := ParseAutoSelectStrategy(strategy.String())
+		if err != nil || parsed != strategy {
+			t.Errorf("ParseAutoSelectStrategy(%q) = %v, %v; want %v", strategy.String(), parsed, err, strategy)
+		}
+	}
+
+	if parsed, err := ParseAutoSelectStrategy(" Keep-Largest "); err != nil || parsed != KeepLargest {
+		t.Errorf("Expected names to be trimmed and case-insensitive, got %v, %v", parsed, err)
+	}
+	if _, err := ParseAutoSelectStrategy("keep-best"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package duplicates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sameFilesystem reports whether two files are on the same volume
+func sameFilesystem(a, b string, _, _ os.FileInfo) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(filepath.VolumeName(absA), filepath.VolumeName(absB)), nil
+}
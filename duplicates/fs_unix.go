@@ -0,0 +1,19 @@
+//go:build unix
+
+package duplicates
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// sameFilesystem reports whether two files are on the same device
+func sameFilesystem(a, b string, infoA, infoB os.FileInfo) (bool, error) {
+	statA, okA := infoA.Sys().(*syscall.Stat_t)
+	statB, okB := infoB.Sys().(*syscall.Stat_t)
+	if !okA || !okB {
+		return false, fmt.Errorf("can't tell which filesystem %s and %s are on", a, b)
+	}
+	return statA.Dev == statB.Dev, nil
+}
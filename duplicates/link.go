@@ -0,0 +1,265 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LinkMode selects how a duplicate is replaced by the file that is kept
+type LinkMode int
+
+const (
+	Hardlink LinkMode = iota // Share the kept file's inode.
+	Reflink                  // Clone the kept file's extents (FICLONE on btrfs/xfs).
+)
+
+var (
+	// ErrDifferentFilesystem is returned when a duplicate is on another filesystem than the kept file
+	ErrDifferentFilesystem = errors.New("files are on different filesystems")
+	// ErrContentsDiffer is returned when a duplicate isn't byte-identical to the kept file
+	ErrContentsDiffer = errors.New("file contents differ")
+	// ErrReflinkUnsupported is returned where the platform has no reflink support
+	ErrReflinkUnsupported = errors.New("reflinks are not supported on this platform")
+)
+
+// String returns the name used for the mode on the command line and in the UIs
+func (m LinkMode) String() string {
+	switch m {
+	case Hardlink:
+		return "hardlink"
+	case Reflink:
+		return "reflink"
+	default:
+		return fmt.Sprintf("LinkMode(%d)", int(m))
+	}
+}
+
+// ParseLinkMode parses "hardlink" or "reflink"
+func ParseLinkMode(s string) (LinkMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "hardlink":
+		return Hardlink, nil
+	case "reflink":
+		return Reflink, nil
+	default:
+		return 0, fmt.Errorf("unknown link mode: %q (want hardlink or reflink)", s)
+	}
+}
+
+// LinkTarget pairs a duplicate with the file it will be linked to
+type LinkTarget struct {
+	Keep string `json:"keep"`
+	Path string `json:"path"`
+}
+
+// CollectLinkTargets pairs every selected file with the first unselected file of its group,
+// which is the copy that is kept. Groups with every file selected have nothing to link to
+// and are left out.
+func CollectLinkTargets(groups []DuplicateGroup) []LinkTarget {
+	var targets []LinkTarget
+	for _, group := range groups {
+		keep := -1
+		for i := range group.Files {
+			if i < len(group.Selected) && !group.Selected[i] {
+				keep = i
+				break
+			}
+		}
+		if keep < 0 {
+			continue
+		}
+
+		for i, selected := range group.Selected {
+			if selected && i < len(group.Files) {
+				targets = append(targets, LinkTarget{Keep: group.Files[keep].Path, Path: group.Files[i].Path})
+			}
+		}
+	}
+
+	return targets
+}
+
+// LinkResult records what happened to one duplicate that was to be replaced by a link
+type LinkResult struct {
+	LinkTarget
+	Linked        bool  `json:"linked"`
+	AlreadyLinked bool  `json:"alreadyLinked,omitempty"` // Was a hardlink of the kept file already; no space freed
+	Error         error `json:"-"`
+}
+
+// LinkFiles replaces each duplicate with a link to its kept file and reports the outcome for
+// every target. Duplicates inside a reference directory are left alone. A failure doesn't stop
+// the rest.
+func LinkFiles(targets []LinkTarget, mode LinkMode, reference ReferenceRoots) []LinkResult {
+	results := make([]LinkResult, 0, len(targets))
+	for _, target := range targets {
+		result := LinkResult{LinkTarget: target}
+		switch {
+		case reference.Contains(target.Path):
+			result.Error = fmt.Errorf("%w: %s", ErrProtected, target.Path)
+		default:
+			result.AlreadyLinked = alreadyLinked(target.Keep, target.Path)
+			result.Error = LinkFile(target.Keep, target.Path, mode)
+		}
+		result.Linked = result.Error == nil
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// SummarizeLinks describes link results in one line, naming the first failure
+func SummarizeLinks(results []LinkResult) string {
+	linked, failed := 0, 0
+	var firstFailure *LinkResult
+	for i, result := range results {
+		if result.Linked {
+			linked++
+			continue
+		}
+		failed++
+		if firstFailure == nil {
+			firstFailure = &results[i]
+		}
+	}
+
+	summary := fmt.Sprintf("%d linked, %d failed", linked, failed)
+	if firstFailure != nil {
+		summary += fmt.Sprintf(" (%s: %v)", firstFailure.Path, firstFailure.Error)
+	}
+	return summary
+}
+
+// alreadyLinked reports whether both paths already name the same file
+func alreadyLinked(keep, path string) bool {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && os.SameFile(keepInfo, info)
+}
+
+// LinkFile replaces path with a hardlink or reflink clone of keep. Both files must be
+// regular files on the same filesystem with byte-identical contents. The replacement is
+// built next to path and renamed over it, so path exists throughout.
+func LinkFile(keep, path string, mode LinkMode) error {
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !keepInfo.Mode().IsRegular() || !info.Mode().IsRegular() {
+		return fmt.Errorf("only regular files can be linked: %s", path)
+	}
+	if os.SameFile(keepInfo, info) {
+		return nil // Already a hardlink of the kept file
+	}
+
+	same, err := sameFilesystem(keep, path, keepInfo, info)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return fmt.Errorf("%w: %s and %s", ErrDifferentFilesystem, keep, path)
+	}
+
+	equal, err := sameContents(keep, path)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("%w: %s and %s", ErrContentsDiffer, keep, path)
+	}
+
+	var tmp string
+	switch mode {
+	case Hardlink:
+		tmp, err = hardlinkTemp(keep, path)
+	case Reflink:
+		tmp, err = reflinkTemp(keep, path, info)
+	default:
+		err = fmt.Errorf("unknown link mode: %d", int(mode))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// tempPath returns an unused name next to path for building its replacement
+func tempPath(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.vtlink")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return name, os.Remove(name)
+}
+
+// hardlinkTemp creates a hardlink to keep next to path
+func hardlinkTemp(keep, path string) (string, error) {
+	tmp, err := tempPath(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Link(keep, tmp); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// reflinkTemp clones keep next to path, taking over path's permissions and modification time
+func reflinkTemp(keep, path string, info os.FileInfo) (string, error) {
+	src, err := os.Open(keep)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.vtlink")
+	if err != nil {
+		return "", err
+	}
+	tmp := dst.Name()
+
+	err = cloneFile(dst, src)
+	if err == nil {
+		err = dst.Chmod(info.Mode().Perm())
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// ApplyLinks drops the files that were linked from their groups, since they no longer take up
+// space of their own, and drops groups with <= 1 file left. Files that failed stay as they were.
+func ApplyLinks(groups []DuplicateGroup, results []LinkResult) []DuplicateGroup {
+	var linked []string
+	for _, result := range results {
+		if result.Linked {
+			linked = append(linked, result.Path)
+		}
+	}
+	return dropFiles(groups, linked, nil)
+}
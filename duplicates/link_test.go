@@ -0,0 +1,191 @@
+package duplicates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinkFileHardlink(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "same contents")
+	dup := writeFile(t, dir, "dup.mp4", "same contents")
+
+	if err := LinkFile(keep.Path, dup.Path, Hardlink); err != nil {
+		t.Fatalf("LinkFile() error = %v", err)
+	}
+
+	keepInfo, _ := os.Stat(keep.Path)
+	dupInfo, err := os.Stat(dup.Path)
+	if err != nil {
+		t.Fatalf("Expected %s to still exist, got %v", dup.Path, err)
+	}
+	if !os.SameFile(keepInfo, dupInfo) {
+		t.Error("Expected the duplicate to be a hardlink of the kept file")
+	}
+
+	// Linking again is a no-op
+	if err := LinkFile(keep.Path, dup.Path, Hardlink); err != nil {
+		t.Errorf("Expected relinking to succeed, got %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestLinkFileReflink(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "same contents")
+	dup := writeFile(t, dir, "dup.mp4", "same contents")
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(dup.Path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LinkFile(keep.Path, dup.Path, Reflink)
+	if errors.Is(err, ErrReflinkUnsupported) {
+		t.Skip("filesystem of the temp dir can't clone files")
+	}
+	if err != nil {
+		t.Fatalf("LinkFile() error = %v", err)
+	}
+
+	info, err := os.Stat(dup.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("Expected the clone to keep the duplicate's mtime %v, got %v", mtime, info.ModTime())
+	}
+	data, _ := os.ReadFile(dup.Path)
+	if string(data) != "same contents" {
+		t.Errorf("Unexpected contents after cloning: %q", data)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestLinkFileRefusesDifferentContents(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "contents A")
+	dup := writeFile(t, dir, "dup.mp4", "contents B")
+
+	for _, mode := range []LinkMode{Hardlink, Reflink} {
+		if err := LinkFile(keep.Path, dup.Path, mode); !errors.Is(err, ErrContentsDiffer) {
+			t.Errorf("%s: expected ErrContentsDiffer, got %v", mode, err)
+		}
+	}
+
+	data, _ := os.ReadFile(dup.Path)
+	if string(data) != "contents B" {
+		t.Errorf("Expected the duplicate to be left alone, got %q", data)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestLinkFilesRefusesProtected(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "same")
+	dup := writeFile(t, dir, "dup.mp4", "same")
+
+	roots, err := NewReferenceRoots([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := LinkFiles([]LinkTarget{{Keep: keep.Path, Path: dup.Path}}, Hardlink, roots)
+	if len(results) != 1 || results[0].Linked || !errors.Is(results[0].Error, ErrProtected) {
+		t.Errorf("Expected ErrProtected for %s, got %+v", dup.Path, results)
+	}
+}
+
+func TestLinkFilesContinuesAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "same")
+	differs := writeFile(t, dir, "differs.mp4", "else")
+	dup := writeFile(t, dir, "dup.mp4", "same")
+
+	results := LinkFiles([]LinkTarget{
+		{Keep: keep.Path, Path: differs.Path},
+		{Keep: keep.Path, Path: dup.Path},
+		{Keep: keep.Path, Path: dup.Path},
+	}, Hardlink, nil)
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for every target, got %+v", results)
+	}
+	if results[0].Linked || !errors.Is(results[0].Error, ErrContentsDiffer) {
+		t.Errorf("Expected the differing file to fail, got %+v", results[0])
+	}
+	if !results[1].Linked || results[1].AlreadyLinked {
+		t.Errorf("Expected the duplicate after the failure to be linked, got %+v", results[1])
+	}
+	if !results[2].Linked || !results[2].AlreadyLinked {
+		t.Errorf("Expected the second attempt to find the link in place, got %+v", results[2])
+	}
+	if got := SummarizeLinks(results); !strings.HasPrefix(got, "2 linked, 1 failed ("+differs.Path) {
+		t.Errorf("SummarizeLinks = %q", got)
+	}
+}
+
+func TestCollectLinkTargets(t *testing.T) {
+	groups := []DuplicateGroup{
+		{
+			Files:    []FileMetadata{{Path: "a1"}, {Path: "a2"}, {Path: "a3"}},
+			Selected: []bool{true, false, true},
+		},
+		{
+			Files:    []FileMetadata{{Path: "b1"}, {Path: "b2"}},
+			Selected: []bool{true, true}, // Nothing left to link to
+		},
+	}
+
+	targets := CollectLinkTargets(groups)
+	want := []LinkTarget{{Keep: "a2", Path: "a1"}, {Keep: "a2", Path: "a3"}}
+	if len(targets) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, targets)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("Target %d: got %v, want %v", i, targets[i], want[i])
+		}
+	}
+}
+
+func TestApplyLinks(t *testing.T) {
+	groups := []DuplicateGroup{{
+		Files:    []FileMetadata{{Path: "a1"}, {Path: "a2"}, {Path: "a3"}},
+		Selected: []bool{true, false, false},
+	}}
+
+	groups = ApplyLinks(groups, []LinkResult{
+		{LinkTarget: LinkTarget{Keep: "a2", Path: "a1"}, Linked: true},
+		{LinkTarget: LinkTarget{Keep: "a2", Path: "a3"}, Error: ErrContentsDiffer},
+	})
+	if len(groups) != 1 || len(groups[0].Files) != 2 || len(groups[0].DeletedFiles) != 0 {
+		t.Errorf("Expected a1 to be dropped without being recorded as deleted, got %+v", groups)
+	}
+
+	if groups = ApplyLinks(groups, []LinkResult{{LinkTarget: LinkTarget{Path: "a3"}, Linked: true}}); len(groups) != 0 {
+		t.Errorf("Expected the group to be dropped once one file is left, got %+v", groups)
+	}
+}
+
+func TestParseLinkMode(t *testing.T) {
+	for _, mode := range []LinkMode{Hardlink, Reflink} {
+		got, err := ParseLinkMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseLinkMode(%q) = %v, %v", mode.String(), got, err)
+		}
+	}
+	if _, err := ParseLinkMode("symlink"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.vtlink"))
+	if len(matches) > 0 {
+		t.Errorf("Expected no leftover temp files, got %v", matches)
+	}
+}
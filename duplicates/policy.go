@@ -0,0 +1,313 @@
+package duplicates
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Rule compares two files of a group and prefers one of them
+type Rule struct {
+	spec    string
+	compare func(a, b FileMetadata) int // Negative when a is preferred, zero when it can't tell
+	missing func(FileMetadata) bool     // Whether a file lacks what the rule looks at; nil if it can't
+}
+
+// String returns the rule as it was written
+func (r Rule) String() string {
+	return r.spec
+}
+
+// Policy is an ordered list of rules that picks the file to keep in a group. The first rule
+// that prefers one file decides; files it can't tell apart go on to the next rule, and a
+// full tie keeps the earliest file in the group.
+type Policy []Rule
+
+// ParseRule parses one keep rule:
+//
+//	path:DIR[,DIR...]          files under an earlier directory first
+//	resolution:highest|lowest  by pixel count
+//	duration:longest|shortest
+//	size:largest|smallest
+//	mtime:newest|oldest
+//	codec:NAME[,NAME...]       files in an earlier codec first, named as ffprobe does (hevc, h264)
+//	name:GLOB                  files whose name matches first
+//	not-name:GLOB              files whose name doesn't match first
+//
+// Files missing what a rule looks at, such as an untagged file's resolution, are probed
+// with ffprobe by ProbeMissing, and come last if that doesn't tell either.
+func ParseRule(spec string) (Rule, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.TrimSpace(value)
+	rule := Rule{spec: strings.TrimSpace(spec)}
+
+	switch kind {
+	case "path":
+		dirs := splitList(value)
+		if len(dirs) == 0 {
+			return Rule{}, fmt.Errorf("keep rule %q needs a directory", spec)
+		}
+		roots := make([]ReferenceRoots, len(dirs))
+		for i, dir := range dirs {
+			roots[i] = ReferenceRoots{resolvePath(dir)}
+		}
+		rule.compare = func(a, b FileMetadata) int {
+			return cmp.Compare(pathRank(roots, a.Path), pathRank(roots, b.Path))
+		}
+
+	case "resolution":
+		higher, err := direction(spec, value, "highest", "lowest")
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.compare = func(a, b FileMetadata) int {
+			return compareKnown(pixels(a.Resolution), pixels(b.Resolution), higher)
+		}
+		rule.missing = func(f FileMetadata) bool { return f.Resolution == "" }
+
+	case "duration":
+		longer, err := direction(spec, value, "longest", "shortest")
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.compare = func(a, b FileMetadata) int {
+			return compareKnown(float64(a.DurationMins), float64(b.DurationMins), longer)
+		}
+		rule.missing = func(f FileMetadata) bool { return f.DurationMins == 0 }
+
+	case "size":
+		larger, err := direction(spec, value, "largest", "smallest")
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.compare = func(a, b FileMetadata) int {
+			return compareKnown(float64(a.Size), float64(b.Size), larger)
+		}
+
+	case "mtime":
+		newer, err := direction(spec, value, "newest", "oldest")
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.compare = func(a, b FileMetadata) int {
+			return compareKnown(float64(a.ModTime), float64(b.ModTime), newer)
+		}
+
+	case "codec":
+		codecs := splitList(strings.ToLower(value))
+		if len(codecs) == 0 {
+			return Rule{}, fmt.Errorf("keep rule %q needs a codec", spec)
+		}
+		rule.missing = func(f FileMetadata) bool { return f.Codec == "" }
+		rule.compare = func(a, b FileMetadata) int {
+			return cmp.Compare(listRank(codecs, strings.ToLower(a.Codec)), listRank(codecs, strings.ToLower(b.Codec)))
+		}
+
+	case "name", "not-name":
+		if _, err := filepath.Match(value, ""); err != nil || value == "" {
+			return Rule{}, fmt.Errorf("keep rule %q needs a valid name pattern", spec)
+		}
+		want := kind == "name"
+		rule.compare = func(a, b FileMetadata) int {
+			return compareBool(nameMatches(value, a.Path) == want, nameMatches(value, b.Path) == want)
+		}
+
+	default:
+		return Rule{}, fmt.Errorf("unknown keep rule %q (want path, resolution, duration, size, mtime, codec, name or not-name)", spec)
+	}
+
+	return rule, nil
+}
+
+// ParsePolicy parses keep rules in order of priority, ignoring blank ones
+func ParsePolicy(specs []string) (Policy, error) {
+	var policy Policy
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		rule, err := ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		policy = append(policy, rule)
+	}
+	return policy, nil
+}
+
+// String lists the rules in order
+func (p Policy) String() string {
+	specs := make([]string, len(p))
+	for i, rule := range p {
+		specs[i] = rule.spec
+	}
+	return strings.Join(specs, " > ")
+}
+
+// Missing reports whether the file lacks something a rule looks at and hasn't been probed yet
+func (p Policy) Missing(file FileMetadata) bool {
+	if file.Probed {
+		return false
+	}
+	return slices.ContainsFunc(p, func(rule Rule) bool { return rule.missing != nil && rule.missing(file) })
+}
+
+// FindKeepIndex returns the index of the file the policy keeps
+func (p Policy) FindKeepIndex(group *DuplicateGroup) int {
+	return bestIndex(group.Files, p.compare)
+}
+
+// Explain names the rules that decided which file the policy keeps in the group: for every
+// other file, the first rule that preferred the kept one
+func (p Policy) Explain(group *DuplicateGroup) string {
+	if len(group.Files) == 0 {
+		return ""
+	}
+	if hasProtected(group) {
+		return "reference copy"
+	}
+
+	keep := p.FindKeepIndex(group)
+	decided := make([]bool, len(p))
+	tied := false
+	for i, file := range group.Files {
+		if i == keep {
+			continue
+		}
+		rule := slices.IndexFunc(p, func(rule Rule) bool { return rule.compare(group.Files[keep], file) != 0 })
+		if rule < 0 {
+			tied = true
+			continue
+		}
+		decided[rule] = true
+	}
+
+	var specs []string
+	for i, rule := range p {
+		if decided[i] {
+			specs = append(specs, rule.spec)
+		}
+	}
+	if tied {
+		specs = append(specs, "tied, first in list")
+	}
+	return "rules: " + strings.Join(specs, ", ")
+}
+
+// Apply selects every file in the group except the one the policy keeps. As with the
+// auto-select strategies, a protected file is always the one kept.
+func (p Policy) Apply(group *DuplicateGroup) {
+	if len(group.Files) == 0 {
+		return
+	}
+	selectAllExcept(group, p.FindKeepIndex(group))
+}
+
+// Keeper returns the policy as a Keeper
+func (p Policy) Keeper() Keeper {
+	return Keeper{Apply: p.Apply, Missing: p.Missing, Explain: p.Explain}
+}
+
+// compare runs the rules in order until one prefers a file
+func (p Policy) compare(a, b FileMetadata) int {
+	for _, rule := range p {
+		if c := rule.compare(a, b); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// direction parses a rule's value into true for the first choice and false for the second
+func direction(spec, value, first, second string) (bool, error) {
+	switch strings.ToLower(value) {
+	case first:
+		return true, nil
+	case second:
+		return false, nil
+	default:
+		return false, fmt.Errorf("keep rule %q wants %s or %s", spec, first, second)
+	}
+}
+
+// splitList splits a comma-separated rule value, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// compareKnown prefers the larger value when higher is set and the smaller one otherwise.
+// Zero means unknown and comes after any known value.
+func compareKnown(a, b float64, higher bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a == 0:
+		return 1
+	case b == 0:
+		return -1
+	case higher:
+		return cmp.Compare(b, a)
+	default:
+		return cmp.Compare(a, b)
+	}
+}
+
+// compareBool prefers the side that is true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// pathRank returns the index of the first directory containing path, or len(roots) if none does
+func pathRank(roots []ReferenceRoots, path string) int {
+	for i, root := range roots {
+		if root.Contains(path) {
+			return i
+		}
+	}
+	return len(roots)
+}
+
+// listRank returns the index of value in list, or len(list) if it isn't there
+func listRank(list []string, value string) int {
+	if i := slices.Index(list, value); value != "" && i >= 0 {
+		return i
+	}
+	return len(list)
+}
+
+// nameMatches reports whether the file name of path matches the glob pattern
+func nameMatches(pattern, path string) bool {
+	matched, _ := filepath.Match(pattern, filepath.Base(path))
+	return matched
+}
+
+// pixels returns the pixel count of a WxH resolution, or 0 if it can't be read
+func pixels(resolution string) float64 {
+	w, h, ok := strings.Cut(strings.ToLower(resolution), "x")
+	if !ok {
+		return 0
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil {
+		return 0
+	}
+	return float64(width) * float64(height)
+}
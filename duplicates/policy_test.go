@@ -0,0 +1,92 @@
+package duplicates
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"bogus:1",
+		"path:",
+		"resolution:best",
+		"mtime",
+		"codec: , ",
+		"name:[",
+	} {
+		if _, err := ParseRule(spec); err == nil {
+			t.Errorf("ParseRule(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestPolicyFindKeepIndex(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "archive")
+	files := []FileMetadata{
+		{Path: "/incoming/a.mp4", Resolution: "1280x720", Size: 100, ModTime: 300, Codec: "h264"},
+		{Path: "/incoming/a (1).mp4", Resolution: "1920x1080", Size: 200, ModTime: 100, Codec: "h264"},
+		{Path: filepath.Join(archive, "a.mp4"), Resolution: "1280x720", Size: 50, ModTime: 200, Codec: "hevc"},
+		{Path: "/incoming/b.mp4", Resolution: "", Size: 400, ModTime: 400},
+	}
+
+	tests := []struct {
+		name  string
+		rules []string
+		keep  int
+	}{
+		{"Path first", []string{"path:" + archive, "resolution:highest"}, 2},
+		{"Unknown resolution comes last", []string{"resolution:lowest", "mtime:newest"}, 0},
+		{"Highest resolution", []string{"resolution:highest"}, 1},
+		{"Codec order", []string{"codec:hevc,h264"}, 2},
+		{"Codec tie falls through", []string{"codec:h264", "size:largest"}, 1},
+		{"Missing codec comes last", []string{"codec:av1", "mtime:newest"}, 3},
+		{"Avoid copies", []string{"not-name:* (1).mp4", "size:largest"}, 3},
+		{"Prefer a name", []string{"name:a (*"}, 1},
+		{"Oldest", []string{"mtime:oldest"}, 1},
+		{"Smallest", []string{"size:smallest"}, 2},
+		{"Full tie keeps the first file", []string{"duration:longest"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			group := DuplicateGroup{Files: files, Selected: make([]bool, len(files))}
+			if got := policy.FindKeepIndex(&group); got != tt.keep {
+				t.Errorf("FindKeepIndex(%s) = %d, want %d", policy, got, tt.keep)
+			}
+		})
+	}
+}
+
+func TestPolicyApplyKeepsProtected(t *testing.T) {
+	policy, err := ParsePolicy([]string{"size:largest", " "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy) != 1 || policy.Missing(FileMetadata{Path: "a.mp4"}) {
+		t.Fatalf("Expected one rule that needs no probing, got %v", policy)
+	}
+
+	group := DuplicateGroup{
+		Files: []FileMetadata{
+			{Path: "a.mp4", Size: 10, Protected: true},
+			{Path: "b.mp4", Size: 20},
+			{Path: "c.mp4", Size: 30},
+		},
+		Selected: make([]bool, 3),
+	}
+	policy.Apply(&group)
+	if group.Selected[0] || !group.Selected[1] || !group.Selected[2] {
+		t.Errorf("Expected the protected file to be kept, got %v", group.Selected)
+	}
+
+	group.Files[0].Protected = false
+	policy.Apply(&group)
+	if !group.Selected[0] || !group.Selected[1] || group.Selected[2] {
+		t.Errorf("Expected the largest file to be kept, got %v", group.Selected)
+	}
+}
@@ -0,0 +1,233 @@
+package duplicates
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/utils"
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// codecTiers ranks codecs for KeepBestCodec, best first, as ffprobe names them.
+// Codecs not listed come after MPEG-4, and files without a codec come last.
+var codecTiers = [][]string{
+	{"hevc", "av1"},
+	{"h264"},
+	{"mpeg4", "msmpeg4v3", "msmpeg4v2"},
+}
+
+// codecRank returns the tier of a codec, lower being better
+func codecRank(codec string) int {
+	if codec == "" {
+		return len(codecTiers) + 1
+	}
+	for i, tier := range codecTiers {
+		for _, name := range tier {
+			if strings.EqualFold(codec, name) {
+				return i
+			}
+		}
+	}
+	return len(codecTiers)
+}
+
+// qualityCompare returns the comparison a quality strategy ranks files by,
+// negative when a is preferred
+func qualityCompare(strategy AutoSelectStrategy) func(a, b FileMetadata) int {
+	switch strategy {
+	case KeepHighestResolution:
+		return func(a, b FileMetadata) int {
+			return compareKnown(pixels(a.Resolution), pixels(b.Resolution), true)
+		}
+	case KeepLongest:
+		return func(a, b FileMetadata) int {
+			return compareKnown(float64(a.DurationMins), float64(b.DurationMins), true)
+		}
+	case KeepBestCodec:
+		return func(a, b FileMetadata) int {
+			return cmp.Compare(codecRank(a.Codec), codecRank(b.Codec))
+		}
+	case KeepHighestBitsPerPixel:
+		return func(a, b FileMetadata) int {
+			return compareKnown(a.BitsPerPixel, b.BitsPerPixel, true)
+		}
+	default:
+		return func(a, b FileMetadata) int { return 0 }
+	}
+}
+
+// bestIndex returns the index of the file compare prefers, the earliest one on a tie
+func bestIndex(files []FileMetadata, compare func(a, b FileMetadata) int) int {
+	best := 0
+	for i := 1; i < len(files); i++ {
+		if compare(files[i], files[best]) < 0 {
+			best = i
+		}
+	}
+	return best
+}
+
+// Keeper picks the file to keep in a group, by an auto-select strategy or by keep rules
+type Keeper struct {
+	Apply   func(*DuplicateGroup)        // Selects every file but the one kept
+	Missing func(FileMetadata) bool      // Whether a file has to be probed before Apply
+	Explain func(*DuplicateGroup) string // Why the kept file won
+}
+
+// Keeper returns the strategy as a Keeper
+func (s AutoSelectStrategy) Keeper() Keeper {
+	return Keeper{
+		Apply:   func(group *DuplicateGroup) { ApplyAutoSelectStrategy(group, s) },
+		Missing: s.Missing,
+		Explain: func(group *DuplicateGroup) string { return ExplainKeep(group, s) },
+	}
+}
+
+// Missing reports whether the file lacks metadata the strategy needs and hasn't been probed yet
+func (s AutoSelectStrategy) Missing(file FileMetadata) bool {
+	if file.Probed {
+		return false
+	}
+	switch s {
+	case KeepHighestResolution:
+		return file.Resolution == ""
+	case KeepLongest:
+		return file.DurationMins == 0
+	case KeepBestCodec:
+		return file.Codec == ""
+	case KeepHighestBitsPerPixel:
+		return file.BitsPerPixel == 0
+	default:
+		return false
+	}
+}
+
+// NeedsProbe reports whether any file in the groups is missing something, as missing judges
+func NeedsProbe(groups []DuplicateGroup, missing func(FileMetadata) bool) bool {
+	for _, group := range groups {
+		for _, file := range group.Files {
+			if missing(file) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ProbeMissing runs ffprobe on every file missing something, as missing judges, and fills
+// in what the filename didn't give. Each file is probed at most once; a file that can't be
+// probed keeps what it had, and strategies rank the missing values last.
+func ProbeMissing(groups []DuplicateGroup, missing func(FileMetadata) bool) {
+	for g := range groups {
+		for i := range groups[g].Files {
+			if missing(groups[g].Files[i]) {
+				probeFile(&groups[g].Files[i])
+			}
+		}
+	}
+}
+
+// probeFile fills in a file's metadata from ffprobe, keeping the tags from its filename
+func probeFile(file *FileMetadata) {
+	file.Probed = true
+	info, err := video.ProbeVideo(file.Path)
+	if err != nil {
+		return
+	}
+
+	if file.Resolution == "" {
+		file.Resolution = info.Resolution
+	}
+	if file.DurationMins == 0 {
+		file.DurationMins = int(math.Round(info.DurationMins))
+	}
+	if file.Codec == "" {
+		file.Codec = info.Codec
+	}
+	file.BitsPerPixel = info.BitsPerPixel()
+}
+
+// ExplainKeep describes why the strategy keeps the file it does in the group,
+// such as "highest resolution: 1920x1080 over 1280x720"
+func ExplainKeep(group *DuplicateGroup, strategy AutoSelectStrategy) string {
+	if len(group.Files) == 0 {
+		return ""
+	}
+	if hasProtected(group) {
+		return "reference copy"
+	}
+
+	criterion, value, prefer := keepMeasure(strategy)
+	if value == nil || len(group.Files) == 1 {
+		return criterion
+	}
+
+	// The runner-up is the best of the others by the same measure
+	keep := FindKeepIndex(group, strategy)
+	others := make([]FileMetadata, 0, len(group.Files)-1)
+	others = append(others, group.Files[:keep]...)
+	others = append(others, group.Files[keep+1:]...)
+	kept, runnerUp := group.Files[keep], others[bestIndex(others, prefer)]
+
+	if prefer(kept, runnerUp) == 0 {
+		return fmt.Sprintf("%s: %s, tied, first in list", criterion, value(kept))
+	}
+	return fmt.Sprintf("%s: %s over %s", criterion, value(kept), value(runnerUp))
+}
+
+// keepMeasure returns how a strategy is described, how it shows a file's value, and its
+// preference between two files. Strategies without a value to show return a nil value.
+func keepMeasure(strategy AutoSelectStrategy) (criterion string, value func(FileMetadata) string, prefer func(a, b FileMetadata) int) {
+	modTime := func(f FileMetadata) string { return time.Unix(f.ModTime, 0).Format(time.DateTime) }
+	size := func(f FileMetadata) string { return utils.FormatBytes(f.Size) }
+
+	switch strategy {
+	case KeepNewest:
+		return "newest", modTime, func(a, b FileMetadata) int { return cmp.Compare(b.ModTime, a.ModTime) }
+	case KeepOldest:
+		return "oldest", modTime, func(a, b FileMetadata) int { return cmp.Compare(a.ModTime, b.ModTime) }
+	case KeepLargest:
+		return "largest", size, func(a, b FileMetadata) int { return cmp.Compare(b.Size, a.Size) }
+	case KeepSmallest:
+		return "smallest", size, func(a, b FileMetadata) int { return cmp.Compare(a.Size, b.Size) }
+	case KeepFirst:
+		return "first by name", nil, nil
+	case KeepLast:
+		return "last by name", nil, nil
+	case KeepFirstPosition:
+		return "first in list", nil, nil
+	case KeepLastPosition:
+		return "last in list", nil, nil
+	case KeepHighestResolution:
+		return "highest resolution", func(f FileMetadata) string { return orUnknown(f.Resolution) }, qualityCompare(strategy)
+	case KeepLongest:
+		return "longest", func(f FileMetadata) string {
+			if f.DurationMins == 0 {
+				return "unknown"
+			}
+			return fmt.Sprintf("%dmin", f.DurationMins)
+		}, qualityCompare(strategy)
+	case KeepBestCodec:
+		return "best codec", func(f FileMetadata) string { return orUnknown(f.Codec) }, qualityCompare(strategy)
+	case KeepHighestBitsPerPixel:
+		return "most bits per pixel", func(f FileMetadata) string {
+			if f.BitsPerPixel == 0 {
+				return "unknown"
+			}
+			return fmt.Sprintf("%.3f", f.BitsPerPixel)
+		}, qualityCompare(strategy)
+	default:
+		return strategy.String(), nil, nil
+	}
+}
+
+// orUnknown returns s, or "unknown" if it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
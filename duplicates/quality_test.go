@@ -0,0 +1,117 @@
+package duplicates
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestQualityStrategies(t *testing.T) {
+	files := []FileMetadata{
+		{Path: "a.mp4", Resolution: "1280x720", DurationMins: 42, Codec: "h264", BitsPerPixel: 0.12},
+		{Path: "b.mp4", Resolution: "1920x1080", DurationMins: 41, Codec: "mpeg4", BitsPerPixel: 0.05},
+		{Path: "c.mp4", Resolution: "", DurationMins: 0, Codec: "hevc", BitsPerPixel: 0},
+		{Path: "d.mp4", Resolution: "1920x1080", DurationMins: 42, Codec: "vp8", BitsPerPixel: 0.2},
+	}
+
+	tests := []struct {
+		strategy AutoSelectStrategy
+		keep     int
+		reason   string
+	}{
+		{KeepHighestResolution, 1, "highest resolution: 1920x1080, tied, first in list"},
+		{KeepLongest, 0, "longest: 42min, tied, first in list"},
+		{KeepBestCodec, 2, "best codec: hevc over h264"},
+		{KeepHighestBitsPerPixel, 3, "most bits per pixel: 0.200 over 0.120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			group := &DuplicateGroup{Files: files, Selected: make([]bool, len(files))}
+			if got := FindKeepIndex(group, tt.strategy); got != tt.keep {
+				t.Errorf("FindKeepIndex = %d, want %d", got, tt.keep)
+			}
+			if got := ExplainKeep(group, tt.strategy); got != tt.reason {
+				t.Errorf("ExplainKeep = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCodecRank(t *testing.T) {
+	order := []string{"HEVC", "h264", "msmpeg4v3", "vp8", ""}
+	for i := 1; i < len(order); i++ {
+		if codecRank(order[i-1]) >= codecRank(order[i]) {
+			t.Errorf("Expected %q to rank before %q", order[i-1], order[i])
+		}
+	}
+	if codecRank("av1") != codecRank("hevc") {
+		t.Error("Expected AV1 and HEVC in the same tier")
+	}
+}
+
+func TestExplainKeepProtectedAndUnmeasured(t *testing.T) {
+	group := &DuplicateGroup{
+		Files:    []FileMetadata{{Path: "a.mp4", Size: 10}, {Path: "b.mp4", Size: 20, Protected: true}},
+		Selected: make([]bool, 2),
+	}
+	if got := ExplainKeep(group, KeepLargest); got != "reference copy" {
+		t.Errorf("Expected a protected file to be kept as the reference copy, got %q", got)
+	}
+
+	group.Files[1].Protected = false
+	if got := ExplainKeep(group, KeepFirstPosition); got != "first in list" {
+		t.Errorf("ExplainKeep(KeepFirstPosition) = %q", got)
+	}
+	if got := ExplainKeep(group, KeepLargest); got != "largest: 20 B over 10 B" {
+		t.Errorf("ExplainKeep(KeepLargest) = %q", got)
+	}
+}
+
+func TestProbeMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.mp4")
+	groups := []DuplicateGroup{{
+		Files: []FileMetadata{
+			{Path: "tagged_[1920x1080][5min][ABCD1234].mp4", Resolution: "1920x1080"},
+			{Path: missing},
+		},
+		Selected: make([]bool, 2),
+	}}
+
+	if !NeedsProbe(groups, KeepHighestResolution.Missing) {
+		t.Fatal("Expected an untagged file to need probing")
+	}
+	if NeedsProbe(groups, KeepLargest.Missing) {
+		t.Error("Expected KeepLargest to need no probing")
+	}
+
+	// A file that can't be probed is marked anyway so it isn't probed again
+	ProbeMissing(groups, KeepHighestResolution.Missing)
+	if groups[0].Files[0].Probed || !groups[0].Files[1].Probed {
+		t.Errorf("Expected only the untagged file to be probed, got %+v", groups[0].Files)
+	}
+	if NeedsProbe(groups, KeepHighestResolution.Missing) {
+		t.Error("Expected no more probing after ProbeMissing")
+	}
+}
+
+func TestPolicyExplain(t *testing.T) {
+	policy, err := ParsePolicy([]string{"codec:hevc", "resolution:highest", "size:largest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := &DuplicateGroup{
+		Files: []FileMetadata{
+			{Path: "a.mp4", Codec: "h264", Resolution: "1920x1080"},
+			{Path: "b.mp4", Codec: "hevc", Resolution: "1920x1080"},
+			{Path: "c.mp4", Codec: "hevc", Resolution: "1280x720"},
+			{Path: "d.mp4", Codec: "hevc", Resolution: "1920x1080"},
+		},
+		Selected: make([]bool, 4),
+	}
+	if got, want := policy.Explain(group), "rules: codec:hevc, resolution:highest, tied, first in list"; got != want {
+		t.Errorf("Explain = %q, want %q", got, want)
+	}
+	if !policy.Missing(FileMetadata{Path: "e.mp4"}) || policy.Missing(FileMetadata{Path: "e.mp4", Probed: true}) {
+		t.Error("Expected an unprobed file without a codec to be missing, and a probed one not")
+	}
+}
@@ -0,0 +1,80 @@
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrProtected is returned when a file in a reference directory would be selected or deleted
+var ErrProtected = errors.New("file is in a protected reference directory")
+
+// ReferenceRoots are directories whose files are never selected for deletion.
+// A duplicate found elsewhere can be removed, the copy in the reference tree is always kept.
+type ReferenceRoots []string
+
+// NewReferenceRoots resolves the given directories to absolute paths without symlinks,
+// so a file can't slip past the check through a different spelling of the same path
+func NewReferenceRoots(directories []string) (ReferenceRoots, error) {
+	roots := make(ReferenceRoots, 0, len(directories))
+	for _, directory := range directories {
+		info, err := os.Stat(directory)
+		if err != nil {
+			return nil, fmt.Errorf("reference directory not accessible: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("reference path is not a directory: %s", directory)
+		}
+		roots = append(roots, resolvePath(directory))
+	}
+	return roots, nil
+}
+
+// Contains reports whether a path is inside one of the reference directories
+func (r ReferenceRoots) Contains(path string) bool {
+	if len(r) == 0 {
+		return false
+	}
+
+	// Resolve the parent rather than the file, so a symlink in the tree is judged
+	// by where it lives: removing it wouldn't touch its target
+	resolved := filepath.Join(resolvePath(filepath.Dir(path)), filepath.Base(path))
+	for _, root := range r {
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark flags the files inside the reference directories as protected and clears their selection
+func (r ReferenceRoots) Mark(groups []DuplicateGroup) {
+	for g := range groups {
+		for i := range groups[g].Files {
+			if !r.Contains(groups[g].Files[i].Path) {
+				continue
+			}
+			groups[g].Files[i].Protected = true
+			if i < len(groups[g].Selected) {
+				groups[g].Selected[i] = false
+			}
+		}
+	}
+}
+
+// resolvePath returns an absolute path with symlinks evaluated, or as much of that as possible
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,148 @@
+package duplicates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReferenceRootsContains(t *testing.T) {
+	base := t.TempDir()
+	archive := filepath.Join(base, "archive")
+	archived := filepath.Join(base, "archive-old")
+	for _, dir := range []string{filepath.Join(archive, "nested"), archived} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots, err := NewReferenceRoots([]string{archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(archive, "a.mp4"), true},
+		{filepath.Join(archive, "nested", "b.mp4"), true},
+		{filepath.Join(archive, "..", "archive", "c.mp4"), true},
+		{filepath.Join(archived, "d.mp4"), false}, // Shares a prefix, not a parent
+		{filepath.Join(base, "e.mp4"), false},
+	}
+	for _, tt := range tests {
+		if got := roots.Contains(tt.path); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if ReferenceRoots(nil).Contains(filepath.Join(archive, "a.mp4")) {
+		t.Error("Expected no roots to protect nothing")
+	}
+}
+
+func TestReferenceRootsContainsThroughSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+
+	base := t.TempDir()
+	archive := filepath.Join(base, "archive")
+	if err := os.Mkdir(archive, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(archive, link); err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := NewReferenceRoots([]string{archive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roots.Contains(filepath.Join(link, "a.mp4")) {
+		t.Error("Expected a path through a symlink to the reference directory to be protected")
+	}
+}
+
+func TestNewReferenceRootsRejectsFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.mp4")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReferenceRoots([]string{file}); err == nil {
+		t.Error("Expected an error for a reference path that is a file")
+	}
+}
+
+func protectedGroup() DuplicateGroup {
+	return DuplicateGroup{
+		Files: []FileMetadata{
+			{Path: "/incoming/a.mp4", ModTime: 300},
+			{Path: "/archive/a.mp4", ModTime: 100, Protected: true},
+			{Path: "/backup/a.mp4", ModTime: 200},
+		},
+		Selected: make([]bool, 3),
+	}
+}
+
+func TestApplyAutoSelectStrategyKeepsProtected(t *testing.T) {
+	for _, strategy := range []AutoSelectStrategy{KeepNewest, KeepOldest, KeepFirstPosition, KeepLastPosition} {
+		group := protectedGroup()
+		ApplyAutoSelectStrategy(&group, strategy)
+
+		want := []bool{true, false, true}
+		for i := range want {
+			if group.Selected[i] != want[i] {
+				t.Errorf("Strategy %d: selected = %v, want %v", strategy, group.Selected, want)
+				break
+			}
+		}
+	}
+}
+
+func TestSetSelectedRefusesProtected(t *testing.T) {
+	group := protectedGroup()
+
+	if err := SetSelected(&group, 1, true); !errors.Is(err, ErrProtected) {
+		t.Errorf("Expected ErrProtected, got %v", err)
+	}
+	if group.Selected[1] {
+		t.Error("Expected the protected file to stay unselected")
+	}
+	if err := SetSelected(&group, 0, true); err != nil || !group.Selected[0] {
+		t.Errorf("Expected an unprotected file to be selectable, got %v", err)
+	}
+	if err := SetSelected(&group, 5, true); err == nil {
+		t.Error("Expected an error for an out of range index")
+	}
+
+	SelectAll(&group)
+	if !group.Selected[0] || group.Selected[1] || !group.Selected[2] {
+		t.Errorf("Expected SelectAll to skip the protected file, got %v", group.Selected)
+	}
+}
+
+func TestMarkProtected(t *testing.T) {
+	base := t.TempDir()
+	roots, err := NewReferenceRoots([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := []DuplicateGroup{{
+		Files:    []FileMetadata{{Path: filepath.Join(base, "a.mp4")}, {Path: "/elsewhere/a.mp4"}},
+		Selected: []bool{true, true},
+	}}
+	roots.Mark(groups)
+
+	if !groups[0].Files[0].Protected || groups[0].Files[1].Protected {
+		t.Errorf("Unexpected protection: %+v", groups[0].Files)
+	}
+	if groups[0].Selected[0] || !groups[0].Selected[1] {
+		t.Errorf("Expected only the protected file's selection to be cleared, got %v", groups[0].Selected)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package duplicates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile makes dst share src's extents with the FICLONE ioctl
+func cloneFile(dst, src *os.File) error {
+	err := unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+	if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOTTY) {
+		return fmt.Errorf("%w: the filesystem can't clone %s", ErrReflinkUnsupported, src.Name())
+	}
+	return err
+}
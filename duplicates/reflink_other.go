@@ -0,0 +1,10 @@
+//go:build !linux
+
+package duplicates
+
+import "os"
+
+// cloneFile is only implemented on Linux
+func cloneFile(_, _ *os.File) error {
+	return ErrReflinkUnsupported
+}
@@ -0,0 +1,39 @@
+package duplicates
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteScript writes a POSIX shell script that removes the selected files, for review before
+// running it. Each group is listed with the file it keeps, and a duplicate is only removed
+// while its kept file still exists. The comment lines in header go at the top.
+func WriteScript(w io.Writer, groups []DuplicateGroup, header ...string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#!/bin/sh")
+	for _, line := range header {
+		fmt.Fprintf(bw, "# %s\n", line)
+	}
+
+	for _, group := range groups {
+		targets := CollectLinkTargets([]DuplicateGroup{group})
+		if len(targets) == 0 {
+			continue
+		}
+
+		keep := shellQuote(targets[0].Keep)
+		fmt.Fprintf(bw, "\n# %s: keep %s\n", group.Hash, keep)
+		for _, target := range targets {
+			fmt.Fprintf(bw, "[ -e %s ] && rm -- %s\n", keep, shellQuote(target.Path))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,51 @@
+package duplicates
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteScript(t *testing.T) {
+	dir := t.TempDir()
+	keep := writeFile(t, dir, "keep.mp4", "data")
+	quoted := writeFile(t, dir, "it's a copy.mp4", "data")
+	untouched := writeFile(t, dir, "other.mp4", "more")
+	groups := []DuplicateGroup{
+		{Hash: "11111111", Files: []FileMetadata{keep, quoted}, Selected: []bool{false, true}},
+		{Hash: "22222222", Files: []FileMetadata{untouched, untouched}, Selected: []bool{false, false}},
+	}
+
+	var script strings.Builder
+	if err := WriteScript(&script, groups, "Generated for a test"); err != nil {
+		t.Fatal(err)
+	}
+	text := script.String()
+	if !strings.HasPrefix(text, "#!/bin/sh\n# Generated for a test\n") {
+		t.Errorf("Expected the shebang and header first, got:\n%s", text)
+	}
+	if strings.Contains(text, "22222222") {
+		t.Errorf("Expected groups without a selection to be left out, got:\n%s", text)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	path := filepath.Join(dir, "remove.sh")
+	if err := os.WriteFile(path, []byte(text), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("sh", path).CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, output)
+	}
+	if _, err := os.Stat(quoted.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", quoted.Path, err)
+	}
+	for _, file := range []FileMetadata{keep, untouched} {
+		if _, err := os.Stat(file.Path); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", file.Path, err)
+		}
+	}
+}
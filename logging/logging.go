@@ -0,0 +1,230 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+)
+
+// Supported log formats
+const (
+	FormatPretty = "pretty"
+	FormatText   = "text"
+	FormatJSON   = "json"
+)
+
+// Standard event attribute keys shared by all commands
+const (
+	KeyOp         = "op"
+	KeyPath       = "path"
+	KeyDuration   = "duration"
+	KeyError      = "error"
+	KeyErrorClass = "error_class"
+	KeyWorker     = "worker"
+	KeyStatus     = "status"
+)
+
+// Event status values, used by the pretty renderer to pick icons and styles
+const (
+	StatusHeader  = "header"
+	StatusStart   = "start"
+	StatusOK      = "ok"
+	StatusSkipped = "skipped"
+	StatusWarning = "warning"
+	StatusFailed  = "failed"
+	StatusSummary = "summary"
+)
+
+// Options configures where and how events are written
+type Options struct {
+	Format string // Event format: pretty, text or json
+	File   string // Optional log file; the terminal keeps the pretty renderer when set
+	Level  string // Minimum level: debug, info, warn or error
+	Stderr bool   // Keep stdout free for machine-readable results by writing all events to stderr
+}
+
+// interactive records whether the terminal is rendered by the pretty handler
+var interactive atomic.Bool
+
+func init() {
+	interactive.Store(true)
+}
+
+// Interactive reports whether terminal output is the pretty renderer, meaning
+// progress bars and other terminal-only decorations may be drawn
+func Interactive() bool {
+	return interactive.Load()
+}
+
+// Setup installs the default slog logger according to opts and returns a function
+// that closes any opened log file.
+func Setup(opts Options) (func() error, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatPretty
+	}
+
+	// Progress bars draw on stdout, so they are only shown when it belongs to the renderer
+	terminal := os.Stdout
+	if opts.Stderr {
+		terminal = os.Stderr
+	}
+
+	closer := func() error { return nil }
+	var handler slog.Handler
+
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		closer = f.Close
+
+		// Pretty output is meant for terminals, so log files fall back to text
+		fileFormat := format
+		if fileFormat == FormatPretty {
+			fileFormat = FormatText
+		}
+
+		handler = slog.NewMultiHandler(
+			NewPrettyHandler(terminal, level),
+			newHandler(f, fileFormat, level),
+		)
+		interactive.Store(!opts.Stderr)
+	} else {
+		if format == FormatPretty {
+			handler = NewPrettyHandler(terminal, level)
+		} else {
+			handler = newHandler(os.Stderr, format, level)
+		}
+		interactive.Store(format == FormatPretty && !opts.Stderr)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return closer, nil
+}
+
+// newHandler creates a structured handler for the given format.
+// Durations are written as fractional seconds so log processors don't need to know Go's units.
+func newHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Value.Kind() == slog.KindDuration {
+				return slog.Float64(a.Key, a.Value.Duration().Seconds())
+			}
+			return a
+		},
+	}
+	if format == FormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// ParseLevel converts a level name into a slog.Level, defaulting to info
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// ForOp returns the default logger tagged with an operation name
+func ForOp(op string) *slog.Logger {
+	return slog.Default().With(KeyOp, op)
+}
+
+// Classifier is implemented by errors that know their own class, such as integrity
+// problems found in a video file
+type Classifier interface {
+	ErrorClass() string
+}
+
+// ClassifyError maps an error to a short, stable class name for log filtering
+func ClassifyError(err error) string {
+	var classifier Classifier
+	var exitErr *exec.ExitError
+	var pathErr *fs.PathError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &classifier):
+		return classifier.ErrorClass()
+	case errors.Is(err, fs.ErrNotExist):
+		return "not_found"
+	case errors.Is(err, fs.ErrPermission):
+		return "permission"
+	case errors.Is(err, exec.ErrNotFound):
+		return "missing_dependency"
+	case errors.As(err, &exitErr):
+		return "external_tool"
+	case errors.As(err, &pathErr):
+		return "io"
+	default:
+		return "error"
+	}
+}
+
+// Header logs a section heading, rendered as a banner on the terminal
+func Header(logger *slog.Logger, msg string, args ...any) {
+	logger.Info(msg, append([]any{KeyStatus, StatusHeader}, args...)...)
+}
+
+// Start logs the beginning of work on a file
+func Start(logger *slog.Logger, msg, path string, args ...any) {
+	logger.Info(msg, append([]any{KeyPath, path, KeyStatus, StatusStart}, args...)...)
+}
+
+// Success logs a successfully completed file operation
+func Success(logger *slog.Logger, msg, path string, args ...any) {
+	logger.Info(msg, append([]any{KeyPath, path, KeyStatus, StatusOK}, args...)...)
+}
+
+// Skipped logs a file that was intentionally not processed
+func Skipped(logger *slog.Logger, msg, path string, args ...any) {
+	logger.Warn(msg, append([]any{KeyPath, path, KeyStatus, StatusSkipped}, args...)...)
+}
+
+// Warning logs a problem found with a file that was still processed, with a class naming the
+// problem the way error_class names failures
+func Warning(logger *slog.Logger, msg, path, class string, args ...any) {
+	logger.Warn(msg, append([]any{KeyPath, path, KeyStatus, StatusWarning, KeyErrorClass, class}, args...)...)
+}
+
+// Failure logs a failed file operation along with its error class
+func Failure(logger *slog.Logger, msg, path string, err error, args ...any) {
+	attrs := []any{KeyStatus, StatusFailed}
+	if path != "" {
+		attrs = append(attrs, KeyPath, path)
+	}
+	if err != nil {
+		attrs = append(attrs, KeyError, err.Error(), KeyErrorClass, ClassifyError(err))
+	}
+	logger.Error(msg, append(attrs, args...)...)
+}
+
+// Summary logs the final totals of a command run
+func Summary(logger *slog.Logger, msg string, args ...any) {
+	logger.Info(msg, append([]any{KeyStatus, StatusSummary}, args...)...)
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		expected  slog.Level
+		expectErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		level, err := ParseLevel(tt.name)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("ParseLevel(%q) error = %v, expectErr %v", tt.name, err, tt.expectErr)
+		}
+		if level != tt.expected {
+			t.Errorf("ParseLevel(%q) = %v, expected %v", tt.name, level, tt.expected)
+		}
+	}
+}
+
+// classifiedError reports its own class, like video.IntegrityError
+type classifiedError struct{}
+
+func (classifiedError) Error() string      { return "file ends early" }
+func (classifiedError) ErrorClass() string { return "truncated" }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, ""},
+		{"not found", fmt.Errorf("wrapped: %w", fs.ErrNotExist), "not_found"},
+		{"permission", &fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}, "permission"},
+		{"io", &fs.PathError{Op: "read", Path: "x", Err: fmt.Errorf("bad sector")}, "io"},
+		{"missing tool", &exec.Error{Name: "ffprobe", Err: exec.ErrNotFound}, "missing_dependency"},
+		{"generic", fmt.Errorf("something else"), "error"},
+		{"self-classified", fmt.Errorf("wrapped: %w", classifiedError{}), "truncated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.expected {
+				t.Errorf("ClassifyError() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPrettyHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewPrettyHandler(&buf, slog.LevelInfo)).With(KeyOp, "verify", KeyWorker, 2)
+
+	Success(logger, "Verified", "/videos/a.mp4", KeyDuration, time.Second)
+	Failure(logger, "Hash mismatch", "/videos/b.mp4", fmt.Errorf("boom"), "expected", "AAAA")
+	Skipped(logger, "Not a video file", "/videos/c.txt")
+	Warning(logger, "Metadata tags out of date", "/videos/e.mp4", "metadata_drift", "tagged", "5min")
+	Summary(logger, "Verification complete", "verified", 1, "saved_bytes", int64(2048))
+	logger.Debug("Hidden at info level", KeyPath, "/videos/d.mp4")
+
+	output := buf.String()
+	expectations := []string{
+		"✅ Verified: /videos/a.mp4",
+		"❌ Hash mismatch: /videos/b.mp4 (boom, expected=AAAA)",
+		"⏭️  Not a video file: /videos/c.txt",
+		"⚠️  Metadata tags out of date: /videos/e.mp4 (tagged=5min)",
+		"📈 Verification complete (verified=1, saved_bytes=2.0 KB)",
+	}
+	for _, expected := range expectations {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	// Machine-only attributes are not rendered on the terminal
+	for _, hidden := range []string{"op=", "worker=", "error_class=", "duration="} {
+		if strings.Contains(output, hidden) {
+			t.Errorf("Expected output not to contain %q, got:\n%s", hidden, output)
+		}
+	}
+
+	if strings.Contains(output, "Hidden at info level") {
+		t.Error("Debug events should be filtered at info level")
+	}
+}
+
+func TestSetup_LogFile(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+
+	logFile := filepath.Join(t.TempDir(), "videotagger.log")
+	closeLog, err := Setup(Options{Format: FormatJSON, File: logFile, Level: "info"})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	if !Interactive() {
+		t.Error("Terminal should keep the pretty renderer when logging to a file")
+	}
+
+	Failure(ForOp("tag").With(KeyWorker, 3), "Error processing", "/videos/a.mp4", fs.ErrNotExist, KeyDuration, 1500*time.Millisecond)
+	if err := closeLog(); err != nil {
+		t.Fatalf("Failed to close log file: %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+
+	var event map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(data), &event); err != nil {
+		t.Fatalf("Log file is not JSON: %v\n%s", err, data)
+	}
+
+	expected := map[string]any{
+		KeyOp:         "tag",
+		KeyPath:       "/videos/a.mp4",
+		KeyWorker:     float64(3),
+		KeyErrorClass: "not_found",
+		KeyStatus:     StatusFailed,
+		KeyDuration:   1.5,
+	}
+	for key, value := range expected {
+		if event[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, event[key])
+		}
+	}
+}
+
+func TestSetup_StructuredTerminal(t *testing.T) {
+	previous := slog.Default()
+	defer slog.SetDefault(previous)
+	defer interactive.Store(true)
+
+	closeLog, err := Setup(Options{Format: FormatText})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer func() { _ = closeLog() }()
+
+	if Interactive() {
+		t.Error("Structured terminal output should disable interactive decorations")
+	}
+}
+
+func TestSetup_InvalidLevel(t *testing.T) {
+	if _, err := Setup(Options{Level: "loud"}); err == nil {
+		t.Error("Expected error for invalid log level")
+	}
+}
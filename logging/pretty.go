@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+// Styling definitions for the terminal renderer
+var (
+	headerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("86")).
+			Background(lipgloss.Color("235")).
+			Bold(true).
+			Padding(0, 2)
+
+	processingStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39")).
+			Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46")).
+			Bold(true)
+
+	infoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("33"))
+)
+
+// hiddenKeys are attributes the terminal renderer omits because they are
+// only useful for machine consumption
+var hiddenKeys = map[string]bool{
+	KeyOp:         true,
+	KeyStatus:     true,
+	KeyWorker:     true,
+	KeyErrorClass: true,
+	KeyDuration:   true,
+	KeyPath:       true,
+	KeyError:      true,
+}
+
+// PrettyHandler renders events as styled, human-readable terminal lines
+type PrettyHandler struct {
+	w      io.Writer
+	level  slog.Leveler
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewPrettyHandler creates a terminal renderer writing to w
+func NewPrettyHandler(w io.Writer, level slog.Leveler) *PrettyHandler {
+	return &PrettyHandler{
+		w:     w,
+		level: level,
+		mu:    &sync.Mutex{},
+	}
+}
+
+// Enabled implements slog.Handler
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// WithAttrs implements slog.Handler
+func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup implements slog.Handler
+func (h *PrettyHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
+
+// Handle implements slog.Handler
+func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var status, path, errText string
+	var details []string
+
+	collect := func(a slog.Attr) bool {
+		switch a.Key {
+		case KeyStatus:
+			status = a.Value.String()
+		case KeyPath:
+			path = a.Value.String()
+		case KeyError:
+			errText = a.Value.String()
+		}
+		if !hiddenKeys[a.Key] {
+			details = append(details, fmt.Sprintf("%s=%s", a.Key, formatValue(a)))
+		}
+		return true
+	}
+
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	r.Attrs(collect)
+
+	line := r.Message
+	if path != "" {
+		line += ": " + path
+	}
+	if errText != "" {
+		details = append([]string{errText}, details...)
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.w, render(r.Level, status, line))
+	return err
+}
+
+// render picks the icon and style for an event
+func render(level slog.Level, status, line string) string {
+	switch {
+	case status == StatusHeader:
+		return headerStyle.Render(line)
+	case level >= slog.LevelError || status == StatusFailed:
+		return errorStyle.Render("❌ " + line)
+	case status == StatusSkipped:
+		return "⏭️  " + line
+	case level >= slog.LevelWarn || status == StatusWarning:
+		return "⚠️  " + line
+	case status == StatusOK:
+		return successStyle.Render("✅ " + line)
+	case status == StatusStart:
+		return processingStyle.Render("📊 " + line)
+	case status == StatusSummary:
+		return infoStyle.Render("📈 " + line)
+	default:
+		return line
+	}
+}
+
+// formatValue renders attribute values for humans, e.g. byte counts as sizes
+func formatValue(a slog.Attr) string {
+	value := a.Value.Resolve()
+
+	if strings.HasSuffix(a.Key, "bytes") && value.Kind() == slog.KindInt64 {
+		return utils.FormatBytes(value.Int64())
+	}
+	if value.Kind() == slog.KindDuration {
+		return value.Duration().Round(time.Millisecond).String()
+	}
+	if value.Kind() == slog.KindFloat64 {
+		return fmt.Sprintf("%.1f", value.Float64())
+	}
+
+	return value.String()
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/alecthomas/kong"
 	"github.com/lepinkainen/videotagger/cmd"
+	"github.com/lepinkainen/videotagger/config"
+	"github.com/lepinkainen/videotagger/logging"
 	"github.com/lepinkainen/videotagger/types"
 	"github.com/lepinkainen/videotagger/utils"
 )
@@ -21,29 +25,104 @@ func (v *VersionCmd) Run() error {
 
 // CLI defines the command-line interface structure with all available commands
 type CLI struct {
+	Profile   string `help:"Named settings profile from the config file" env:"VIDEOTAGGER_PROFILE"`
+	LogFormat string `help:"Event output format (pretty renders for terminals)" default:"pretty" enum:"pretty,text,json"`
+	LogFile   string `help:"Also write events to this file (uses text format when --log-format is pretty)" type:"path"`
+	LogLevel  string `help:"Minimum event level" default:"info" enum:"debug,info,warn,error"`
+
 	Tag        *cmd.TagCmd        `cmd:"" help:"Tag video files with metadata and hash"`
 	Duplicates *cmd.DuplicatesCmd `cmd:"" help:"Find duplicate files by hash"`
 	Verify     *cmd.VerifyCmd     `cmd:"" help:"Verify file hash integrity"`
 	Phash      *cmd.PhashCmd      `cmd:"" help:"Find perceptually similar videos"`
 	Reencode   *cmd.ReencodeCmd   `cmd:"" help:"Re-encode videos to H.265/HEVC for space savings"`
+	Repair     *cmd.RepairCmd     `cmd:"" help:"Recover truncated or unindexed videos into new files"`
+	Stats      *cmd.StatsCmd      `cmd:"" help:"Report library statistics by resolution, codec, container, duration and year"`
+	SFV        *cmd.SFVCmd        `cmd:"" name:"sfv" help:"Export and verify SFV checksum files"`
+	Manifest   *cmd.ManifestCmd   `cmd:"" help:"Create and check sha256sum/b3sum checksum manifests"`
+	Parity     *cmd.ParityCmd     `cmd:"" help:"Create Reed-Solomon recovery data and repair damaged files with it"`
+	Quarantine *cmd.QuarantineCmd `cmd:"" help:"Manage files quarantined by verify --on-fail"`
+	Trash      *cmd.TrashCmd      `cmd:"" help:"Restore files deleted to the trash by duplicates"`
+	Config     *cmd.ConfigCmd     `cmd:"" help:"Inspect configuration files and profiles"`
 	Version    *VersionCmd        `cmd:"" help:"Show version information"`
 }
 
+// commandsWithoutFFmpeg lists commands that can run without FFmpeg installed, by their
+// subcommand names alone so omitted positionals don't change the match
+var commandsWithoutFFmpeg = map[string]bool{
+	"version":            true,
+	"config show":        true,
+	"sfv export":         true,
+	"sfv verify":         true,
+	"manifest create":    true,
+	"manifest check":     true,
+	"quarantine restore": true,
+	"trash restore":      true,
+	"parity create":      true,
+	"parity repair":      true,
+}
+
+// commandName returns the selected subcommands without their positionals, e.g.
+// "manifest create" where ctx.Command() gives "manifest create <directory>"
+func commandName(ctx *kong.Context) string {
+	var names []string
+	for _, trace := range ctx.Path {
+		if trace.Command != nil {
+			names = append(names, trace.Command.Name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// machineReadableOutput reports whether the selected command writes JSON results to stdout
+func machineReadableOutput(ctx *kong.Context) bool {
+	selected := ctx.Selected()
+	if selected == nil || !selected.Target.CanAddr() {
+		return false
+	}
+
+	target, ok := selected.Target.Addr().Interface().(interface{ MachineReadable() bool })
+	return ok && target.MachineReadable()
+}
+
 func main() {
 	var cli CLI
 	appCtx := &types.AppContext{
 		Version: Version,
 	}
-	ctx := kong.Parse(&cli, kong.Bind(appCtx))
+
+	// Load config files as flag defaults; command-line flags still take precedence.
+	// The library file is the one nearest to the paths the command works on.
+	cfg, err := config.Load(config.CommandPaths(&CLI{}, os.Args[1:])...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "videotagger: %v\n", err)
+		os.Exit(cmd.ExitEnvironment)
+	}
+
+	ctx := kong.Parse(&cli,
+		kong.Bind(appCtx),
+		kong.Bind(cfg),
+		kong.Resolvers(cfg.Resolver()),
+	)
+	config.ApplyDirectoryDefaults(ctx)
+	ctx.FatalIfErrorf(cmd.EnvironmentError(cfg.ValidateProfile(cli.Profile)))
+
+	closeLog, err := logging.Setup(logging.Options{
+		Format: cli.LogFormat,
+		File:   cli.LogFile,
+		Level:  cli.LogLevel,
+		Stderr: machineReadableOutput(ctx),
+	})
+	ctx.FatalIfErrorf(cmd.EnvironmentError(err))
 
 	// Validate FFmpeg dependencies before running any command
-	// Skip validation for version command as it doesn't require FFmpeg
-	if ctx.Command() != "version" {
+	// Skip validation for commands that don't require FFmpeg
+	if !commandsWithoutFFmpeg[commandName(ctx)] {
 		if err := utils.ValidateFFmpegDependencies(); err != nil {
-			ctx.FatalIfErrorf(err)
+			ctx.FatalIfErrorf(cmd.EnvironmentError(err))
 		}
 	}
 
-	err := ctx.Run()
+	err = ctx.Run()
+	_ = closeLog()
 	ctx.FatalIfErrorf(err)
 }
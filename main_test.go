@@ -83,9 +83,9 @@ func TestDuplicatesCmd_DefaultDirectory(t *testing.T) {
 	// Test DuplicatesCmd default directory
 	cmd := &cmd.DuplicatesCmd{}
 
-	// Default directory should be "." (current directory)
-	if cmd.Directory != "" {
-		t.Errorf("Expected default Directory to be empty string (will default to current dir), got %q", cmd.Directory)
+	// Default directories should be empty (Kong defaults them to the current dir)
+	if len(cmd.Directories) != 0 {
+		t.Errorf("Expected default Directories to be empty (will default to current dir), got %q", cmd.Directories)
 	}
 }
 
@@ -783,3 +783,35 @@ func TestCLI_Integration(t *testing.T) {
 		t.Errorf("Expected file %q, got %q", testFile, cli.Tag.Files[0])
 	}
 }
+
+func TestCommandName(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		args       []string
+		want       string
+		skipFFmpeg bool
+	}{
+		{[]string{"version"}, "version", true},
+		{[]string{"config", "show"}, "config show", true},
+		{[]string{"config", "show", dir}, "config show", true},
+		{[]string{"manifest", "create"}, "manifest create", true},
+		{[]string{"manifest", "create", dir}, "manifest create", true},
+		{[]string{"parity", "repair", dir}, "parity repair", true},
+		{[]string{"tag", dir}, "tag", false},
+	}
+
+	for _, tt := range tests {
+		var cli CLI
+		parser := kong.Must(&cli)
+		ctx, err := parser.Parse(tt.args)
+		if err != nil {
+			t.Fatalf("Failed to parse args %v: %v", tt.args, err)
+		}
+		if got := commandName(ctx); got != tt.want {
+			t.Errorf("commandName(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+		if commandsWithoutFFmpeg[commandName(ctx)] != tt.skipFFmpeg {
+			t.Errorf("Expected %v to need FFmpeg: %v", tt.args, !tt.skipFFmpeg)
+		}
+	}
+}
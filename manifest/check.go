@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Status is the outcome of checking one file against a manifest.
+type Status string
+
+// Check outcomes
+const (
+	StatusOK          Status = "ok"          // Contents match the manifest
+	StatusMismatch    Status = "mismatch"    // Contents differ from the manifest
+	StatusMissing     Status = "missing"     // Listed in the manifest but not on disk
+	StatusExtra       Status = "extra"       // On disk but not listed in the manifest
+	StatusFailed      Status = "failed"      // The file couldn't be read
+	StatusInterrupted Status = "interrupted" // The run stopped before the file was checked
+)
+
+// CheckResult contains the outcome of checking one file.
+type CheckResult struct {
+	Path         string    `json:"path"`
+	Manifest     string    `json:"manifest"`
+	Algorithm    Algorithm `json:"algorithm"`
+	Status       Status    `json:"status"`
+	ExpectedHash string    `json:"expectedHash,omitempty"`
+	ActualHash   string    `json:"actualHash,omitempty"`
+	Error        error     `json:"-"`
+}
+
+// Check hashes the file for an entry and compares it with the recorded hash.
+func (m *Manifest) Check(e Entry) *CheckResult {
+	result := &CheckResult{
+		Path:         m.EntryPath(e),
+		Manifest:     m.Path,
+		Algorithm:    m.Algorithm,
+		ExpectedHash: e.Hash,
+	}
+
+	actual, err := HashFile(result.Path, m.Algorithm)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		result.Status = StatusMissing
+		result.Error = err
+	case err != nil:
+		result.Status = StatusFailed
+		result.Error = err
+	case actual == e.Hash:
+		result.Status = StatusOK
+		result.ActualHash = actual
+	default:
+		result.Status = StatusMismatch
+		result.ActualHash = actual
+	}
+
+	return result
+}
+
+// ExtraResult describes a file found next to the manifest that it doesn't list.
+func (m *Manifest) ExtraResult(path string) *CheckResult {
+	return &CheckResult{
+		Path:      path,
+		Manifest:  m.Path,
+		Algorithm: m.Algorithm,
+		Status:    StatusExtra,
+	}
+}
@@ -0,0 +1,305 @@
+package manifest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zeebo/blake3"
+)
+
+// Algorithm is a checksum algorithm with a standard command-line tool that reads its manifests.
+type Algorithm string
+
+// Supported algorithms
+const (
+	SHA256 Algorithm = "sha256" // sha256sum from GNU coreutils
+	BLAKE3 Algorithm = "blake3" // b3sum
+)
+
+// FileName returns the conventional manifest filename for the algorithm.
+func (a Algorithm) FileName() string {
+	switch a {
+	case BLAKE3:
+		return "B3SUMS"
+	default:
+		return "SHA256SUMS"
+	}
+}
+
+// Tool returns the name of the standard tool that checks manifests of this algorithm.
+func (a Algorithm) Tool() string {
+	switch a {
+	case BLAKE3:
+		return "b3sum"
+	default:
+		return "sha256sum"
+	}
+}
+
+// New returns a new hash for the algorithm.
+func (a Algorithm) New() hash.Hash {
+	switch a {
+	case BLAKE3:
+		return blake3.New()
+	default:
+		return sha256.New()
+	}
+}
+
+// ParseAlgorithm converts an algorithm name into an Algorithm.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "sha256":
+		return SHA256, nil
+	case "blake3", "b3":
+		return BLAKE3, nil
+	default:
+		return "", fmt.Errorf("unknown checksum algorithm %q", name)
+	}
+}
+
+// AlgorithmForFile detects a manifest's algorithm from its filename, accepting the
+// conventional names (SHA256SUMS, B3SUMS) and the .sha256 and .b3 extensions.
+func AlgorithmForFile(path string) (Algorithm, bool) {
+	name := strings.ToLower(filepath.Base(path))
+
+	switch {
+	case name == "sha256sums" || strings.HasSuffix(name, ".sha256"):
+		return SHA256, true
+	case name == "b3sums" || strings.HasSuffix(name, ".b3"):
+		return BLAKE3, true
+	default:
+		return "", false
+	}
+}
+
+// IsManifestFile reports whether path is named like a checksum manifest.
+func IsManifestFile(path string) bool {
+	_, ok := AlgorithmForFile(path)
+	return ok
+}
+
+// Entry is one checksummed file in a manifest.
+type Entry struct {
+	Name string // Path relative to the directory containing the manifest, with forward slashes
+	Hash string // Lowercase hex digest
+}
+
+// Manifest is a parsed checksum manifest.
+type Manifest struct {
+	Path      string // Location of the manifest, empty when parsed from a reader
+	Algorithm Algorithm
+	Entries   []Entry
+}
+
+// Dir returns the directory entry names are relative to.
+func (m *Manifest) Dir() string {
+	return filepath.Dir(m.Path)
+}
+
+// EntryPath returns the full path of an entry.
+func (m *Manifest) EntryPath(e Entry) string {
+	return filepath.Join(m.Dir(), filepath.FromSlash(e.Name))
+}
+
+// Extra returns the paths that the manifest doesn't list, in the order given.
+func (m *Manifest) Extra(paths []string) []string {
+	listed := make(map[string]bool, len(m.Entries))
+	for _, e := range m.Entries {
+		listed[filepath.Clean(m.EntryPath(e))] = true
+	}
+
+	var extra []string
+	for _, path := range paths {
+		if !listed[filepath.Clean(path)] {
+			extra = append(extra, path)
+		}
+	}
+	return extra
+}
+
+// lineRegex matches "<hash>  <name>" (text mode) and "<hash> *<name>" (binary mode)
+var lineRegex = regexp.MustCompile(`^([0-9A-Fa-f]+) [ *](.+)$`)
+
+// Read parses the manifest at path, detecting the algorithm from its filename.
+func Read(path string) (*Manifest, error) {
+	algorithm, ok := AlgorithmForFile(path)
+	if !ok {
+		return nil, fmt.Errorf("%s: unrecognized manifest name, expected SHA256SUMS, B3SUMS, *.sha256 or *.b3", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	m, err := Parse(f, algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.Path = path
+
+	return m, nil
+}
+
+// Parse reads manifest content in the format written by sha256sum and b3sum.
+// Lines starting with a backslash have escaped names, as those tools write for
+// names containing backslashes or newlines.
+func Parse(r io.Reader, algorithm Algorithm) (*Manifest, error) {
+	m := &Manifest{Algorithm: algorithm}
+	hexLen := hex.EncodedLen(algorithm.New().Size())
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		escaped := strings.HasPrefix(line, `\`)
+		if escaped {
+			line = line[1:]
+		}
+
+		match := lineRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: expected \"<hash>  <name>\", got %q", lineNumber, line)
+		}
+		if len(match[1]) != hexLen {
+			return nil, fmt.Errorf("line %d: %s hash must be %d hex digits, got %d", lineNumber, algorithm, hexLen, len(match[1]))
+		}
+
+		name := match[2]
+		if escaped {
+			var err error
+			if name, err = unescapeName(name); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
+
+		m.Entries = append(m.Entries, Entry{
+			Name: filepath.ToSlash(name),
+			Hash: strings.ToLower(match[1]),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Write writes entries in text mode ("<hash>  <name>"), escaping names the way sha256sum does.
+func Write(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+
+	for _, e := range entries {
+		name, escaped := escapeName(e.Name)
+		prefix := ""
+		if escaped {
+			prefix = `\`
+		}
+		fmt.Fprintf(bw, "%s%s  %s\n", prefix, e.Hash, name)
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile writes entries to a manifest at path.
+func WriteFile(path string, entries []Entry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, entries); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// escapeName escapes backslashes, newlines and carriage returns, reporting whether anything changed
+func escapeName(name string) (string, bool) {
+	if !strings.ContainsAny(name, "\\\n\r") {
+		return name, false
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	return r.Replace(name), true
+}
+
+// unescapeName reverses escapeName
+func unescapeName(name string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			b.WriteByte(name[i])
+			continue
+		}
+
+		i++
+		if i == len(name) {
+			return "", fmt.Errorf("trailing backslash in escaped name %q", name)
+		}
+		switch name[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", fmt.Errorf("invalid escape \\%c in name %q", name[i], name)
+		}
+	}
+	return b.String(), nil
+}
+
+// HashFile returns the lowercase hex digest of the file at path.
+func HashFile(path string, algorithm Algorithm) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := algorithm.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FindManifests returns every manifest file under directory, in walk order.
+func FindManifests(directory string) ([]string, error) {
+	var manifests []string
+
+	err := filepath.WalkDir(directory, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && IsManifestFile(path) {
+			manifests = append(manifests, path)
+		}
+
+		return nil
+	})
+
+	return manifests, err
+}
@@ -0,0 +1,189 @@
+package manifest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	helloSHA256 = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	helloBLAKE3 = "d74981efa70a0c880b8d8c1985d075dbcbf679b99a5f9914e5aaf96b831a9e24"
+)
+
+func TestAlgorithmForFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Algorithm
+		ok       bool
+	}{
+		{"SHA256SUMS", SHA256, true},
+		{"/backup/sha256sums", SHA256, true},
+		{"movies.sha256", SHA256, true},
+		{"B3SUMS", BLAKE3, true},
+		{"movies.b3", BLAKE3, true},
+		{"movies.sfv", "", false},
+		{"MD5SUMS", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, ok := AlgorithmForFile(tt.name)
+			if algorithm != tt.expected || ok != tt.ok {
+				t.Errorf("AlgorithmForFile(%q) = %q, %v; expected %q, %v", tt.name, algorithm, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	content := helloSHA256 + "  My Movie (2020).mkv\r\n" +
+		strings.ToUpper(helloSHA256) + " *binary.mp4\n" +
+		"\n" +
+		`\` + helloSHA256 + `  back\\slash\nnewline.mp4` + "\n"
+
+	m, err := Parse(strings.NewReader(content), SHA256)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	expected := []Entry{
+		{Name: "My Movie (2020).mkv", Hash: helloSHA256},
+		{Name: "binary.mp4", Hash: helloSHA256},
+		{Name: "back\\slash\nnewline.mp4", Hash: helloSHA256},
+	}
+	if len(m.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(m.Entries))
+	}
+	for i, entry := range m.Entries {
+		if entry != expected[i] {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, expected[i], entry)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Missing name", helloSHA256 + "\n"},
+		{"Single space", helloSHA256 + " video.mp4\n"},
+		{"Wrong hash length", "abcd1234  video.mp4\n"},
+		{"Invalid escape", `\` + helloSHA256 + `  bad\x.mp4` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.content), SHA256); err == nil {
+				t.Error("Expected parse error")
+			}
+		})
+	}
+}
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Name: "a_[1920x1080][45min][0000ABCD].mp4", Hash: helloBLAKE3},
+		{Name: "odd\\name.mkv", Hash: helloBLAKE3},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, entries); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	expectedOutput := helloBLAKE3 + "  a_[1920x1080][45min][0000ABCD].mp4\n" +
+		`\` + helloBLAKE3 + `  odd\\name.mkv` + "\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	m, err := Parse(&buf, BLAKE3)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for i, entry := range m.Entries {
+		if entry != entries[i] {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, entries[i], entry)
+		}
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.mp4")
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	for algorithm, expected := range map[Algorithm]string{SHA256: helloSHA256, BLAKE3: helloBLAKE3} {
+		hash, err := HashFile(path, algorithm)
+		if err != nil {
+			t.Fatalf("HashFile(%s) error = %v", algorithm, err)
+		}
+		if hash != expected {
+			t.Errorf("HashFile(%s) = %s, expected %s", algorithm, hash, expected)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"good.mp4": "hello world", "bad.mp4": "changed", "new.mp4": "x"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	entries := []Entry{
+		{Name: "good.mp4", Hash: helloSHA256},
+		{Name: "bad.mp4", Hash: helloSHA256},
+		{Name: "gone.mp4", Hash: helloSHA256},
+	}
+	manifestPath := filepath.Join(dir, SHA256.FileName())
+	if err := WriteFile(manifestPath, entries); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	m, err := Read(manifestPath)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	expected := []Status{StatusOK, StatusMismatch, StatusMissing}
+	for i, entry := range m.Entries {
+		result := m.Check(entry)
+		if result.Status != expected[i] {
+			t.Errorf("Check(%s) status = %s, expected %s (error: %v)", entry.Name, result.Status, expected[i], result.Error)
+		}
+	}
+
+	onDisk := []string{filepath.Join(dir, "bad.mp4"), filepath.Join(dir, "good.mp4"), filepath.Join(dir, "new.mp4")}
+	extra := m.Extra(onDisk)
+	if len(extra) != 1 || filepath.Base(extra[0]) != "new.mp4" {
+		t.Errorf("Expected new.mp4 as the only extra file, got %v", extra)
+	}
+}
+
+func TestFindManifests(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "Season [1]")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, path := range []string{filepath.Join(dir, "SHA256SUMS"), filepath.Join(sub, "B3SUMS"), filepath.Join(sub, "video.mp4")} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	manifests, err := FindManifests(dir)
+	if err != nil {
+		t.Fatalf("FindManifests() error = %v", err)
+	}
+	if len(manifests) != 2 {
+		t.Errorf("Expected 2 manifests, got %v", manifests)
+	}
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/lepinkainen/videotagger/logging"
+)
+
+// SchemaVersion identifies the record layout. It changes only when fields are
+// removed or change meaning; new fields may be added within a version.
+const SchemaVersion = "videotagger/v1"
+
+// Supported result formats
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Record types
+const (
+	TypeFile    = "file"    // One processed file (tag, verify, reencode)
+	TypeGroup   = "group"   // One group of duplicate files
+	TypePair    = "pair"    // One pair of similar files
+	TypeStale   = "stale"   // One file overdue for verification (verify --scrub)
+	TypeSummary = "summary" // Final totals, always the last record
+)
+
+// Record is the envelope written for every result
+type Record struct {
+	Schema     string `json:"schema"`
+	Command    string `json:"command"`
+	Type       string `json:"type"`
+	Data       any    `json:"data"`
+	Error      string `json:"error,omitempty"`
+	ErrorClass string `json:"errorClass,omitempty"`
+}
+
+// Flags adds the --output flag to commands that produce per-item results.
+// Embed it in a command struct with `embed:""`.
+type Flags struct {
+	Output string `help:"Result format: text for humans, json or ndjson for scripts" default:"text" enum:"text,json,ndjson"`
+}
+
+// MachineReadable reports whether results are written as JSON to stdout
+func (f Flags) MachineReadable() bool {
+	return f.Output == FormatJSON || f.Output == FormatNDJSON
+}
+
+// Writer emits result records. In text mode it discards everything, since
+// human-readable output is produced by the logging events instead.
+// It is safe for concurrent use by multiple workers.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	format  string
+	command string
+	records []Record
+	closed  bool
+}
+
+// New creates a writer for the given command's results
+func New(w io.Writer, format, command string) *Writer {
+	return &Writer{
+		w:       w,
+		format:  format,
+		command: command,
+		records: make([]Record, 0),
+	}
+}
+
+// Enabled reports whether records are being written
+func (w *Writer) Enabled() bool {
+	return w.format == FormatJSON || w.format == FormatNDJSON
+}
+
+// Emit writes one record. NDJSON records are written immediately;
+// JSON records are buffered and written as a single array by Close.
+func (w *Writer) Emit(recordType string, data any, err error) error {
+	if !w.Enabled() {
+		return nil
+	}
+
+	record := Record{
+		Schema:  SchemaVersion,
+		Command: w.command,
+		Type:    recordType,
+		Data:    data,
+	}
+	if err != nil {
+		record.Error = err.Error()
+		record.ErrorClass = logging.ClassifyError(err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.format == FormatJSON {
+		w.records = append(w.records, record)
+		return nil
+	}
+
+	return json.NewEncoder(w.w).Encode(record)
+}
+
+// Summary writes the final summary record and flushes buffered output
+func (w *Writer) Summary(data any) error {
+	if err := w.Emit(TypeSummary, data, nil); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Close writes buffered JSON records once. It is a no-op for other formats.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.format != FormatJSON || w.closed {
+		return nil
+	}
+	w.closed = true
+
+	encoder := json.NewEncoder(w.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(w.records)
+}
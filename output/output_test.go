@@ -0,0 +1,130 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+type testData struct {
+	Path string `json:"path"`
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, FormatNDJSON, "verify")
+
+	if err := w.Emit(TypeFile, testData{Path: "a.mp4"}, nil); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := w.Emit(TypeFile, testData{Path: "b.mp4"}, fmt.Errorf("open: %w", fs.ErrNotExist)); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := w.Summary(map[string]int{"files": 2}); err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Line is not valid JSON: %v\n%s", err, scanner.Text())
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	for _, record := range records {
+		if record.Schema != SchemaVersion {
+			t.Errorf("Expected schema %s, got %s", SchemaVersion, record.Schema)
+		}
+		if record.Command != "verify" {
+			t.Errorf("Expected command verify, got %s", record.Command)
+		}
+	}
+
+	if records[0].Error != "" {
+		t.Errorf("Expected no error on first record, got %q", records[0].Error)
+	}
+	if records[1].ErrorClass != "not_found" {
+		t.Errorf("Expected error class not_found, got %q", records[1].ErrorClass)
+	}
+	if records[2].Type != TypeSummary {
+		t.Errorf("Expected last record to be the summary, got %s", records[2].Type)
+	}
+}
+
+func TestWriter_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, FormatJSON, "duplicates")
+
+	if err := w.Emit(TypeGroup, testData{Path: "a.mp4"}, nil); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("JSON records should be buffered until Close")
+	}
+
+	if err := w.Summary(map[string]int{"groups": 1}); err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	// A second close must not write the array again
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var records []Record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Output is not a JSON array: %v\n%s", err, buf.String())
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Type != TypeGroup || records[1].Type != TypeSummary {
+		t.Errorf("Unexpected record types: %s, %s", records[0].Type, records[1].Type)
+	}
+}
+
+func TestWriter_Text(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(&buf, FormatText, "tag")
+
+	if w.Enabled() {
+		t.Error("Text format should not emit records")
+	}
+	if err := w.Emit(TypeFile, testData{Path: "a.mp4"}, nil); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if err := w.Summary(map[string]int{"files": 1}); err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output in text mode, got %q", buf.String())
+	}
+}
+
+func TestFlags_MachineReadable(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected bool
+	}{
+		{FormatText, false},
+		{FormatJSON, true},
+		{FormatNDJSON, true},
+	}
+
+	for _, tt := range tests {
+		if got := (Flags{Output: tt.format}).MachineReadable(); got != tt.expected {
+			t.Errorf("MachineReadable() for %s = %v, expected %v", tt.format, got, tt.expected)
+		}
+	}
+}
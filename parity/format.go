@@ -0,0 +1,269 @@
+package parity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+)
+
+// Parity files hold a header, the parity blocks, and a second copy of the header so the
+// layout survives damage to either end:
+//
+//	header | parity blocks | header | header length (uint32) | magic
+//
+// All integers are little endian. The header ends with a CRC32 of its other bytes.
+var magic = []byte("VTPARITY")
+
+// formatVersion is written into every header; readers reject other versions
+const formatVersion = 1
+
+// trailerLen is the size of the header length and magic after the header copy
+var trailerLen = 4 + len(magic)
+
+// ErrInvalidHeader means neither copy of a parity file's header could be read
+var ErrInvalidHeader = errors.New("invalid parity header")
+
+// Header describes a protected file and how its blocks map onto Reed-Solomon stripes.
+//
+// The file is split into BlockSize blocks, the last one zero-padded. Each stripe has
+// DataShards data blocks and ParityShards parity blocks. Consecutive blocks are dealt
+// out to Interleave stripes in turn, so damage to a contiguous region is spread over
+// several stripes instead of exhausting the parity of one.
+type Header struct {
+	Name         string   // Base name of the protected file when the parity was created
+	Size         int64    // Size of the protected file
+	CRC32        uint32   // CRC32 of the whole protected file
+	BlockSize    int      // Bytes per block
+	DataShards   int      // Data blocks per stripe
+	ParityShards int      // Parity blocks per stripe
+	Interleave   int      // Stripes that consecutive blocks are dealt out to
+	Stripes      int      // Total number of stripes
+	DataCRCs     []uint32 // CRC32 of each (padded) data block
+	ParityCRCs   []uint32 // CRC32 of each parity block, stripe by stripe
+}
+
+// newHeader lays out a file of size bytes
+func newHeader(name string, size int64, blockSize int, redundancy float64) *Header {
+	blocks := int((size + int64(blockSize) - 1) / int64(blockSize))
+	dataShards := min(maxDataShards, blocks)
+	stripes := (blocks + dataShards - 1) / dataShards
+	parityShards := int(math.Ceil(float64(dataShards) * redundancy))
+	parityShards = min(max(1, parityShards), maxShards-dataShards)
+
+	return &Header{
+		Name:         name,
+		Size:         size,
+		BlockSize:    blockSize,
+		DataShards:   dataShards,
+		ParityShards: parityShards,
+		Interleave:   min(maxInterleave, stripes),
+		Stripes:      stripes,
+		DataCRCs:     make([]uint32, blocks),
+		ParityCRCs:   make([]uint32, stripes*parityShards),
+	}
+}
+
+// Blocks returns the number of data blocks in the protected file
+func (h *Header) Blocks() int {
+	return len(h.DataCRCs)
+}
+
+// ParitySize returns the number of bytes of parity blocks
+func (h *Header) ParitySize() int64 {
+	return int64(len(h.ParityCRCs)) * int64(h.BlockSize)
+}
+
+// groupStripes returns the number of stripes in the interleave group starting at stripe
+func (h *Header) groupStripes(firstStripe int) int {
+	return min(h.Interleave, h.Stripes-firstStripe)
+}
+
+// locate returns the stripe and shard holding data block
+func (h *Header) locate(block int) (stripe, shard int) {
+	groupBlocks := h.Interleave * h.DataShards
+	first := block / groupBlocks * h.Interleave
+	within := block % groupBlocks
+	n := h.groupStripes(first)
+	return first + within%n, within / n
+}
+
+// block returns the data block at shard of stripe, which may lie past the end of the file
+// when the last stripes are only partly filled
+func (h *Header) block(stripe, shard int) int {
+	first := stripe / h.Interleave * h.Interleave
+	return first*h.DataShards + shard*h.groupStripes(first) + stripe - first
+}
+
+// encode serializes the header, ending with its CRC32
+func (h *Header) encode() []byte {
+	var buf bytes.Buffer
+	buf.Write(magic)
+	w := func(v any) { _ = binary.Write(&buf, binary.LittleEndian, v) }
+	w(uint16(formatVersion))
+	w(uint16(len(h.Name)))
+	buf.WriteString(h.Name)
+	w(uint64(h.Size))
+	w(h.CRC32)
+	w(uint32(h.BlockSize))
+	w(uint16(h.DataShards))
+	w(uint16(h.ParityShards))
+	w(uint16(h.Interleave))
+	w(uint32(h.Stripes))
+	w(uint32(len(h.DataCRCs)))
+	w(h.DataCRCs)
+	w(h.ParityCRCs)
+	w(crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// decodeHeader parses an encoded header, checking its CRC32 and layout
+func decodeHeader(b []byte) (*Header, error) {
+	if len(b) < len(magic)+4 || !bytes.Equal(b[:len(magic)], magic) {
+		return nil, fmt.Errorf("%w: not a parity file", ErrInvalidHeader)
+	}
+	body, sum := b[:len(b)-4], binary.LittleEndian.Uint32(b[len(b)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidHeader)
+	}
+
+	r := bytes.NewReader(body[len(magic):])
+	var fixed struct {
+		Version uint16
+		NameLen uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &fixed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if fixed.Version != formatVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHeader, fixed.Version)
+	}
+
+	name := make([]byte, fixed.NameLen)
+	var layout struct {
+		Size         uint64
+		CRC32        uint32
+		BlockSize    uint32
+		DataShards   uint16
+		ParityShards uint16
+		Interleave   uint16
+		Stripes      uint32
+		Blocks       uint32
+	}
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &layout); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	h := &Header{
+		Name:         string(name),
+		Size:         int64(layout.Size),
+		CRC32:        layout.CRC32,
+		BlockSize:    int(layout.BlockSize),
+		DataShards:   int(layout.DataShards),
+		ParityShards: int(layout.ParityShards),
+		Interleave:   int(layout.Interleave),
+		Stripes:      int(layout.Stripes),
+	}
+	if h.BlockSize == 0 || h.DataShards == 0 || h.ParityShards == 0 || h.Interleave == 0 ||
+		h.DataShards+h.ParityShards > maxShards ||
+		int64(layout.Blocks) != (h.Size+int64(h.BlockSize)-1)/int64(h.BlockSize) ||
+		int(layout.Blocks) > h.Stripes*h.DataShards {
+		return nil, fmt.Errorf("%w: inconsistent layout", ErrInvalidHeader)
+	}
+
+	h.DataCRCs = make([]uint32, layout.Blocks)
+	h.ParityCRCs = make([]uint32, h.Stripes*h.ParityShards)
+	if err := binary.Read(r, binary.LittleEndian, h.DataCRCs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, h.ParityCRCs); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidHeader)
+	}
+
+	return h, nil
+}
+
+// layoutLen is the size of the fixed fields after the name
+const layoutLen = 8 + 4 + 4 + 2 + 2 + 2 + 4 + 4
+
+// encodedLen returns the length of the header that starts at offset 0 of f, worked out
+// from its fields, which are only trusted once the header's CRC32 has been checked
+func encodedLen(f *os.File) (int64, error) {
+	prefix := make([]byte, len(magic)+4)
+	if _, err := f.ReadAt(prefix, 0); err != nil {
+		return 0, err
+	}
+	nameLen := int64(binary.LittleEndian.Uint16(prefix[len(magic)+2:]))
+
+	layout := make([]byte, layoutLen)
+	if _, err := f.ReadAt(layout, int64(len(prefix))+nameLen); err != nil {
+		return 0, err
+	}
+	parityShards := int64(binary.LittleEndian.Uint16(layout[18:]))
+	stripes := int64(binary.LittleEndian.Uint32(layout[22:]))
+	blocks := int64(binary.LittleEndian.Uint32(layout[26:]))
+
+	return int64(len(prefix)) + nameLen + layoutLen + 4*(blocks+stripes*parityShards) + 4, nil
+}
+
+// readHeaderAt reads and decodes a header copy of length n at offset
+func readHeaderAt(f *os.File, offset, n, fileSize int64) (*Header, error) {
+	if offset < 0 || n <= 0 || offset+n > fileSize {
+		return nil, fmt.Errorf("%w: header out of bounds", ErrInvalidHeader)
+	}
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	return decodeHeader(buf)
+}
+
+// Read reads the header of a parity file, falling back to the copy at its end when the
+// first is damaged. It also returns the offset of the first parity block.
+func Read(path string) (*Header, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := fi.Size()
+
+	var firstErr error
+	headerLen, err := encodedLen(f)
+	if err == nil {
+		var h *Header
+		if h, err = readHeaderAt(f, 0, headerLen, size); err == nil {
+			return h, headerLen, nil
+		}
+	}
+	firstErr = err
+
+	// Both copies have the same length, which the trailer records
+	trailer := make([]byte, trailerLen)
+	if _, err := f.ReadAt(trailer, size-int64(trailerLen)); err == nil && bytes.Equal(trailer[4:], magic) {
+		headerLen = int64(binary.LittleEndian.Uint32(trailer))
+		if h, err := readHeaderAt(f, size-int64(trailerLen)-headerLen, headerLen, size); err == nil {
+			return h, headerLen, nil
+		}
+	}
+
+	if !errors.Is(firstErr, ErrInvalidHeader) {
+		firstErr = fmt.Errorf("%w: %v", ErrInvalidHeader, firstErr)
+	}
+	return nil, 0, fmt.Errorf("%s: %w", path, firstErr)
+}
@@ -0,0 +1,407 @@
+// Package parity creates Reed-Solomon recovery data for archived files and uses it to
+// rebuild blocks damaged by bit rot.
+package parity
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// Extension is appended to a file's name to name its parity file
+const Extension = ".vtpar"
+
+// Defaults for Create
+const (
+	DefaultRedundancy = 0.05     // Parity size as a fraction of the file size
+	DefaultBlockSize  = 64 << 10 // Bytes per block
+)
+
+const (
+	maxShards     = 256 // Data plus parity shards per stripe allowed by 8-bit Reed-Solomon
+	maxDataShards = 128 // Data shards per stripe, leaving room for 100% redundancy
+	maxInterleave = 16  // Stripes a contiguous run of blocks is spread over
+)
+
+// Options configures Create
+type Options struct {
+	Redundancy float64 // Fraction of each stripe's data blocks that can be rebuilt, 0 < r <= 1
+	BlockSize  int     // Bytes per block; DefaultBlockSize when 0
+	Replace    bool    // Replace an existing parity file instead of failing
+	ExpectCRC  string  // CRC32 the contents must have, as a filename tag writes it; unchecked when empty
+}
+
+// ErrCRCMismatch is returned by Create when the contents don't have the expected CRC32
+var ErrCRCMismatch = errors.New("contents don't match the expected CRC32")
+
+// Path returns the parity file for a file
+func Path(path string) string {
+	return path + Extension
+}
+
+// IsParityFile reports whether path is named like a parity file
+func IsParityFile(path string) bool {
+	return strings.HasSuffix(path, Extension)
+}
+
+// ProtectedPath returns the file a parity file protects
+func ProtectedPath(parityPath string) string {
+	return strings.TrimSuffix(parityPath, Extension)
+}
+
+// Create writes a parity file for path. It is written under a temporary name and renamed
+// into place once complete and, if ExpectCRC is set, once the contents are known to match it.
+// An existing parity file is only replaced with Replace, and is left untouched on any failure.
+func Create(path string, opts Options) (*Header, error) {
+	if opts.Redundancy <= 0 || opts.Redundancy > 1 {
+		return nil, fmt.Errorf("redundancy must be greater than 0 and at most 1, got %g", opts.Redundancy)
+	}
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = src.Close() }()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	dst := Path(path)
+	if _, err := os.Stat(dst); err == nil && !opts.Replace {
+		return nil, fmt.Errorf("%s: %w", dst, fs.ErrExist)
+	}
+
+	h := newHeader(filepath.Base(path), fi.Size(), blockSize, opts.Redundancy)
+	enc, err := reedsolomon.New(h.DataShards, h.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.OpenFile(dst+".tmp", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	// The header is rewritten with the checksums once they are known; its length doesn't change
+	headerLen := int64(len(h.encode()))
+	if _, err := tmp.Write(make([]byte, headerLen)); err != nil {
+		return nil, err
+	}
+
+	if err := writeParity(src, tmp, h, enc); err != nil {
+		return nil, err
+	}
+	if opts.ExpectCRC != "" && !strings.EqualFold(opts.ExpectCRC, formatCRC(h.CRC32)) {
+		return nil, fmt.Errorf("%w: want %s, got %s", ErrCRCMismatch, strings.ToUpper(opts.ExpectCRC), formatCRC(h.CRC32))
+	}
+
+	header := h.encode()
+	if _, err := tmp.WriteAt(header, 0); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, 0, len(header)+trailerLen)
+	trailer = append(trailer, header...)
+	trailer = binary.LittleEndian.AppendUint32(trailer, uint32(len(header)))
+	trailer = append(trailer, magic...)
+	if _, err := tmp.WriteAt(trailer, headerLen+h.ParitySize()); err != nil {
+		return nil, err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	committed = true
+	if _, err := os.Stat(dst); err == nil && !opts.Replace {
+		_ = os.Remove(tmp.Name())
+		return nil, fmt.Errorf("%s: %w", dst, fs.ErrExist)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// writeParity reads src one interleave group at a time, recording block checksums and the
+// file's CRC32 in h and writing the group's parity blocks to dst after the header
+func writeParity(src io.Reader, dst *os.File, h *Header, enc reedsolomon.Encoder) error {
+	fileHash := crc32.NewIEEE()
+	buf := make([]byte, h.BlockSize)
+
+	for first := 0; first < h.Stripes; first += h.Interleave {
+		n := h.groupStripes(first)
+
+		parity := make([][][]byte, n)
+		for i := range parity {
+			parity[i] = make([][]byte, h.ParityShards)
+			for j := range parity[i] {
+				parity[i][j] = make([]byte, h.BlockSize)
+			}
+		}
+
+		for shard := 0; shard < h.DataShards; shard++ {
+			for local := 0; local < n; local++ {
+				block := h.block(first+local, shard)
+				if block >= h.Blocks() {
+					continue // Padding past the end of the file adds nothing to the parity
+				}
+
+				read, err := io.ReadFull(src, buf)
+				if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+					return fmt.Errorf("failed to read block %d: %w", block, err)
+				}
+				fileHash.Write(buf[:read])
+				clear(buf[read:])
+
+				h.DataCRCs[block] = crc32.ChecksumIEEE(buf)
+				if err := enc.EncodeIdx(buf, shard, parity[local]); err != nil {
+					return err
+				}
+			}
+		}
+
+		for local := range n {
+			for j, p := range parity[local] {
+				index := (first+local)*h.ParityShards + j
+				h.ParityCRCs[index] = crc32.ChecksumIEEE(p)
+				if _, err := dst.Write(p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	h.CRC32 = fileHash.Sum32()
+	return nil
+}
+
+// RepairResult describes what Repair found and fixed
+type RepairResult struct {
+	DamagedBlocks       int    `json:"damagedBlocks"`
+	RepairedBlocks      int    `json:"repairedBlocks"`
+	UnrecoverableBlocks int    `json:"unrecoverableBlocks"`
+	DamagedParity       int    `json:"damagedParity"` // Parity blocks that were themselves damaged
+	Resized             bool   `json:"resized"`       // The file had the wrong size and was extended or cut
+	CRC32               string `json:"crc32"`         // CRC32 of the file after repair
+	ExpectedCRC32       string `json:"expectedCrc32"` // CRC32 recorded when the parity was created
+}
+
+// OK reports whether the file matches the contents the parity was created from
+func (r *RepairResult) OK() bool {
+	return r.CRC32 == r.ExpectedCRC32
+}
+
+// Repair checks every block of path against its parity file and rewrites damaged blocks
+// in place. Only rebuilt blocks whose checksum matches the one recorded at creation are
+// written, so a repair never makes a file worse.
+func Repair(path, parityPath string) (*RepairResult, error) {
+	h, offset, err := Read(parityPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	pf, err := os.Open(parityPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = pf.Close() }()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RepairResult{ExpectedCRC32: formatCRC(h.CRC32)}
+	damaged, err := findDamagedBlocks(f, h)
+	if err != nil {
+		return nil, err
+	}
+	for _, blocks := range damaged {
+		result.DamagedBlocks += len(blocks)
+	}
+
+	if len(damaged) > 0 {
+		if err := rebuildBlocks(f, pf, offset, h, damaged, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// A file with blocks that couldn't be rebuilt keeps any extra data for manual recovery
+	if fi.Size() != h.Size && result.UnrecoverableBlocks == 0 {
+		if err := f.Truncate(h.Size); err != nil {
+			return nil, err
+		}
+		result.Resized = true
+	}
+
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+
+	// Confirm the result against the checksum of the whole file
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := crc32.NewIEEE()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	result.CRC32 = formatCRC(hash.Sum32())
+
+	return result, nil
+}
+
+// findDamagedBlocks returns the data blocks whose checksums don't match, grouped by stripe
+func findDamagedBlocks(f *os.File, h *Header) (map[int][]int, error) {
+	damaged := make(map[int][]int)
+	buf := make([]byte, h.BlockSize)
+
+	for block := range h.Blocks() {
+		if err := readBlock(f, buf, int64(block)*int64(h.BlockSize)); err != nil {
+			return nil, err
+		}
+		if crc32.ChecksumIEEE(buf) != h.DataCRCs[block] {
+			stripe, _ := h.locate(block)
+			damaged[stripe] = append(damaged[stripe], block)
+		}
+	}
+
+	return damaged, nil
+}
+
+// rebuildBlocks reconstructs the damaged blocks of each affected stripe and writes them back
+func rebuildBlocks(f, pf *os.File, offset int64, h *Header, damaged map[int][]int, result *RepairResult) error {
+	enc, err := reedsolomon.New(h.DataShards, h.ParityShards)
+	if err != nil {
+		return err
+	}
+
+	for stripe, blocks := range damaged {
+		shards := make([][]byte, h.DataShards+h.ParityShards)
+		missing := make(map[int]bool, len(blocks))
+		for _, block := range blocks {
+			missing[block] = true
+		}
+
+		for shard := range h.DataShards {
+			block := h.block(stripe, shard)
+			buf := make([]byte, h.BlockSize)
+			switch {
+			case block >= h.Blocks():
+				// Padding past the end of the file is zeros
+			case missing[block]:
+				continue
+			default:
+				if err := readBlock(f, buf, int64(block)*int64(h.BlockSize)); err != nil {
+					return err
+				}
+			}
+			shards[shard] = buf
+		}
+
+		for j := range h.ParityShards {
+			index := stripe*h.ParityShards + j
+			buf := make([]byte, h.BlockSize)
+			if err := readBlock(pf, buf, offset+int64(index)*int64(h.BlockSize)); err != nil {
+				return err
+			}
+			if crc32.ChecksumIEEE(buf) != h.ParityCRCs[index] {
+				result.DamagedParity++
+				continue
+			}
+			shards[h.DataShards+j] = buf
+		}
+
+		if err := enc.ReconstructData(shards); err != nil {
+			if errors.Is(err, reedsolomon.ErrTooFewShards) {
+				result.UnrecoverableBlocks += len(blocks)
+				continue
+			}
+			return err
+		}
+
+		for _, block := range blocks {
+			_, shard := h.locate(block)
+			data := shards[shard]
+			if crc32.ChecksumIEEE(data) != h.DataCRCs[block] {
+				result.UnrecoverableBlocks++
+				continue
+			}
+
+			start := int64(block) * int64(h.BlockSize)
+			length := min(int64(h.BlockSize), h.Size-start)
+			if _, err := f.WriteAt(data[:length], start); err != nil {
+				return fmt.Errorf("failed to write block %d: %w", block, err)
+			}
+			result.RepairedBlocks++
+		}
+	}
+
+	return nil
+}
+
+// readBlock fills buf from offset, zero-padding past the end of the file
+func readBlock(f *os.File, buf []byte, offset int64) error {
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	clear(buf[n:])
+	return nil
+}
+
+// formatCRC formats a CRC32 the way filename tags do
+func formatCRC(crc uint32) string {
+	return fmt.Sprintf("%08X", crc)
+}
+
+// FindParityFiles returns every parity file under directory, in walk order
+func FindParityFiles(directory string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && IsParityFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,268 @@
+package parity
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRandomFile writes size pseudo-random bytes and returns them
+func writeRandomFile(t *testing.T, path string, size int) []byte {
+	t.Helper()
+	data := make([]byte, size)
+	rng := rand.New(rand.NewPCG(1, uint64(size)))
+	for i := range data {
+		data[i] = byte(rng.UintN(256))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return data
+}
+
+func TestLayout(t *testing.T) {
+	// Sizes giving a single partial block, one partial stripe, and several interleave groups
+	// with a partly filled last group
+	for _, size := range []int64{1, 1000, 128 * 1024, 40*128*1024 + 3} {
+		h := newHeader("f", size, 1024, 0.05)
+		seen := make(map[[2]int]bool)
+
+		for block := range h.Blocks() {
+			stripe, shard := h.locate(block)
+			if stripe >= h.Stripes || shard >= h.DataShards {
+				t.Fatalf("size %d: block %d maps outside the layout: stripe %d shard %d", size, block, stripe, shard)
+			}
+			if seen[[2]int{stripe, shard}] {
+				t.Fatalf("size %d: two blocks map to stripe %d shard %d", size, stripe, shard)
+			}
+			seen[[2]int{stripe, shard}] = true
+
+			if got := h.block(stripe, shard); got != block {
+				t.Fatalf("size %d: block(%d, %d) = %d, expected %d", size, stripe, shard, got, block)
+			}
+		}
+	}
+}
+
+func TestLayout_Interleave(t *testing.T) {
+	h := newHeader("f", 64*128*1024, 1024, 0.05)
+	if h.Interleave != maxInterleave {
+		t.Fatalf("Expected interleave %d, got %d", maxInterleave, h.Interleave)
+	}
+
+	// Consecutive blocks land in different stripes
+	for block := range h.Interleave - 1 {
+		a, _ := h.locate(block)
+		b, _ := h.locate(block + 1)
+		if a == b {
+			t.Errorf("Blocks %d and %d share stripe %d", block, block+1, a)
+		}
+	}
+}
+
+func TestHeaderEncoding(t *testing.T) {
+	h := newHeader("clip_[1920x1080][45min][ABCD1234].mkv", 300000, 4096, 0.1)
+	h.CRC32 = 0xABCD1234
+	for i := range h.DataCRCs {
+		h.DataCRCs[i] = uint32(i * 7)
+	}
+	for i := range h.ParityCRCs {
+		h.ParityCRCs[i] = uint32(i * 13)
+	}
+
+	encoded := h.encode()
+	decoded, err := decodeHeader(encoded)
+	if err != nil {
+		t.Fatalf("decodeHeader() error = %v", err)
+	}
+	if decoded.Name != h.Name || decoded.Size != h.Size || decoded.CRC32 != h.CRC32 ||
+		decoded.ParityShards != h.ParityShards || decoded.DataCRCs[10] != 70 || decoded.ParityCRCs[3] != 39 {
+		t.Errorf("Header changed in round trip: %+v", decoded)
+	}
+
+	encoded[20] ^= 0xFF
+	if _, err := decodeHeader(encoded); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader for a damaged header, got %v", err)
+	}
+}
+
+func createTestParity(t *testing.T, size int, redundancy float64) (string, []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clip.mkv")
+	data := writeRandomFile(t, path, size)
+
+	if _, err := Create(path, Options{Redundancy: redundancy, BlockSize: 1024}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return path, data
+}
+
+func TestCreateAndRepair(t *testing.T) {
+	path, original := createTestParity(t, 3_000_000, 0.05)
+
+	// Damage a contiguous 40 KiB region, wider than one stripe's parity can rebuild
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xEE}, 40*1024), 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	result, err := Repair(path, Path(path))
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if result.DamagedBlocks != 41 || result.RepairedBlocks != 41 || result.UnrecoverableBlocks != 0 {
+		t.Errorf("Expected 41 damaged and repaired blocks, got %+v", result)
+	}
+	if !result.OK() {
+		t.Errorf("Expected CRC %s after repair, got %s", result.ExpectedCRC32, result.CRC32)
+	}
+
+	repaired, _ := os.ReadFile(path)
+	if !bytes.Equal(repaired, original) {
+		t.Error("Repaired file differs from the original")
+	}
+}
+
+func TestRepair_Intact(t *testing.T) {
+	path, _ := createTestParity(t, 100_000, 0.1)
+
+	result, err := Repair(path, Path(path))
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if result.DamagedBlocks != 0 || result.Resized || !result.OK() {
+		t.Errorf("Expected an intact file, got %+v", result)
+	}
+}
+
+func TestRepair_Truncated(t *testing.T) {
+	path, original := createTestParity(t, 500_000, 0.1)
+
+	if err := os.Truncate(path, 480_000); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Repair(path, Path(path))
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if !result.OK() || !result.Resized {
+		t.Errorf("Expected the truncated tail to be rebuilt, got %+v", result)
+	}
+
+	repaired, _ := os.ReadFile(path)
+	if !bytes.Equal(repaired, original) {
+		t.Error("Repaired file differs from the original")
+	}
+}
+
+func TestRepair_TooMuchDamage(t *testing.T) {
+	path, _ := createTestParity(t, 200_000, 0.05)
+
+	if err := os.WriteFile(path, make([]byte, 200_000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Repair(path, Path(path))
+	if err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if result.UnrecoverableBlocks == 0 || result.OK() {
+		t.Errorf("Expected unrecoverable blocks, got %+v", result)
+	}
+}
+
+func TestRead_DamagedHeader(t *testing.T) {
+	path, original := createTestParity(t, 100_000, 0.1)
+
+	// Damage the start of the parity file, including the first header copy
+	pf, err := os.OpenFile(Path(path), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pf.WriteAt(bytes.Repeat([]byte{0}, 64), 0); err != nil {
+		t.Fatal(err)
+	}
+	_ = pf.Close()
+
+	h, _, err := Read(Path(path))
+	if err != nil {
+		t.Fatalf("Expected the header copy at the end to be used, got %v", err)
+	}
+	if h.Size != int64(len(original)) || h.Name != "clip.mkv" {
+		t.Errorf("Unexpected header %+v", h)
+	}
+}
+
+func TestCreate_Existing(t *testing.T) {
+	path, _ := createTestParity(t, 10_000, 0.1)
+
+	if _, err := Create(path, Options{Redundancy: 0.1}); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Expected fs.ErrExist, got %v", err)
+	}
+}
+
+func TestCreate_ReplaceKeepsOldParityOnMismatch(t *testing.T) {
+	path, _ := createTestParity(t, 10_000, 0.1)
+	before, err := os.ReadFile(Path(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _, err := Read(Path(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file rots after its parity was made; replacing the parity must not lose it
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(bytes.Repeat([]byte{0xEE}, 16), 100); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	_, err = Create(path, Options{Redundancy: 0.2, Replace: true, ExpectCRC: formatCRC(h.CRC32)})
+	if !errors.Is(err, ErrCRCMismatch) {
+		t.Fatalf("Expected ErrCRCMismatch, got %v", err)
+	}
+	after, err := os.ReadFile(Path(path))
+	if err != nil || !bytes.Equal(before, after) {
+		t.Fatalf("Expected the old parity file to be kept, got %v", err)
+	}
+	if _, err := os.Stat(Path(path) + ".tmp"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the temporary file to be removed, got %v", err)
+	}
+
+	// With intact contents the parity file is replaced
+	if _, err := Repair(path, Path(path)); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := Create(path, Options{Redundancy: 0.2, Replace: true, ExpectCRC: strings.ToLower(formatCRC(h.CRC32))})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if replaced.BlockSize != DefaultBlockSize {
+		t.Errorf("Expected the new parity file, got %+v", replaced)
+	}
+}
+
+func TestCreate_InvalidRedundancy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mkv")
+	writeRandomFile(t, path, 1000)
+
+	for _, r := range []float64{0, -0.1, 1.5} {
+		if _, err := Create(path, Options{Redundancy: r}); err == nil {
+			t.Errorf("Expected redundancy %g to be rejected", r)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+// RecordSuffix is appended to a quarantined file's name to get its record's name.
+const RecordSuffix = ".quarantine.json"
+
+// ErrNotQuarantined is returned when a path has no quarantine record.
+var ErrNotQuarantined = errors.New("not a quarantined file")
+
+// Record describes why a file was quarantined and where it came from.
+type Record struct {
+	OriginalPath string    `json:"originalPath"`
+	Reason       string    `json:"reason"` // e.g. "mismatch", "truncated" or "corrupt"
+	Detail       string    `json:"detail,omitempty"`
+	ExpectedHash string    `json:"expectedHash,omitempty"`
+	ActualHash   string    `json:"actualHash,omitempty"`
+	Size         int64     `json:"size"`
+	DetectedAt   time.Time `json:"detectedAt"`
+}
+
+// IsRecord reports whether path is named like a quarantine record.
+func IsRecord(path string) bool {
+	return strings.HasSuffix(path, RecordSuffix)
+}
+
+// RecordPath returns the record path for a quarantined file.
+func RecordPath(quarantinedPath string) string {
+	return quarantinedPath + RecordSuffix
+}
+
+// FilePath returns the quarantined file described by a record.
+func FilePath(recordPath string) string {
+	return strings.TrimSuffix(recordPath, RecordSuffix)
+}
+
+// MirrorPath returns where path is kept inside a quarantine root: the root followed by the
+// file's absolute path, so files with the same name from different directories don't collide.
+// A Windows drive letter becomes a directory, e.g. C:\videos\a.mp4 goes to <root>\C\videos\a.mp4.
+func MirrorPath(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	volume := filepath.VolumeName(abs)
+	rest := strings.TrimLeft(abs[len(volume):], `/\`)
+	volume = strings.Trim(volume, `:\/`)
+
+	return filepath.Join(root, strings.ReplaceAll(volume, `\`, "_"), rest), nil
+}
+
+// Move moves a file into the quarantine root and writes its record next to it.
+// OriginalPath, Size and DetectedAt are filled in when empty. Returns the quarantined path.
+func Move(root, path string, record Record) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := MirrorPath(root, path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("%s is already quarantined", dst)
+	}
+
+	if record.OriginalPath == "" {
+		if record.OriginalPath, err = filepath.Abs(path); err != nil {
+			return "", err
+		}
+	}
+	if record.DetectedAt.IsZero() {
+		record.DetectedAt = time.Now()
+	}
+	record.Size = fi.Size()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write the record first so a quarantined file is never left without its origin
+	if err := writeRecord(RecordPath(dst), record); err != nil {
+		return "", fmt.Errorf("failed to write quarantine record: %w", err)
+	}
+	if err := utils.MoveFile(path, dst); err != nil {
+		_ = os.Remove(RecordPath(dst))
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// ReadRecord reads a quarantine record.
+func ReadRecord(recordPath string) (*Record, error) {
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("%s: %w", recordPath, err)
+	}
+	if record.OriginalPath == "" {
+		return nil, fmt.Errorf("%s: record has no original path", recordPath)
+	}
+
+	return &record, nil
+}
+
+// Restore moves a quarantined file back to its original path and removes its record.
+// It refuses to overwrite a file that has since appeared at the original path.
+func Restore(recordPath string) (*Record, error) {
+	record, err := ReadRecord(recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	src := FilePath(recordPath)
+	if _, err := os.Stat(src); err != nil {
+		return record, fmt.Errorf("quarantined file missing: %w", err)
+	}
+	if _, err := os.Lstat(record.OriginalPath); err == nil {
+		return record, fmt.Errorf("cannot restore, %s already exists", record.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(record.OriginalPath), 0o755); err != nil {
+		return record, err
+	}
+	if err := utils.MoveFile(src, record.OriginalPath); err != nil {
+		return record, err
+	}
+
+	return record, os.Remove(recordPath)
+}
+
+// FindRecords returns every quarantine record under root, in walk order.
+func FindRecords(root string) ([]string, error) {
+	var records []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && IsRecord(path) {
+			records = append(records, path)
+		}
+
+		return nil
+	})
+
+	return records, err
+}
+
+// ResolveRecord finds the record for a path given on the command line, which may be the
+// record itself or the quarantined file.
+func ResolveRecord(path string) (string, error) {
+	if IsRecord(path) {
+		return path, nil
+	}
+
+	recordPath := RecordPath(path)
+	if _, err := os.Stat(recordPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%s: %w", path, ErrNotQuarantined)
+		}
+		return "", err
+	}
+	return recordPath, nil
+}
+
+// writeRecord writes a record as indented JSON, failing if one already exists
+func writeRecord(path string, record Record) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,142 @@
+package quarantine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMirrorPath(t *testing.T) {
+	root := t.TempDir()
+	dir := t.TempDir()
+
+	got, err := MirrorPath(root, filepath.Join(dir, "video.mp4"))
+	if err != nil {
+		t.Fatalf("MirrorPath() error = %v", err)
+	}
+
+	abs, _ := filepath.Abs(filepath.Join(dir, "video.mp4"))
+	rel, err := filepath.Rel(root, got)
+	if err != nil || filepath.IsAbs(rel) || rel == ".." {
+		t.Fatalf("Mirror path %q is not inside root %q", got, root)
+	}
+	if filepath.Base(got) != "video.mp4" || !filepath.IsAbs(got) || len(got) <= len(abs) {
+		t.Errorf("Expected the original path to be mirrored below the root, got %q", got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	root := t.TempDir()
+	original := filepath.Join(t.TempDir(), "Show [1080p]", "episode.mkv")
+	writeFile(t, original, "damaged")
+
+	detected := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	dst, err := Move(root, original, Record{Reason: "mismatch", ExpectedHash: "ABCD1234", ActualHash: "0000FFFF", DetectedAt: detected})
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	if _, err := os.Stat(original); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Original file should be gone after quarantine")
+	}
+
+	record, err := ReadRecord(RecordPath(dst))
+	if err != nil {
+		t.Fatalf("ReadRecord() error = %v", err)
+	}
+	if record.OriginalPath != original || record.Size != int64(len("damaged")) || !record.DetectedAt.Equal(detected) {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if record.ExpectedHash != "ABCD1234" || record.ActualHash != "0000FFFF" {
+		t.Errorf("Hashes not recorded: %+v", record)
+	}
+
+	// Both the file and its record resolve to the record
+	for _, path := range []string{dst, RecordPath(dst)} {
+		if got, err := ResolveRecord(path); err != nil || got != RecordPath(dst) {
+			t.Errorf("ResolveRecord(%q) = %q, %v", path, got, err)
+		}
+	}
+
+	records, err := FindRecords(root)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("FindRecords() = %v, %v; expected one record", records, err)
+	}
+
+	if _, err := Restore(records[0]); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	content, err := os.ReadFile(original)
+	if err != nil || string(content) != "damaged" {
+		t.Errorf("Restored file content = %q, %v", content, err)
+	}
+	if _, err := os.Stat(RecordPath(dst)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Record should be removed after restore")
+	}
+}
+
+func TestRestore_OriginalExists(t *testing.T) {
+	root := t.TempDir()
+	original := filepath.Join(t.TempDir(), "episode.mkv")
+	writeFile(t, original, "damaged")
+
+	dst, err := Move(root, original, Record{Reason: "corrupt"})
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	// A replacement was downloaded in the meantime
+	writeFile(t, original, "replacement")
+
+	if _, err := Restore(RecordPath(dst)); err == nil {
+		t.Fatal("Expected restore to refuse overwriting the replacement")
+	}
+
+	content, _ := os.ReadFile(original)
+	if string(content) != "replacement" {
+		t.Error("Replacement was overwritten")
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Errorf("Quarantined file should stay in place: %v", err)
+	}
+}
+
+func TestMove_AlreadyQuarantined(t *testing.T) {
+	root := t.TempDir()
+	original := filepath.Join(t.TempDir(), "episode.mkv")
+	writeFile(t, original, "first")
+
+	if _, err := Move(root, original, Record{Reason: "mismatch"}); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	writeFile(t, original, "second")
+	if _, err := Move(root, original, Record{Reason: "mismatch"}); err == nil {
+		t.Error("Expected error when the quarantine already holds a file for this path")
+	}
+	if _, err := os.Stat(original); err != nil {
+		t.Errorf("File should stay in place when it can't be quarantined: %v", err)
+	}
+}
+
+func TestResolveRecord_NotQuarantined(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	writeFile(t, path, "x")
+
+	if _, err := ResolveRecord(path); !errors.Is(err, ErrNotQuarantined) {
+		t.Errorf("Expected ErrNotQuarantined, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package scrub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Budget limits how much of the archive a scrub run verifies, either by elapsed time or by data read.
+type Budget struct {
+	Duration time.Duration // Stop starting new files after this long; zero means no time limit
+	Bytes    int64         // Only select files up to this total size; zero means no size limit
+}
+
+// sizeUnits maps size suffixes to bytes. Units are binary to match the sizes shown elsewhere,
+// so 500GB and 500GiB are the same budget.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"tib", 1 << 40}, {"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+	{"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+	{"t", 1 << 40}, {"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+// ParseBudget parses a duration such as "2h" or "90m", or a size such as "500GB" or "1.5TB".
+// An empty string is an unlimited budget.
+func ParseBudget(s string) (Budget, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Budget{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return Budget{}, fmt.Errorf("budget must be positive, got %q", s)
+		}
+		return Budget{Duration: d}, nil
+	}
+
+	lower := strings.ToLower(s)
+	for _, unit := range sizeUnits {
+		number, ok := strings.CutSuffix(lower, unit.suffix)
+		if !ok {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			break
+		}
+		if value <= 0 {
+			return Budget{}, fmt.Errorf("budget must be positive, got %q", s)
+		}
+		return Budget{Bytes: int64(value * float64(unit.bytes))}, nil
+	}
+
+	return Budget{}, fmt.Errorf("invalid budget %q, expected a duration (2h) or a size (500GB)", s)
+}
+
+// IsZero reports whether the budget is unlimited.
+func (b Budget) IsZero() bool {
+	return b.Duration == 0 && b.Bytes == 0
+}
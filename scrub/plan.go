@@ -0,0 +1,44 @@
+package scrub
+
+import (
+	"slices"
+	"strings"
+)
+
+// Candidate is a file that may be verified in a scrub run.
+type Candidate struct {
+	Path string
+	Size int64
+}
+
+// Plan orders candidates by their last successful verification, never-verified files first,
+// and returns the ones to verify this run. A size budget keeps the oldest files that fit; the
+// oldest file is always included so a file larger than the budget can't block the scrub forever.
+// A time budget is enforced while verifying, so all candidates are returned in order.
+func Plan(candidates []Candidate, state *State, budget Budget) []Candidate {
+	ordered := slices.Clone(candidates)
+	sortOldestFirst(state, ordered, func(c Candidate) string { return c.Path })
+
+	if budget.Bytes <= 0 {
+		return ordered
+	}
+
+	var total int64
+	for i, c := range ordered {
+		if i > 0 && total+c.Size > budget.Bytes {
+			return ordered[:i]
+		}
+		total += c.Size
+	}
+	return ordered
+}
+
+// sortOldestFirst sorts items by last successful verification, then by path for stable runs
+func sortOldestFirst[T any](s *State, items []T, path func(T) string) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		if c := s.Get(path(a)).LastVerified.Compare(s.Get(path(b)).LastVerified); c != 0 {
+			return c
+		}
+		return strings.Compare(path(a), path(b))
+	})
+}
@@ -0,0 +1,172 @@
+package scrub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Budget
+		wantErr  bool
+	}{
+		{"", Budget{}, false},
+		{"2h", Budget{Duration: 2 * time.Hour}, false},
+		{"90m", Budget{Duration: 90 * time.Minute}, false},
+		{"500GB", Budget{Bytes: 500 << 30}, false},
+		{"500 GiB", Budget{Bytes: 500 << 30}, false},
+		{"1.5tb", Budget{Bytes: 3 << 39}, false},
+		{"100M", Budget{Bytes: 100 << 20}, false},
+		{"0h", Budget{}, true},
+		{"-5GB", Budget{}, true},
+		{"lots", Budget{}, true},
+		{"5 parsecs", Budget{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			budget, err := ParseBudget(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBudget(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if budget != tt.expected {
+				t.Errorf("ParseBudget(%q) = %+v, expected %+v", tt.input, budget, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "scrub.json")
+
+	state, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of missing file error = %v", err)
+	}
+	if len(state.Files) != 0 {
+		t.Errorf("Expected empty state, got %d files", len(state.Files))
+	}
+
+	verifiedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	failedAt := verifiedAt.Add(24 * time.Hour)
+	state.Record("/videos/a.mp4", ResultVerified, verifiedAt)
+	state.Record("/videos/a.mp4", ResultMismatch, failedAt)
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got := loaded.Get("/videos/a.mp4")
+	if !got.LastVerified.Equal(verifiedAt) {
+		t.Errorf("A mismatch must not move LastVerified: got %v, expected %v", got.LastVerified, verifiedAt)
+	}
+	if !got.LastChecked.Equal(failedAt) || got.LastResult != ResultMismatch {
+		t.Errorf("Expected last check %v/%s, got %v/%s", failedAt, ResultMismatch, got.LastChecked, got.LastResult)
+	}
+
+	// No temporary files are left next to the state
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file, found %d entries", len(entries))
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrub.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for corrupt state file")
+	}
+}
+
+func newTestState(t *testing.T) *State {
+	t.Helper()
+
+	state, err := Load(filepath.Join(t.TempDir(), "scrub.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state.Record("/v/old.mp4", ResultVerified, base)
+	state.Record("/v/recent.mp4", ResultVerified, base.AddDate(0, 0, 20))
+	state.Record("/v/middle.mp4", ResultVerified, base.AddDate(0, 0, 10))
+	return state
+}
+
+func TestPlan(t *testing.T) {
+	state := newTestState(t)
+	candidates := []Candidate{
+		{Path: "/v/recent.mp4", Size: 100},
+		{Path: "/v/middle.mp4", Size: 100},
+		{Path: "/v/old.mp4", Size: 100},
+		{Path: "/v/new-b.mp4", Size: 100},
+		{Path: "/v/new-a.mp4", Size: 100},
+	}
+
+	tests := []struct {
+		name     string
+		budget   Budget
+		expected []string
+	}{
+		{"Unlimited", Budget{}, []string{"/v/new-a.mp4", "/v/new-b.mp4", "/v/old.mp4", "/v/middle.mp4", "/v/recent.mp4"}},
+		{"Time budget keeps every file", Budget{Duration: time.Hour}, []string{"/v/new-a.mp4", "/v/new-b.mp4", "/v/old.mp4", "/v/middle.mp4", "/v/recent.mp4"}},
+		{"Size budget", Budget{Bytes: 250}, []string{"/v/new-a.mp4", "/v/new-b.mp4"}},
+		{"Oldest file always included", Budget{Bytes: 10}, []string{"/v/new-a.mp4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planned := Plan(candidates, state, tt.budget)
+			if len(planned) != len(tt.expected) {
+				t.Fatalf("Expected %d files, got %+v", len(tt.expected), planned)
+			}
+			for i, c := range planned {
+				if c.Path != tt.expected[i] {
+					t.Errorf("Position %d: expected %s, got %s", i, tt.expected[i], c.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestStale(t *testing.T) {
+	state := newTestState(t)
+	cutoff := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	stale := state.Stale([]string{"/v/recent.mp4", "/v/middle.mp4", "/v/old.mp4", "/v/never.mp4"}, cutoff)
+
+	expected := []string{"/v/never.mp4", "/v/old.mp4", "/v/middle.mp4"}
+	if len(stale) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, stale)
+	}
+	for i := range expected {
+		if stale[i] != expected[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expected[i], stale[i])
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	state := newTestState(t)
+	before := state.Get("/v/old.mp4")
+
+	state.Move("/v/old.mp4", "/v/renamed.mp4")
+
+	if got := state.Get("/v/renamed.mp4"); got != before {
+		t.Errorf("Expected history to move, got %+v", got)
+	}
+	if _, ok := state.Files["/v/old.mp4"]; ok {
+		t.Error("Old path should be removed")
+	}
+}
@@ -0,0 +1,160 @@
+package scrub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateVersion is written to the state file so future formats can migrate old files
+const stateVersion = 1
+
+// Result is the outcome of the latest verification of a file.
+type Result string
+
+// Verification outcomes recorded in the state
+const (
+	ResultVerified Result = "verified"
+	ResultMismatch Result = "mismatch"
+	ResultCorrupt  Result = "corrupt" // verify --decode found the stream truncated or damaged
+	ResultFailed   Result = "failed"
+	ResultSkipped  Result = "skipped"
+)
+
+// FileState records when a file was last checked and last verified successfully.
+type FileState struct {
+	LastVerified time.Time `json:"lastVerified,omitzero"`
+	LastChecked  time.Time `json:"lastChecked"`
+	LastResult   Result    `json:"lastResult"`
+}
+
+// State is the persistent scrub history, keyed by absolute file path.
+// It is not safe for concurrent use.
+type State struct {
+	Version int                  `json:"version"`
+	Files   map[string]FileState `json:"files"`
+
+	path string
+}
+
+// DefaultPath returns the location of the scrub state file,
+// honouring XDG_STATE_HOME and defaulting to ~/.local/state/videotagger/scrub.json
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "videotagger", "scrub.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "state", "videotagger", "scrub.json"), nil
+}
+
+// Load reads the state file at path. A missing file is an empty state.
+func Load(path string) (*State, error) {
+	state := &State{Version: stateVersion, Files: make(map[string]FileState), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Version > stateVersion {
+		return nil, fmt.Errorf("%s: unsupported state version %d", path, state.Version)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]FileState)
+	}
+
+	return state, nil
+}
+
+// Save writes the state back to the file it was loaded from. The file is replaced
+// atomically so an interrupted save never leaves a truncated history.
+func (s *State) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Get returns the recorded state of a file.
+func (s *State) Get(path string) FileState {
+	return s.Files[key(path)]
+}
+
+// Record stores the outcome of verifying a file at the given time.
+// Only successful verifications move LastVerified forward.
+func (s *State) Record(path string, result Result, at time.Time) {
+	k := key(path)
+	entry := s.Files[k]
+	entry.LastChecked = at
+	entry.LastResult = result
+	if result == ResultVerified {
+		entry.LastVerified = at
+	}
+	s.Files[k] = entry
+}
+
+// Move carries the history of a renamed file over to its new path.
+func (s *State) Move(oldPath, newPath string) {
+	oldKey := key(oldPath)
+	if entry, ok := s.Files[oldKey]; ok {
+		s.Files[key(newPath)] = entry
+		delete(s.Files, oldKey)
+	}
+}
+
+// Stale returns the paths that have not been verified successfully since cutoff,
+// including paths that were never verified, oldest first.
+func (s *State) Stale(paths []string, cutoff time.Time) []string {
+	var stale []string
+	for _, path := range paths {
+		if s.Get(path).LastVerified.Before(cutoff) {
+			stale = append(stale, path)
+		}
+	}
+
+	sortOldestFirst(s, stale, func(path string) string { return path })
+	return stale
+}
+
+// key returns the absolute form of path so relative arguments match across runs
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,78 @@
+package sfv
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Index looks up trusted checksums from SFV files stored next to video files.
+// The SFV files in a directory are read once, on the first lookup for that directory.
+// It is safe for concurrent use.
+type Index struct {
+	mu      sync.Mutex
+	loaded  map[string]bool
+	entries map[string]Entry // keyed by cleaned full path
+	onError func(path string, err error)
+}
+
+// NewIndex creates an empty index. onError, if not nil, is called for SFV files that can't be read;
+// their entries are ignored.
+func NewIndex(onError func(path string, err error)) *Index {
+	return &Index{
+		loaded:  make(map[string]bool),
+		entries: make(map[string]Entry),
+		onError: onError,
+	}
+}
+
+// Lookup returns the CRC32 an SFV file records for path, provided it also records the same size.
+// Entries without a size are not trusted because a stale checksum couldn't be detected.
+func (idx *Index) Lookup(path string, size int64) (uint32, bool) {
+	path = filepath.Clean(path)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.loadDir(filepath.Dir(path))
+
+	entry, ok := idx.entries[path]
+	if !ok || !entry.HasSize() || entry.Size != size {
+		return 0, false
+	}
+
+	return entry.CRC32, true
+}
+
+// loadDir reads every SFV file in dir into the index. The caller must hold idx.mu.
+func (idx *Index) loadDir(dir string) {
+	if idx.loaded[dir] {
+		return
+	}
+	idx.loaded[dir] = true
+
+	// Directory names often contain brackets, so list entries rather than globbing
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !IsSFVFile(dirEntry.Name()) {
+			continue
+		}
+
+		sfvPath := filepath.Join(dir, dirEntry.Name())
+		file, err := Read(sfvPath)
+		if err != nil {
+			if idx.onError != nil {
+				idx.onError(sfvPath, err)
+			}
+			continue
+		}
+
+		for _, entry := range file.Entries {
+			idx.entries[filepath.Clean(file.EntryPath(entry))] = entry
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package sfv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Extension is the file extension of SFV checksum files.
+const Extension = ".sfv"
+
+// IsSFVFile reports whether name has the SFV extension, in any case.
+func IsSFVFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), Extension)
+}
+
+// Entry is one checksummed file in an SFV file.
+type Entry struct {
+	Name    string    // Path relative to the directory containing the SFV file
+	CRC32   uint32    // CRC32 checksum of the file contents
+	Size    int64     // File size in bytes, or -1 when the SFV has no size comment for it
+	ModTime time.Time // Modification time from the size comment, zero when unknown
+}
+
+// HasSize reports whether the SFV recorded the file's size.
+func (e Entry) HasSize() bool {
+	return e.Size >= 0
+}
+
+// File is a parsed SFV file.
+type File struct {
+	Path    string // Location of the SFV file, empty when parsed from a reader
+	Entries []Entry
+}
+
+// Dir returns the directory entry names are relative to.
+func (f *File) Dir() string {
+	return filepath.Dir(f.Path)
+}
+
+// EntryPath returns the full path of an entry.
+func (f *File) EntryPath(e Entry) string {
+	return filepath.Join(f.Dir(), filepath.FromSlash(e.Name))
+}
+
+// sizeCommentRegex matches cksfv-style size comments: "; <size> <hh:mm.ss> <yyyy-mm-dd> <name>"
+var sizeCommentRegex = regexp.MustCompile(`^;\s*(\d+)\s+(\d{2}:\d{2}\.\d{2})\s+(\d{4}-\d{2}-\d{2})\s+(.+)$`)
+
+// checksumLineRegex matches "<name> <crc32>", where the name may contain spaces
+var checksumLineRegex = regexp.MustCompile(`^(.+?)\s+([0-9A-Fa-f]{8})$`)
+
+// timeLayout is the timestamp layout used in size comments
+const timeLayout = "15:04.05 2006-01-02"
+
+// Read parses the SFV file at path.
+func Read(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file.Path = path
+
+	return file, nil
+}
+
+// Parse reads SFV content. Size comments are matched to checksum lines by name;
+// other comments and blank lines are ignored.
+func Parse(r io.Reader) (*File, error) {
+	type sizeInfo struct {
+		size    int64
+		modTime time.Time
+	}
+	sizes := make(map[string]sizeInfo)
+	file := &File{}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ";") {
+			if m := sizeCommentRegex.FindStringSubmatch(line); m != nil {
+				size, err := strconv.ParseInt(m[1], 10, 64)
+				if err != nil {
+					continue
+				}
+				modTime, _ := time.ParseInLocation(timeLayout, m[2]+" "+m[3], time.Local)
+				sizes[normalizeName(m[4])] = sizeInfo{size: size, modTime: modTime}
+			}
+			continue
+		}
+
+		m := checksumLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected \"<name> <crc32>\", got %q", lineNumber, line)
+		}
+
+		crc, err := strconv.ParseUint(m[2], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid CRC32 %q", lineNumber, m[2])
+		}
+
+		file.Entries = append(file.Entries, Entry{
+			Name:  normalizeName(m[1]),
+			CRC32: uint32(crc),
+			Size:  -1,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range file.Entries {
+		if info, ok := sizes[entry.Name]; ok {
+			file.Entries[i].Size = info.size
+			file.Entries[i].ModTime = info.modTime
+		}
+	}
+
+	return file, nil
+}
+
+// normalizeName converts Windows-style separators so names work on every platform
+func normalizeName(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(name), `\`, "/")
+}
+
+// Write writes entries in SFV format. Entries with a known size get a cksfv-style
+// size comment so readers can check sizes before trusting a checksum.
+func Write(w io.Writer, entries []Entry, generator string) error {
+	bw := bufio.NewWriter(w)
+
+	now := time.Now()
+	fmt.Fprintf(bw, "; Generated by %s on %s at %s\n", generator, now.Format("2006-01-02"), now.Format("15:04.05"))
+	fmt.Fprintln(bw, ";")
+
+	for _, e := range entries {
+		if e.HasSize() {
+			fmt.Fprintf(bw, "; %12d  %s %s\n", e.Size, e.ModTime.Format(timeLayout), e.Name)
+		}
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%s %08X\n", e.Name, e.CRC32)
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile writes entries to an SFV file at path.
+func WriteFile(path string, entries []Entry, generator string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, entries, generator); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,159 @@
+package sfv
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cksfvOutput = "; Generated by cksfv v1.3.14 on 2024-03-08 at 20:04.17\r\n" +
+	"; Project web site: http://zakalwe.fi/~shd/foss/cksfv/\r\n" +
+	";\r\n" +
+	";         1024  20:03.59 2024-03-08 My Movie (2020).mkv\r\n" +
+	"My Movie (2020).mkv 1A2B3C4D\r\n" +
+	"extras\\trailer.mp4 deadbeef\r\n"
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(cksfvOutput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(file.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(file.Entries))
+	}
+
+	movie := file.Entries[0]
+	if movie.Name != "My Movie (2020).mkv" {
+		t.Errorf("Expected name with spaces, got %q", movie.Name)
+	}
+	if movie.CRC32 != 0x1A2B3C4D {
+		t.Errorf("Expected CRC 1A2B3C4D, got %08X", movie.CRC32)
+	}
+	if !movie.HasSize() || movie.Size != 1024 {
+		t.Errorf("Expected size 1024 from comment, got %d", movie.Size)
+	}
+	expectedTime := time.Date(2024, 3, 8, 20, 3, 59, 0, time.Local)
+	if !movie.ModTime.Equal(expectedTime) {
+		t.Errorf("Expected mod time %v, got %v", expectedTime, movie.ModTime)
+	}
+
+	trailer := file.Entries[1]
+	if trailer.Name != "extras/trailer.mp4" {
+		t.Errorf("Expected backslashes to be normalized, got %q", trailer.Name)
+	}
+	if trailer.CRC32 != 0xDEADBEEF {
+		t.Errorf("Expected lowercase CRC to parse, got %08X", trailer.CRC32)
+	}
+	if trailer.HasSize() {
+		t.Error("Entry without size comment should have unknown size")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Missing CRC", "video.mp4\n"},
+		{"Short CRC", "video.mp4 1234\n"},
+		{"Non-hex CRC", "video.mp4 ZZZZZZZZ\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.content)); err == nil {
+				t.Error("Expected parse error")
+			}
+		})
+	}
+}
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	entries := []Entry{
+		{Name: "a_[1920x1080][45min][0000ABCD].mp4", CRC32: 0xABCD, Size: 4096, ModTime: modTime},
+		{Name: "b.mkv", CRC32: 0xFFFFFFFF, Size: -1},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, entries, "videotagger test"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "a_[1920x1080][45min][0000ABCD].mp4 0000ABCD") {
+		t.Errorf("Expected checksum line with zero-padded CRC, got:\n%s", buf.String())
+	}
+
+	file, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(file.Entries) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(file.Entries))
+	}
+	for i, entry := range file.Entries {
+		expected := entries[i]
+		if entry.Name != expected.Name || entry.CRC32 != expected.CRC32 || entry.Size != expected.Size {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, expected, entry)
+		}
+		if !entry.ModTime.Equal(expected.ModTime) {
+			t.Errorf("Entry %d: expected mod time %v, got %v", i, expected.ModTime, entry.ModTime)
+		}
+	}
+}
+
+func TestIndexLookup(t *testing.T) {
+	// Brackets in directory names must not be treated as glob patterns
+	dir := filepath.Join(t.TempDir(), "Show [1080p]")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	entries := []Entry{
+		{Name: "sized.mp4", CRC32: 0x11111111, Size: 100},
+		{Name: "unsized.mp4", CRC32: 0x22222222, Size: -1},
+	}
+	if err := WriteFile(filepath.Join(dir, "Show.SFV"), entries, "test"); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.sfv"), []byte("not an sfv line\n"), 0644); err != nil {
+		t.Fatalf("Failed to write broken SFV: %v", err)
+	}
+
+	var errorPaths []string
+	index := NewIndex(func(path string, err error) {
+		errorPaths = append(errorPaths, path)
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		size     int64
+		expected uint32
+		found    bool
+	}{
+		{"Matching size", filepath.Join(dir, "sized.mp4"), 100, 0x11111111, true},
+		{"Different size", filepath.Join(dir, "sized.mp4"), 101, 0, false},
+		{"No size recorded", filepath.Join(dir, "unsized.mp4"), 100, 0, false},
+		{"Not listed", filepath.Join(dir, "other.mp4"), 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crc, found := index.Lookup(tt.path, tt.size)
+			if found != tt.found || crc != tt.expected {
+				t.Errorf("Lookup() = %08X, %v; expected %08X, %v", crc, found, tt.expected, tt.found)
+			}
+		})
+	}
+
+	// The directory is only read once, so the broken file is reported once
+	if len(errorPaths) != 1 || filepath.Base(errorPaths[0]) != "broken.sfv" {
+		t.Errorf("Expected one error for broken.sfv, got %v", errorPaths)
+	}
+}
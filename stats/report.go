@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+// Section is a titled dimension of the report, used by the renderers.
+type Section struct {
+	Title   string
+	Buckets []Bucket
+}
+
+// Sections returns the report dimensions in display order.
+func (r *Report) Sections() []Section {
+	return []Section{
+		{Title: "Resolution", Buckets: r.ByResolution},
+		{Title: "Codec", Buckets: r.ByCodec},
+		{Title: "Container", Buckets: r.ByContainer},
+		{Title: "Duration", Buckets: r.ByDuration},
+		{Title: "Year", Buckets: r.ByYear},
+	}
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteHTML writes the report as a self-contained HTML page with inline SVG bar charts.
+func WriteHTML(w io.Writer, report *Report) error {
+	return htmlTemplate.Execute(w, report)
+}
+
+// Share returns part as a percentage of total.
+func Share(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// largestBucket returns the byte count of the largest bucket, used to scale chart bars
+func largestBucket(buckets []Bucket) int64 {
+	var largest int64
+	for _, bucket := range buckets {
+		largest = max(largest, bucket.Bytes)
+	}
+	return largest
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": utils.FormatBytes,
+	"share": Share,
+	"barWidth": func(bucket Bucket, buckets []Bucket) float64 {
+		return Share(bucket.Bytes, largestBucket(buckets)) * 4
+	},
+	"barY": func(i int) int { return i * 26 },
+	"chartHeight": func(buckets []Bucket) int {
+		return len(buckets) * 26
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>VideoTagger library report</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem; color: #222; background: #fafafa; }
+  h1 { margin-bottom: 0.2rem; }
+  .meta { color: #666; margin-bottom: 1.5rem; }
+  .summary { display: flex; flex-wrap: wrap; gap: 1rem; margin-bottom: 2rem; }
+  .card { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 0.8rem 1.2rem; min-width: 10rem; }
+  .card strong { display: block; font-size: 1.4rem; }
+  section { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 1rem 1.2rem; margin-bottom: 1.5rem; }
+  table { border-collapse: collapse; margin-top: 0.8rem; }
+  th, td { text-align: left; padding: 0.2rem 1rem 0.2rem 0; }
+  td.num { text-align: right; }
+  svg text { font-size: 12px; fill: #222; }
+  svg rect { fill: #2b7bb9; }
+</style>
+</head>
+<body>
+<h1>Library report</h1>
+<div class="meta">{{.Root}} &middot; generated {{.GeneratedAt.Format "2006-01-02 15:04"}}</div>
+<div class="summary">
+  <div class="card">Files<strong>{{.TotalFiles}}</strong></div>
+  <div class="card">Total size<strong>{{bytes .TotalBytes}}</strong></div>
+  <div class="card">Tagged<strong>{{.TaggedFiles}}</strong></div>
+  <div class="card">Untagged<strong>{{.UntaggedFiles}}</strong></div>
+  <div class="card">Duplicate groups<strong>{{.DuplicateGroups}}</strong></div>
+  <div class="card">Duplicate waste<strong>{{bytes .DuplicateWaste}}</strong></div>
+</div>
+{{- $total := .TotalBytes}}
+{{- range .Sections}}
+{{- $buckets := .Buckets}}
+<section>
+  <h2>{{.Title}}</h2>
+  <svg width="760" height="{{chartHeight $buckets}}" role="img" aria-label="{{.Title}} by size">
+  {{- range $i, $b := $buckets}}
+    <text x="0" y="{{barY $i}}" dy="16">{{$b.Name}}</text>
+    <rect x="120" y="{{barY $i}}" height="20" width="{{barWidth $b $buckets}}"></rect>
+    <text x="{{barWidth $b $buckets}}" dx="128" y="{{barY $i}}" dy="16">{{bytes $b.Bytes}}</text>
+  {{- end}}
+  </svg>
+  <table>
+    <tr><th>{{.Title}}</th><th>Files</th><th>Size</th><th>Share</th></tr>
+    {{- range $buckets}}
+    <tr><td>{{.Name}}</td><td class="num">{{.Files}}</td><td class="num">{{bytes .Bytes}}</td><td class="num">{{printf "%.1f%%" (share .Bytes $total)}}</td></tr>
+    {{- end}}
+  </table>
+</section>
+{{- end}}
+</body>
+</html>
+`))
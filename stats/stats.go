@@ -0,0 +1,312 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lepinkainen/videotagger/video"
+)
+
+// Entry describes a single video file in the library.
+type Entry struct {
+	Path         string
+	Size         int64
+	ModTime      time.Time
+	Resolution   string
+	Codec        string
+	DurationMins float64
+	Tagged       bool
+	Hash         string
+}
+
+// Bucket aggregates file counts and sizes for one value of a dimension.
+type Bucket struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Report is the aggregated view of a video library.
+type Report struct {
+	Root            string    `json:"root"`
+	GeneratedAt     time.Time `json:"generatedAt"`
+	TotalFiles      int       `json:"totalFiles"`
+	TotalBytes      int64     `json:"totalBytes"`
+	TaggedFiles     int       `json:"taggedFiles"`
+	UntaggedFiles   int       `json:"untaggedFiles"`
+	DuplicateGroups int       `json:"duplicateGroups"`
+	DuplicateFiles  int       `json:"duplicateFiles"`
+	DuplicateWaste  int64     `json:"duplicateWaste"`
+	ByResolution    []Bucket  `json:"byResolution"`
+	ByCodec         []Bucket  `json:"byCodec"`
+	ByContainer     []Bucket  `json:"byContainer"`
+	ByDuration      []Bucket  `json:"byDuration"`
+	ByYear          []Bucket  `json:"byYear"`
+}
+
+// Options controls how library entries are collected.
+type Options struct {
+	Workers int  // Number of parallel ffprobe workers
+	Probe   bool // Probe files with ffprobe for codec and untagged metadata
+}
+
+// Unknown is the bucket name used when a dimension could not be determined.
+const Unknown = "unknown"
+
+// resolutionClasses lists resolution buckets from highest to lowest.
+var resolutionClasses = []string{"8K", "4K", "1440p", "1080p", "720p", "480p", "SD", Unknown}
+
+// durationClasses lists duration buckets from shortest to longest.
+var durationClasses = []string{"<10min", "10-30min", "30-60min", "1-2h", "2h+", Unknown}
+
+// Collect scans a directory and gathers an Entry for every video file found.
+func Collect(directory string, opts Options) ([]Entry, error) {
+	files, err := video.FindAllVideoFilesRecursively(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := max(opts.Workers, 1)
+	entries := make([]Entry, len(files))
+	jobs := make(chan int, len(files))
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entries[i] = collectEntry(files[i], opts.Probe)
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return entries, nil
+}
+
+// collectEntry builds an Entry from the filename tags, file info and optionally ffprobe
+func collectEntry(path string, probe bool) Entry {
+	entry := Entry{Path: path}
+
+	if fi, err := os.Stat(path); err == nil {
+		entry.Size = fi.Size()
+		entry.ModTime = fi.ModTime()
+	}
+
+	resolution, durationMins, hash, ok := video.ExtractMetadataFromFilename(filepath.Base(path))
+	if ok {
+		entry.Tagged = true
+		entry.Resolution = resolution
+		entry.DurationMins = float64(durationMins)
+		entry.Hash = strings.ToUpper(hash)
+	}
+
+	if !probe {
+		return entry
+	}
+
+	info, err := video.ProbeVideo(path)
+	if err != nil {
+		return entry
+	}
+
+	entry.Codec = info.Codec
+	if !entry.Tagged {
+		entry.Resolution = info.Resolution
+		entry.DurationMins = info.DurationMins
+	}
+
+	return entry
+}
+
+// Aggregate builds a Report from collected entries.
+func Aggregate(root string, entries []Entry) *Report {
+	report := &Report{
+		Root:        root,
+		GeneratedAt: time.Now(),
+	}
+
+	resolutions := make(map[string]*Bucket)
+	codecs := make(map[string]*Bucket)
+	containers := make(map[string]*Bucket)
+	durations := make(map[string]*Bucket)
+	years := make(map[string]*Bucket)
+	hashGroups := make(map[string][]int64)
+
+	for _, entry := range entries {
+		report.TotalFiles++
+		report.TotalBytes += entry.Size
+
+		if entry.Tagged {
+			report.TaggedFiles++
+			hashGroups[entry.Hash] = append(hashGroups[entry.Hash], entry.Size)
+		} else {
+			report.UntaggedFiles++
+		}
+
+		addToBucket(resolutions, ResolutionClass(entry.Resolution), entry.Size)
+		addToBucket(codecs, valueOrUnknown(strings.ToLower(entry.Codec)), entry.Size)
+		addToBucket(containers, valueOrUnknown(strings.TrimPrefix(strings.ToLower(filepath.Ext(entry.Path)), ".")), entry.Size)
+		addToBucket(durations, DurationClass(entry.DurationMins), entry.Size)
+		if entry.ModTime.IsZero() {
+			addToBucket(years, Unknown, entry.Size)
+		} else {
+			addToBucket(years, strconv.Itoa(entry.ModTime.Year()), entry.Size)
+		}
+	}
+
+	// Every copy beyond the largest one in a hash group is wasted space
+	for _, sizes := range hashGroups {
+		if len(sizes) < 2 {
+			continue
+		}
+
+		var total, largest int64
+		for _, size := range sizes {
+			total += size
+			largest = max(largest, size)
+		}
+
+		report.DuplicateGroups++
+		report.DuplicateFiles += len(sizes)
+		report.DuplicateWaste += total - largest
+	}
+
+	report.ByResolution = orderedBuckets(resolutions, resolutionClasses)
+	report.ByDuration = orderedBuckets(durations, durationClasses)
+	report.ByCodec = bucketsBySize(codecs)
+	report.ByContainer = bucketsBySize(containers)
+	report.ByYear = bucketsByName(years)
+
+	return report
+}
+
+// ResolutionClass maps a WIDTHxHEIGHT resolution to a named class such as "1080p".
+// Both dimensions are considered so that cropped and portrait videos land in the expected class.
+func ResolutionClass(resolution string) string {
+	width, height, ok := parseResolution(resolution)
+	if !ok {
+		return Unknown
+	}
+
+	long, short := max(width, height), min(width, height)
+	switch {
+	case long >= 7600 || short >= 4300:
+		return "8K"
+	case long >= 3800 || short >= 2100:
+		return "4K"
+	case long >= 2500 || short >= 1400:
+		return "1440p"
+	case long >= 1900 || short >= 1060:
+		return "1080p"
+	case long >= 1260 || short >= 700:
+		return "720p"
+	case long >= 640 || short >= 470:
+		return "480p"
+	default:
+		return "SD"
+	}
+}
+
+// DurationClass maps a duration in minutes to a named duration bucket.
+func DurationClass(durationMins float64) string {
+	switch {
+	case durationMins <= 0:
+		return Unknown
+	case durationMins < 10:
+		return "<10min"
+	case durationMins < 30:
+		return "10-30min"
+	case durationMins < 60:
+		return "30-60min"
+	case durationMins < 120:
+		return "1-2h"
+	default:
+		return "2h+"
+	}
+}
+
+// parseResolution splits a WIDTHxHEIGHT string into its dimensions
+func parseResolution(resolution string) (width, height int, ok bool) {
+	w, h, found := strings.Cut(resolution, "x")
+	if !found {
+		return 0, 0, false
+	}
+
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
+
+func addToBucket(buckets map[string]*Bucket, name string, size int64) {
+	bucket, ok := buckets[name]
+	if !ok {
+		bucket = &Bucket{Name: name}
+		buckets[name] = bucket
+	}
+	bucket.Files++
+	bucket.Bytes += size
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return Unknown
+	}
+	return value
+}
+
+// orderedBuckets returns buckets in a fixed order, omitting empty ones
+func orderedBuckets(buckets map[string]*Bucket, order []string) []Bucket {
+	result := make([]Bucket, 0, len(buckets))
+	for _, name := range order {
+		if bucket, ok := buckets[name]; ok {
+			result = append(result, *bucket)
+		}
+	}
+	return result
+}
+
+// bucketsBySize returns buckets sorted by total bytes, largest first
+func bucketsBySize(buckets map[string]*Bucket) []Bucket {
+	result := make([]Bucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, *bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// bucketsByName returns buckets sorted by name, with the unknown bucket last
+func bucketsByName(buckets map[string]*Bucket) []Bucket {
+	result := make([]Bucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, *bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name == Unknown || result[j].Name == Unknown {
+			return result[j].Name == Unknown && result[i].Name != Unknown
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,194 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolutionClass(t *testing.T) {
+	tests := []struct {
+		resolution string
+		expected   string
+	}{
+		{"7680x4320", "8K"},
+		{"3840x2160", "4K"},
+		{"2560x1440", "1440p"},
+		{"1920x1080", "1080p"},
+		{"1920x800", "1080p"},  // Cropped widescreen
+		{"1078x1578", "1080p"}, // Portrait
+		{"1280x720", "720p"},
+		{"854x480", "480p"},
+		{"320x240", "SD"},
+		{"", Unknown},
+		{"garbage", Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := ResolutionClass(tt.resolution); got != tt.expected {
+			t.Errorf("ResolutionClass(%q) = %s, expected %s", tt.resolution, got, tt.expected)
+		}
+	}
+}
+
+func TestDurationClass(t *testing.T) {
+	tests := []struct {
+		duration float64
+		expected string
+	}{
+		{0, Unknown},
+		{5, "<10min"},
+		{10, "10-30min"},
+		{45, "30-60min"},
+		{90, "1-2h"},
+		{180, "2h+"},
+	}
+
+	for _, tt := range tests {
+		if got := DurationClass(tt.duration); got != tt.expected {
+			t.Errorf("DurationClass(%f) = %s, expected %s", tt.duration, got, tt.expected)
+		}
+	}
+}
+
+func testEntries() []Entry {
+	year2020 := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	year2023 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return []Entry{
+		{Path: "a_[1920x1080][45min][AAAAAAAA].mp4", Size: 1000, ModTime: year2020, Resolution: "1920x1080", Codec: "h264", DurationMins: 45, Tagged: true, Hash: "AAAAAAAA"},
+		{Path: "copy_[1920x1080][45min][AAAAAAAA].mp4", Size: 1000, ModTime: year2023, Resolution: "1920x1080", Codec: "h264", DurationMins: 45, Tagged: true, Hash: "AAAAAAAA"},
+		{Path: "b_[3840x2160][120min][BBBBBBBB].mkv", Size: 5000, ModTime: year2023, Resolution: "3840x2160", Codec: "hevc", DurationMins: 120, Tagged: true, Hash: "BBBBBBBB"},
+		{Path: "untagged.avi", Size: 200, ModTime: year2020},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	report := Aggregate("/library", testEntries())
+
+	if report.TotalFiles != 4 {
+		t.Errorf("Expected 4 files, got %d", report.TotalFiles)
+	}
+	if report.TotalBytes != 7200 {
+		t.Errorf("Expected 7200 bytes, got %d", report.TotalBytes)
+	}
+	if report.TaggedFiles != 3 || report.UntaggedFiles != 1 {
+		t.Errorf("Expected 3 tagged and 1 untagged, got %d and %d", report.TaggedFiles, report.UntaggedFiles)
+	}
+	if report.DuplicateGroups != 1 || report.DuplicateFiles != 2 {
+		t.Errorf("Expected 1 duplicate group with 2 files, got %d groups with %d files", report.DuplicateGroups, report.DuplicateFiles)
+	}
+	if report.DuplicateWaste != 1000 {
+		t.Errorf("Expected 1000 bytes of duplicate waste, got %d", report.DuplicateWaste)
+	}
+
+	// Resolution buckets follow the fixed class order
+	expectedResolutions := []string{"4K", "1080p", Unknown}
+	if len(report.ByResolution) != len(expectedResolutions) {
+		t.Fatalf("Expected %d resolution buckets, got %d", len(expectedResolutions), len(report.ByResolution))
+	}
+	for i, name := range expectedResolutions {
+		if report.ByResolution[i].Name != name {
+			t.Errorf("Resolution bucket %d = %s, expected %s", i, report.ByResolution[i].Name, name)
+		}
+	}
+
+	// Codec buckets are sorted by size, largest first
+	if report.ByCodec[0].Name != "hevc" || report.ByCodec[0].Bytes != 5000 {
+		t.Errorf("Expected hevc to be the largest codec bucket, got %+v", report.ByCodec[0])
+	}
+
+	// Year buckets are sorted chronologically
+	if len(report.ByYear) != 2 || report.ByYear[0].Name != "2020" || report.ByYear[1].Name != "2023" {
+		t.Errorf("Unexpected year buckets: %+v", report.ByYear)
+	}
+	if report.ByYear[0].Files != 2 || report.ByYear[0].Bytes != 1200 {
+		t.Errorf("Unexpected 2020 bucket: %+v", report.ByYear[0])
+	}
+
+	containers := make(map[string]int)
+	for _, bucket := range report.ByContainer {
+		containers[bucket.Name] = bucket.Files
+	}
+	if containers["mp4"] != 2 || containers["mkv"] != 1 || containers["avi"] != 1 {
+		t.Errorf("Unexpected container buckets: %+v", report.ByContainer)
+	}
+}
+
+func TestCollect_WithoutProbe(t *testing.T) {
+	testDir := t.TempDir()
+
+	files := map[string]string{
+		"movie_[1920x1080][45min][A1B2C3D4].mp4": "tagged content",
+		"sub/untagged.mkv":                       "untagged",
+		"notes.txt":                              "not a video",
+	}
+	for name, content := range files {
+		path := filepath.Join(testDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	entries, err := Collect(testDir, Options{Workers: 2, Probe: false})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 video entries, got %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Path, ".mp4") {
+			if !entry.Tagged || entry.Resolution != "1920x1080" || entry.DurationMins != 45 || entry.Hash != "A1B2C3D4" {
+				t.Errorf("Tagged entry not parsed from filename: %+v", entry)
+			}
+		} else if entry.Tagged {
+			t.Errorf("Untagged entry reported as tagged: %+v", entry)
+		}
+		if entry.Size == 0 {
+			t.Errorf("Expected size to be populated for %s", entry.Path)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	report := Aggregate("/library", testEntries())
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, report); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON report: %v", err)
+	}
+	if decoded.TotalFiles != report.TotalFiles || decoded.DuplicateWaste != report.DuplicateWaste {
+		t.Errorf("Decoded report does not match: %+v", decoded)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	report := Aggregate("/library", testEntries())
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, report); err != nil {
+		t.Fatalf("WriteHTML() error = %v", err)
+	}
+
+	html := buf.String()
+	for _, expected := range []string{"<svg", "Resolution", "Codec", "hevc", "1080p", "/library"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected HTML report to contain %q", expected)
+		}
+	}
+}
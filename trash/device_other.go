@@ -0,0 +1,8 @@
+//go:build !unix
+
+package trash
+
+// deviceOf is only implemented on Unix, where the XDG trash is used
+func deviceOf(string) (uint64, error) {
+	return 0, ErrUnsupported
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package trash
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// deviceOf returns the ID of the device holding path
+func deviceOf(path string) (uint64, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("can't tell which device holds %s", path)
+	}
+	return uint64(stat.Dev), nil // Dev is int32 on some platforms
+}
@@ -0,0 +1,25 @@
+package trash
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// escapePath percent-encodes a path for a .trashinfo file, keeping the separators
+func escapePath(path string) string {
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// unescapePath decodes a path read from a .trashinfo file
+func unescapePath(value string) (string, error) {
+	path, err := url.PathUnescape(value)
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(path), nil
+}
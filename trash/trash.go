@@ -0,0 +1,417 @@
+// Package trash moves files to the freedesktop.org trash and back.
+//
+// A trash directory holds the trashed files in files/ and one .trashinfo file per entry in
+// info/, recording where the file came from and when it was trashed. Files on the same
+// filesystem as the home trash ($XDG_DATA_HOME/Trash) go there; files on other mounts go to
+// $topdir/.Trash/$uid or $topdir/.Trash-$uid so trashing never copies data between disks.
+// A holding directory given by the user uses the same layout.
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+const (
+	infoSuffix = ".trashinfo"
+	infoHeader = "[Trash Info]"
+	dateLayout = "2006-01-02T15:04:05"
+)
+
+var (
+	// ErrUnsupported is returned where the XDG trash can't be used; a holding directory still works
+	ErrUnsupported = errors.New("the XDG trash is not supported on this platform")
+	// ErrNotTrashed is returned when no trash entry matches a path
+	ErrNotTrashed = errors.New("not found in the trash")
+)
+
+// Item is one trashed file
+type Item struct {
+	Trash        string    `json:"trash"`        // Trash directory holding the file
+	Name         string    `json:"name"`         // Name inside files/ and, with .trashinfo, inside info/
+	OriginalPath string    `json:"originalPath"` // Absolute path the file was trashed from
+	DeletedAt    time.Time `json:"deletedAt"`
+}
+
+// FilePath returns where the trashed file is kept
+func (i Item) FilePath() string {
+	return filepath.Join(i.Trash, "files", i.Name)
+}
+
+// InfoPath returns the item's .trashinfo file
+func (i Item) InfoPath() string {
+	return filepath.Join(i.Trash, "info", i.Name+infoSuffix)
+}
+
+// HomeDir returns the home trash directory, honouring XDG_DATA_HOME
+func HomeDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "Trash"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// Move moves a file to the XDG trash: the home trash when the file is on the same
+// filesystem, otherwise the trash at the top of the file's mount. When no trash can be
+// created on that mount, the file is copied to the home trash.
+func Move(path string) (*Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return nil, err
+	}
+
+	home, err := HomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	sameDevice, err := onSameDevice(abs, home)
+	if err != nil {
+		return nil, err
+	}
+	if !sameDevice {
+		if dir, topDir, err := mountTrash(abs); err == nil {
+			return moveInto(dir, topDir, abs)
+		}
+	}
+
+	return moveInto(home, "", abs)
+}
+
+// MoveTo moves a file into a holding directory laid out like a trash directory
+func MoveTo(dir, path string) (*Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return nil, err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return moveInto(dir, "", abs)
+}
+
+// moveInto trashes abs into dir. Paths in the info file are written relative to topDir
+// when it is set, as the spec asks of trash directories at the top of a mount.
+func moveInto(dir, topDir, abs string) (*Item, error) {
+	for _, sub := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	item := Item{Trash: dir, OriginalPath: abs, DeletedAt: time.Now().Truncate(time.Second)}
+
+	recorded := abs
+	if topDir != "" {
+		if rel, err := filepath.Rel(topDir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			recorded = rel
+		}
+	}
+
+	// Claim a name by creating its info file exclusively, so two processes trashing
+	// files with the same name can't pick the same entry
+	base := filepath.Base(abs)
+	for n := 1; ; n++ {
+		item.Name = base
+		if n > 1 {
+			ext := filepath.Ext(base)
+			item.Name = fmt.Sprintf("%s.%d%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		if _, err := os.Lstat(item.FilePath()); err == nil {
+			continue
+		}
+
+		err := writeInfo(item.InfoPath(), recorded, item.DeletedAt)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write trash info: %w", err)
+		}
+		break
+	}
+
+	if err := utils.MoveFile(abs, item.FilePath()); err != nil {
+		_ = os.Remove(item.InfoPath())
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// Restore moves a trashed file back to its original path and removes its info file.
+// It refuses to overwrite a file that has since appeared at the original path.
+func Restore(item Item) error {
+	if _, err := os.Lstat(item.FilePath()); err != nil {
+		return fmt.Errorf("trashed file missing: %w", err)
+	}
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return fmt.Errorf("cannot restore, %s already exists", item.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0o755); err != nil {
+		return err
+	}
+	if err := utils.MoveFile(item.FilePath(), item.OriginalPath); err != nil {
+		return err
+	}
+
+	return os.Remove(item.InfoPath())
+}
+
+// List returns the entries of a trash directory, oldest first. A missing directory is empty.
+// Info files that can't be parsed, or whose file is gone, are skipped.
+func List(dir string) ([]Item, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, "info"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	topDir := topDirOf(dir)
+
+	var items []Item
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), infoSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		item := Item{Trash: dir, Name: name}
+		original, deletedAt, err := readInfo(item.InfoPath())
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(original) {
+			if topDir == "" {
+				continue
+			}
+			original = filepath.Join(topDir, original)
+		}
+		item.OriginalPath, item.DeletedAt = filepath.Clean(original), deletedAt
+
+		if _, err := os.Lstat(item.FilePath()); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	slices.SortStableFunc(items, func(a, b Item) int { return a.DeletedAt.Compare(b.DeletedAt) })
+	return items, nil
+}
+
+// DirsFor returns the trash directories that may hold files trashed from path:
+// the home trash and the trash directories at the top of path's mount.
+// Directories that don't exist are left out.
+func DirsFor(path string) ([]string, error) {
+	var dirs []string
+	if home, err := HomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+
+	if topDir, err := mountTop(existingParent(path)); err == nil {
+		dirs = append(dirs,
+			filepath.Join(topDir, ".Trash", strconv.Itoa(os.Getuid())),
+			filepath.Join(topDir, fmt.Sprintf(".Trash-%d", os.Getuid())))
+	}
+
+	var existing []string
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() && !slices.Contains(existing, dir) {
+			existing = append(existing, dir)
+		}
+	}
+	return existing, nil
+}
+
+// mountTrash returns the trash directory to use for a file on a mount other than the home
+// trash's, creating it if needed, along with the mount's top directory. $topdir/.Trash/$uid
+// is used when $topdir/.Trash is an administrator-created sticky directory, otherwise
+// $topdir/.Trash-$uid.
+func mountTrash(abs string) (dir, topDir string, err error) {
+	topDir, err = mountTop(abs)
+	if err != nil {
+		return "", "", err
+	}
+	uid := os.Getuid()
+
+	shared := filepath.Join(topDir, ".Trash")
+	if fi, err := os.Lstat(shared); err == nil && fi.IsDir() && fi.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(shared, strconv.Itoa(uid))
+		if err := ensurePrivateDir(dir); err == nil {
+			return dir, topDir, nil
+		}
+	}
+
+	dir = filepath.Join(topDir, fmt.Sprintf(".Trash-%d", uid))
+	if err := ensurePrivateDir(dir); err != nil {
+		return "", "", err
+	}
+	return dir, topDir, nil
+}
+
+// ensurePrivateDir creates dir with owner-only permissions, refusing a symlink in its place
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+// topDirOf returns the directory relative paths in a trash directory's info files start from:
+// the parent of $topdir/.Trash-$uid, or the grandparent of $topdir/.Trash/$uid.
+// Other trash directories record absolute paths.
+func topDirOf(dir string) string {
+	parent := filepath.Dir(dir)
+	switch {
+	case strings.HasPrefix(filepath.Base(dir), ".Trash-"):
+		return parent
+	case filepath.Base(parent) == ".Trash":
+		return filepath.Dir(parent)
+	default:
+		return ""
+	}
+}
+
+// mountTop returns the top directory of the mount holding path
+func mountTop(path string) (string, error) {
+	dir := path
+	if fi, err := os.Lstat(path); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, nil
+		}
+		same, err := onSameDevice(dir, parent)
+		if err != nil {
+			return "", err
+		}
+		if !same {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
+
+// onSameDevice reports whether two paths, or their nearest existing parents, are on the same device
+func onSameDevice(a, b string) (bool, error) {
+	devA, err := deviceOf(existingParent(a))
+	if err != nil {
+		return false, err
+	}
+	devB, err := deviceOf(existingParent(b))
+	if err != nil {
+		return false, err
+	}
+	return devA == devB, nil
+}
+
+// existingParent returns path, or its nearest ancestor that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Lstat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// writeInfo creates a .trashinfo file, failing if it already exists
+func writeInfo(path, original string, deletedAt time.Time) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "%s\nPath=%s\nDeletionDate=%s\n", infoHeader, escapePath(original), deletedAt.Format(dateLayout))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// readInfo parses a .trashinfo file
+func readInfo(path string) (original string, deletedAt time.Time, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	inSection := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == infoHeader
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if original, err = unescapePath(value); err != nil {
+				return "", time.Time{}, fmt.Errorf("%s: %w", path, err)
+			}
+		case "DeletionDate":
+			// A bad date doesn't stop the file from being restored
+			deletedAt, _ = time.ParseInLocation(dateLayout, value, time.Local)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	if original == "" {
+		return "", time.Time{}, fmt.Errorf("%s: no Path entry", path)
+	}
+
+	return original, deletedAt, nil
+}
@@ -0,0 +1,184 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveToAndRestore(t *testing.T) {
+	base := t.TempDir()
+	holding := filepath.Join(base, "holding")
+	path := filepath.Join(base, "videos", "a b%c.mp4")
+	writeTestFile(t, path, "contents")
+
+	item, err := MoveTo(holding, path)
+	if err != nil {
+		t.Fatalf("MoveTo() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", path, err)
+	}
+
+	info, err := os.ReadFile(item.InfoPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\nPath=") || !strings.Contains(string(info), "a%20b%25c.mp4\n") {
+		t.Errorf("Unexpected info file:\n%s", info)
+	}
+	if !strings.Contains(string(info), "\nDeletionDate=") {
+		t.Errorf("Expected a deletion date in:\n%s", info)
+	}
+
+	items, err := List(holding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != path || items[0].Name != item.Name {
+		t.Fatalf("Unexpected listing: %+v", items)
+	}
+	if time.Since(items[0].DeletedAt) > time.Minute {
+		t.Errorf("Unexpected deletion time %v", items[0].DeletedAt)
+	}
+
+	if err := Restore(items[0]); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "contents" {
+		t.Errorf("Expected the file to be back, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(item.InfoPath()); !os.IsNotExist(err) {
+		t.Errorf("Expected the info file to be removed, got %v", err)
+	}
+}
+
+func TestMoveToSameName(t *testing.T) {
+	base := t.TempDir()
+	holding := filepath.Join(base, "holding")
+	first := filepath.Join(base, "one", "a.mp4")
+	second := filepath.Join(base, "two", "a.mp4")
+	writeTestFile(t, first, "one")
+	writeTestFile(t, second, "two")
+
+	itemOne, err := MoveTo(holding, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	itemTwo, err := MoveTo(holding, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if itemOne.Name == itemTwo.Name {
+		t.Fatalf("Expected distinct names, both got %s", itemOne.Name)
+	}
+	if itemTwo.Name != "a.2.mp4" {
+		t.Errorf("Expected the second file to be named a.2.mp4, got %s", itemTwo.Name)
+	}
+	if data, _ := os.ReadFile(itemTwo.FilePath()); string(data) != "two" {
+		t.Errorf("Unexpected contents %q", data)
+	}
+}
+
+func TestRestoreRefusesExisting(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "a.mp4")
+	writeTestFile(t, path, "old")
+
+	item, err := MoveTo(filepath.Join(base, "holding"), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, "new")
+
+	if err := Restore(*item); err == nil {
+		t.Fatal("Expected restore to refuse overwriting a new file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("Expected the new file to be untouched, got %q", data)
+	}
+	if _, err := os.Stat(item.FilePath()); err != nil {
+		t.Errorf("Expected the trashed file to stay in the trash, got %v", err)
+	}
+}
+
+func TestMoveUsesHomeTrash(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	path := filepath.Join(base, "a.mp4")
+	writeTestFile(t, path, "contents")
+
+	item, err := Move(path)
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("XDG trash not supported on this platform")
+	}
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+
+	home, _ := HomeDir()
+	if item.Trash != home {
+		t.Errorf("Expected the home trash %s on the same filesystem, got %s", home, item.Trash)
+	}
+
+	dirs, err := DirsFor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 || dirs[0] != home {
+		t.Errorf("Expected DirsFor to include the home trash, got %v", dirs)
+	}
+}
+
+func TestListTopDirTrash(t *testing.T) {
+	topDir := t.TempDir()
+	trashDir := filepath.Join(topDir, ".Trash-1000")
+	writeTestFile(t, filepath.Join(trashDir, "files", "a.mp4"), "contents")
+	writeTestFile(t, filepath.Join(trashDir, "info", "a.mp4.trashinfo"),
+		"[Trash Info]\nPath=videos/a.mp4\nDeletionDate=2024-05-06T07:08:09\n")
+	// Entries without a file, or without a path, are skipped
+	writeTestFile(t, filepath.Join(trashDir, "info", "gone.mp4.trashinfo"),
+		"[Trash Info]\nPath=videos/gone.mp4\nDeletionDate=2024-05-06T07:08:09\n")
+	writeTestFile(t, filepath.Join(trashDir, "files", "bad.mp4"), "contents")
+	writeTestFile(t, filepath.Join(trashDir, "info", "bad.mp4.trashinfo"), "[Trash Info]\n")
+
+	items, err := List(trashDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %+v", items)
+	}
+	if want := filepath.Join(topDir, "videos", "a.mp4"); items[0].OriginalPath != want {
+		t.Errorf("Expected the relative path to resolve to %s, got %s", want, items[0].OriginalPath)
+	}
+	if want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local); !items[0].DeletedAt.Equal(want) {
+		t.Errorf("Expected deletion date %v, got %v", want, items[0].DeletedAt)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	for _, path := range []string{"/videos/a b.mp4", "/videos/ümlaut [ABCD1234].mp4", "/100%/x#y?.mp4"} {
+		escaped := escapePath(path)
+		if strings.ContainsAny(escaped, " #?[]") {
+			t.Errorf("escapePath(%q) = %q, expected reserved characters to be encoded", path, escaped)
+		}
+		got, err := unescapePath(escaped)
+		if err != nil || got != filepath.FromSlash(path) {
+			t.Errorf("Round trip of %q gave %q, %v", path, got, err)
+		}
+	}
+}
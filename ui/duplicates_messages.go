@@ -1,5 +1,7 @@
 package ui
 
+import "github.com/lepinkainen/videotagger/duplicates"
+
 // TUI Message Types for duplicate file management
 type DuplicateGroupSelectedMsg struct {
 	GroupIndex int
@@ -14,10 +16,28 @@ type DeleteSelectedMsg struct {
 	Confirmed bool
 }
 
+// GroupsConfirmedMsg carries the groups after the selected ones were compared byte for byte
+type GroupsConfirmedMsg struct {
+	Groups []duplicates.DuplicateGroup
+	Error  error
+}
+
+// DeletionCompleteMsg carries the outcome for every file that was to be deleted
 type DeletionCompleteMsg struct {
-	FilePath string
-	Success  bool
-	Error    error
+	Results []duplicates.DeletionResult
+}
+
+// LinkCompleteMsg carries the outcome for every file that was to be replaced by a link
+type LinkCompleteMsg struct {
+	Results []duplicates.LinkResult
+}
+
+// MetadataProbedMsg carries a group's files after ffprobe filled in missing metadata,
+// and the keeper to apply to them
+type MetadataProbedMsg struct {
+	GroupIndex int
+	Files      []duplicates.FileMetadata
+	Keeper     duplicates.Keeper
 }
 
 type AllFilesSelectedMsg struct{}
@@ -1,40 +1,24 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lepinkainen/videotagger/duplicates"
+	"github.com/lepinkainen/videotagger/utils"
 )
 
-// formatFileSize converts bytes to human-readable format
-func formatFileSize(bytes int64) string {
-	const (
-		KB = 1024
-		MB = 1024 * KB
-		GB = 1024 * MB
-	)
-
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.1f GB", float64(bytes)/float64(GB))
-	case bytes >= MB:
-		return fmt.Sprintf("%.1f MB", float64(bytes)/float64(MB))
-	case bytes >= KB:
-		return fmt.Sprintf("%.1f KB", float64(bytes)/float64(KB))
-	default:
-		return fmt.Sprintf("%d B", bytes)
-	}
-}
-
 // DuplicatesModel represents the TUI model for duplicate file management
 type DuplicatesModel struct {
 	// Data
 	groups       []duplicates.DuplicateGroup
+	deleteOpts   duplicates.DeleteOptions // reference directories and where deleted files go
 	currentGroup int
 	currentFile  int
 
@@ -48,8 +32,17 @@ type DuplicatesModel struct {
 
 	// Interaction state
 	confirmingDeletion bool
-	pendingDeletion    []string // files pending deletion
+	comparing          bool                      // selected groups are being compared before deletion
+	probing            bool                      // the current group is being probed before a keep strategy
+	pendingDeletion    []duplicates.FileMetadata // files pending deletion
+	linking            bool                      // the pending action links instead of deleting
+	linkMode           duplicates.LinkMode
+	confirmingLink     bool
+	pendingLinks       []duplicates.LinkTarget // duplicates pending replacement by links
 	showHelp           bool
+	statusMessage      string
+	keepPolicy         duplicates.Policy // keep rules applied with 0
+	keepReasons        map[string]string // path -> why a keep strategy kept the file
 
 	// Control state
 	quitting bool
@@ -57,18 +50,32 @@ type DuplicatesModel struct {
 
 // NewDuplicatesModel creates a new duplicates TUI model
 func NewDuplicatesModel(duplicatePaths map[string][]string) DuplicatesModel {
-	groups := duplicates.BuildGroups(duplicatePaths)
+	return NewDuplicatesModelFromGroups(duplicates.BuildGroups(duplicatePaths), duplicates.DeleteOptions{})
+}
+
+// NewDuplicatesModelFromGroups creates a new duplicates TUI model from already built groups.
+// Files inside the reference directories are marked protected and can't be selected.
+func NewDuplicatesModelFromGroups(groups []duplicates.DuplicateGroup, opts duplicates.DeleteOptions) DuplicatesModel {
+	opts.Reference.Mark(groups)
 
 	return DuplicatesModel{
 		groups:               groups,
+		deleteOpts:           opts,
 		currentGroup:         0,
 		currentFile:          0,
 		showHelp:             true,
 		totalSelectedCount:   0,
 		groupsWithSelections: make(map[int]int),
+		keepReasons:          make(map[string]string),
 	}
 }
 
+// WithKeepPolicy sets the keep rules applied to the current group with the 0 key
+func (m DuplicatesModel) WithKeepPolicy(policy duplicates.Policy) DuplicatesModel {
+	m.keepPolicy = policy
+	return m
+}
+
 // Init implements tea.Model
 func (m DuplicatesModel) Init() tea.Cmd {
 	return nil
@@ -79,34 +86,117 @@ func (m *DuplicatesModel) recalculateSelectionStats() {
 	m.totalSelectedCount, m.groupsWithSelections = duplicates.RecalculateSelectionStats(m.groups)
 }
 
-// applyAutoSelectStrategy applies an auto-selection strategy to the current group
-// Selects all files EXCEPT the one to keep based on the strategy
-func (m *DuplicatesModel) applyAutoSelectStrategy(strategy duplicates.AutoSelectStrategy) {
+// strategyKeys maps keys to the auto-select strategies they apply to the current group
+var strategyKeys = map[string]duplicates.AutoSelectStrategy{
+	"1": duplicates.KeepNewest,
+	"2": duplicates.KeepOldest,
+	"3": duplicates.KeepLargest,
+	"4": duplicates.KeepSmallest,
+	"5": duplicates.KeepFirst,
+	"6": duplicates.KeepLast,
+	"7": duplicates.KeepFirstPosition,
+	"8": duplicates.KeepLastPosition,
+	"9": duplicates.KeepHighestResolution,
+	"d": duplicates.KeepLongest,
+	"v": duplicates.KeepBestCodec,
+	"b": duplicates.KeepHighestBitsPerPixel,
+}
+
+// applyKeeper selects all files in the current group EXCEPT the one the keeper keeps.
+// Files missing metadata the keeper needs are probed first, off the UI goroutine.
+func (m *DuplicatesModel) applyKeeper(keeper duplicates.Keeper) tea.Cmd {
 	if len(m.groups) == 0 {
+		return nil
+	}
+
+	group := m.groups[m.currentGroup]
+	if !duplicates.NeedsProbe([]duplicates.DuplicateGroup{group}, keeper.Missing) {
+		m.finishKeep(m.currentGroup, keeper)
+		return nil
+	}
+
+	m.probing = true
+	group.Files = slices.Clone(group.Files)
+	groupIndex := m.currentGroup
+	return func() tea.Msg {
+		duplicates.ProbeMissing([]duplicates.DuplicateGroup{group}, keeper.Missing)
+		return MetadataProbedMsg{GroupIndex: groupIndex, Files: group.Files, Keeper: keeper}
+	}
+}
+
+// handleMetadataProbed copies the probed metadata back and applies the keeper
+func (m *DuplicatesModel) handleMetadataProbed(msg MetadataProbedMsg) {
+	m.probing = false
+	if msg.GroupIndex >= len(m.groups) {
 		return
 	}
 
-	group := &m.groups[m.currentGroup]
-	duplicates.ApplyAutoSelectStrategy(group, strategy)
+	files := m.groups[msg.GroupIndex].Files
+	for i, probed := range msg.Files {
+		if i < len(files) && files[i].Path == probed.Path {
+			files[i] = probed
+		}
+	}
+	m.finishKeep(msg.GroupIndex, msg.Keeper)
+}
+
+// finishKeep applies the keeper to a group and labels the kept file with the reason
+func (m *DuplicatesModel) finishKeep(groupIndex int, keeper duplicates.Keeper) {
+	group := &m.groups[groupIndex]
+	keeper.Apply(group)
+
+	m.clearKeepReasons(group)
+	reason := keeper.Explain(group)
+	for i, file := range group.Files {
+		if !group.Selected[i] {
+			m.keepReasons[file.Path] = reason
+		}
+	}
 
 	m.recalculateSelectionStats()
 }
 
+// clearKeepReasons drops the keep labels of a group once its selection changes by hand
+func (m *DuplicatesModel) clearKeepReasons(group *duplicates.DuplicateGroup) {
+	for _, file := range group.Files {
+		delete(m.keepReasons, file.Path)
+	}
+}
+
 // Update implements tea.Model
 func (m DuplicatesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.comparing || m.probing {
+			if msg.String() == "ctrl+c" {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
 		if m.confirmingDeletion {
 			return m.handleConfirmationInput(msg)
 		}
+		if m.confirmingLink {
+			return m.handleLinkConfirmationInput(msg)
+		}
 		return m.handleNormalInput(msg)
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 
+	case GroupsConfirmedMsg:
+		m.handleGroupsConfirmed(msg)
+
 	case DeletionCompleteMsg:
 		m.handleDeletionComplete(msg)
+
+	case LinkCompleteMsg:
+		m.handleLinkComplete(msg)
+
+	case MetadataProbedMsg:
+		m.handleMetadataProbed(msg)
 	}
 
 	return m, nil
@@ -121,6 +211,13 @@ func (m DuplicatesModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		return m, nil
 	}
 
+	m.statusMessage = ""
+
+	if strategy, ok := strategyKeys[msg.String()]; ok {
+		cmd := m.applyKeeper(strategy.Keeper())
+		return m, cmd
+	}
+
 	switch msg.String() {
 	case "ctrl+c", "q":
 		m.quitting = true
@@ -153,14 +250,15 @@ func (m DuplicatesModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 
 	case " ": // spacebar to toggle selection
 		group := &m.groups[m.currentGroup]
-		group.Selected[m.currentFile] = !group.Selected[m.currentFile]
+		if err := duplicates.SetSelected(group, m.currentFile, !group.Selected[m.currentFile]); err != nil {
+			m.statusMessage = "This file is in a reference directory and can't be deleted."
+		}
+		m.clearKeepReasons(group)
 		m.recalculateSelectionStats()
 
 	case "a": // select all files in current group
-		group := &m.groups[m.currentGroup]
-		for i := range group.Selected {
-			group.Selected[i] = true
-		}
+		duplicates.SelectAll(&m.groups[m.currentGroup])
+		m.clearKeepReasons(&m.groups[m.currentGroup])
 		m.recalculateSelectionStats()
 
 	case "c": // clear all selections in current group
@@ -168,6 +266,7 @@ func (m DuplicatesModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		for i := range group.Selected {
 			group.Selected[i] = false
 		}
+		m.clearKeepReasons(group)
 		m.recalculateSelectionStats()
 
 	case "s": // skip current group
@@ -183,23 +282,19 @@ func (m DuplicatesModel) handleNormalInput(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 	case "enter":
 		return m.handleDeleteCommand()
 
-	// Auto-selection strategies (1-8)
-	case "1":
-		m.applyAutoSelectStrategy(duplicates.KeepNewest)
-	case "2":
-		m.applyAutoSelectStrategy(duplicates.KeepOldest)
-	case "3":
-		m.applyAutoSelectStrategy(duplicates.KeepLargest)
-	case "4":
-		m.applyAutoSelectStrategy(duplicates.KeepSmallest)
-	case "5":
-		m.applyAutoSelectStrategy(duplicates.KeepFirst)
-	case "6":
-		m.applyAutoSelectStrategy(duplicates.KeepLast)
-	case "7":
-		m.applyAutoSelectStrategy(duplicates.KeepFirstPosition)
-	case "8":
-		m.applyAutoSelectStrategy(duplicates.KeepLastPosition)
+	case "L":
+		return m.handleLinkCommand(duplicates.Hardlink)
+
+	case "R":
+		return m.handleLinkCommand(duplicates.Reflink)
+
+	case "0": // keep rules
+		if len(m.keepPolicy) == 0 {
+			m.statusMessage = "No keep rules set; pass --keep-rule or set keep-rule in the config file."
+			break
+		}
+		cmd := m.applyKeeper(m.keepPolicy.Keeper())
+		return m, cmd
 	}
 
 	return m, nil
@@ -219,59 +314,138 @@ func (m DuplicatesModel) handleConfirmationInput(msg tea.KeyMsg) (tea.Model, tea
 	return m, nil
 }
 
+func (m DuplicatesModel) handleLinkConfirmationInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.confirmingLink = false
+		return m, m.executeLinkCommand()
+
+	case "n", "N", "ctrl+c", "esc":
+		m.confirmingLink = false
+		m.pendingLinks = nil
+	}
+
+	return m, nil
+}
+
 func (m DuplicatesModel) handleDeleteCommand() (tea.Model, tea.Cmd) {
-	selectedFiles := duplicates.CollectSelectedFiles(m.groups)
+	m.linking = false
+	return m.compareSelected()
+}
+
+// handleLinkCommand starts replacing the selected files with links to the unselected
+// file of their group
+func (m DuplicatesModel) handleLinkCommand(mode duplicates.LinkMode) (tea.Model, tea.Cmd) {
+	m.linking = true
+	m.linkMode = mode
+	return m.compareSelected()
+}
 
-	if len(selectedFiles) == 0 {
+// compareSelected confirms the selected groups by content before the pending action is offered
+func (m DuplicatesModel) compareSelected() (tea.Model, tea.Cmd) {
+	if len(duplicates.CollectSelectedFiles(m.groups)) == 0 {
 		return m, nil // No files selected anywhere
 	}
 
-	m.pendingDeletion = selectedFiles
-	m.confirmingDeletion = true
-	return m, nil
+	// Name tags alone aren't proof, so compare contents before asking to delete
+	m.comparing = true
+	groups := m.groups
+	return m, func() tea.Msg {
+		confirmed, err := duplicates.ConfirmSelected(groups)
+		return GroupsConfirmedMsg{Groups: confirmed, Error: err}
+	}
 }
 
-func (m DuplicatesModel) executeDeleteCommand() tea.Cmd {
-	return func() tea.Msg {
-		failedPath, err := duplicates.DeleteFiles(m.pendingDeletion)
-		if err != nil {
-			return DeletionCompleteMsg{
-				FilePath: failedPath,
-				Success:  false,
-				Error:    err,
-			}
-		}
-		return DeletionCompleteMsg{
-			FilePath: "", // Empty means all successful
-			Success:  true,
-			Error:    nil,
+func (m *DuplicatesModel) handleGroupsConfirmed(msg GroupsConfirmedMsg) {
+	m.comparing = false
+
+	switch {
+	case errors.Is(msg.Error, duplicates.ErrSelectionChanged):
+		m.replaceGroups(msg.Groups)
+		m.statusMessage = "Some files were not identical; groups were split. Review the selection and press Enter again."
+		return
+	case msg.Error != nil:
+		m.statusMessage = fmt.Sprintf("Could not compare files: %v", msg.Error)
+		return
+	}
+
+	m.replaceGroups(msg.Groups)
+	if m.linking {
+		m.pendingLinks = duplicates.CollectLinkTargets(m.groups)
+		m.confirmingLink = len(m.pendingLinks) > 0
+		if !m.confirmingLink {
+			m.statusMessage = "Nothing to link: leave the file to keep unselected in each group."
 		}
+		return
 	}
+
+	m.pendingDeletion = duplicates.CollectSelected(m.groups)
+	m.confirmingDeletion = len(m.pendingDeletion) > 0
 }
 
-func (m *DuplicatesModel) handleDeletionComplete(msg DeletionCompleteMsg) {
-	if msg.Success && msg.FilePath == "" {
-		m.groups = duplicates.ApplyDeletion(m.groups, m.pendingDeletion)
+// replaceGroups swaps in a new set of groups, keeping the cursor in range
+func (m *DuplicatesModel) replaceGroups(groups []duplicates.DuplicateGroup) {
+	m.groups = groups
+	if len(m.groups) == 0 {
+		m.currentGroup, m.currentFile = 0, 0
+		m.recalculateSelectionStats()
+		return
+	}
+	if m.currentGroup >= len(m.groups) {
+		m.currentGroup = len(m.groups) - 1
+	}
+	if m.currentFile >= len(m.groups[m.currentGroup].Files) {
+		m.currentFile = max(len(m.groups[m.currentGroup].Files)-1, 0)
+	}
+	m.recalculateSelectionStats()
+}
 
-		// Handle case where all groups were removed
-		if len(m.groups) == 0 {
-			m.quitting = true
-		} else {
-			// Ensure currentGroup is valid
-			if m.currentGroup >= len(m.groups) {
-				m.currentGroup = len(m.groups) - 1
-			}
+func (m DuplicatesModel) executeDeleteCommand() tea.Cmd {
+	files, opts := m.pendingDeletion, m.deleteOpts
+	return func() tea.Msg {
+		return DeletionCompleteMsg{Results: duplicates.DeleteFiles(files, opts)}
+	}
+}
 
-			// Ensure currentFile is valid for the current group
-			if m.currentFile >= len(m.groups[m.currentGroup].Files) {
-				m.currentFile = max(len(m.groups[m.currentGroup].Files)-1, 0)
-			}
+func (m DuplicatesModel) executeLinkCommand() tea.Cmd {
+	targets, mode, reference := m.pendingLinks, m.linkMode, m.deleteOpts.Reference
+	return func() tea.Msg {
+		return LinkCompleteMsg{Results: duplicates.LinkFiles(targets, mode, reference)}
+	}
+}
 
-			m.recalculateSelectionStats()
+func (m *DuplicatesModel) handleLinkComplete(msg LinkCompleteMsg) {
+	m.replaceGroups(duplicates.ApplyLinks(m.groups, msg.Results))
+	m.pendingLinks = nil
+	if len(m.groups) == 0 {
+		m.quitting = true
+		return
+	}
+
+	for _, result := range msg.Results {
+		if !result.Linked {
+			m.statusMessage = "Not everything was linked: " + duplicates.SummarizeLinks(msg.Results)
+			break
 		}
 	}
+}
 
+func (m *DuplicatesModel) handleDeletionComplete(msg DeletionCompleteMsg) {
+	m.replaceGroups(duplicates.ApplyDeletion(m.groups, msg.Results))
 	m.pendingDeletion = nil
+
+	// Handle case where all groups were removed
+	if len(m.groups) == 0 {
+		m.quitting = true
+		return
+	}
+
+	for _, result := range msg.Results {
+		if !result.Gone() {
+			m.statusMessage = "Not everything was deleted: " + duplicates.SummarizeDeletion(msg.Results)
+			break
+		}
+	}
 }
 
 // View implements tea.Model
@@ -288,6 +462,10 @@ func (m DuplicatesModel) View() string {
 		return m.renderConfirmationDialog()
 	}
 
+	if m.confirmingLink {
+		return m.renderLinkConfirmationDialog()
+	}
+
 	return m.renderMainView()
 }
 
@@ -301,14 +479,44 @@ func (m DuplicatesModel) renderConfirmationDialog() string {
 
 	content.WriteString(HeaderStyle.Render("⚠️  Confirm Deletion"))
 	content.WriteString("\n\n")
-	fmt.Fprintf(&content, "Are you sure you want to delete %d file(s)?\n\n", len(m.pendingDeletion))
+	switch {
+	case m.deleteOpts.Permanent:
+		fmt.Fprintf(&content, "Are you sure you want to permanently delete %d file(s)?\n\n", len(m.pendingDeletion))
+	case m.deleteOpts.TrashDir != "":
+		fmt.Fprintf(&content, "Move %d file(s) to %s?\n\n", len(m.pendingDeletion), m.deleteOpts.TrashDir)
+	default:
+		fmt.Fprintf(&content, "Move %d file(s) to the trash?\n\n", len(m.pendingDeletion))
+	}
 
 	for _, file := range m.pendingDeletion {
-		fmt.Fprintf(&content, "  • %s\n", file)
+		fmt.Fprintf(&content, "  • %s\n", file.Path)
+	}
+
+	content.WriteString("\n")
+	if m.deleteOpts.Permanent {
+		content.WriteString(ErrorStyle.Render("This action cannot be undone!"))
+	} else {
+		content.WriteString(InfoStyle.Render("Bring them back with 'videotagger trash restore <paths>'."))
+	}
+	content.WriteString("\n\n")
+	content.WriteString("Press 'y' to confirm, 'n' to cancel")
+
+	return content.String()
+}
+
+func (m DuplicatesModel) renderLinkConfirmationDialog() string {
+	var content strings.Builder
+
+	content.WriteString(HeaderStyle.Render("🔗 Confirm Linking"))
+	content.WriteString("\n\n")
+	fmt.Fprintf(&content, "Replace %d file(s) with a %s to the kept copy?\n\n", len(m.pendingLinks), m.linkMode)
+
+	for _, target := range m.pendingLinks {
+		fmt.Fprintf(&content, "  • %s\n    → %s\n", target.Path, target.Keep)
 	}
 
 	content.WriteString("\n")
-	content.WriteString(ErrorStyle.Render("This action cannot be undone!"))
+	content.WriteString("Every path keeps existing; files are checked to be identical and on the same filesystem first.")
 	content.WriteString("\n\n")
 	content.WriteString("Press 'y' to confirm, 'n' to cancel")
 
@@ -326,10 +534,26 @@ func (m DuplicatesModel) renderMainView() string {
 
 	// Group info
 	group := m.groups[m.currentGroup]
-	groupInfo := fmt.Sprintf("Hash: %s (%d files)", group.Hash, len(group.Files))
+	status := "name-only"
+	if group.Confirmed {
+		status = "confirmed"
+	}
+	groupInfo := fmt.Sprintf("Hash: %s (%d files) · %s", group.Hash, len(group.Files), status)
 	content.WriteString(InfoStyle.Render(groupInfo))
 	content.WriteString("\n\n")
 
+	switch {
+	case m.comparing:
+		content.WriteString(InfoStyle.Render("Comparing selected files..."))
+		content.WriteString("\n\n")
+	case m.probing:
+		content.WriteString(InfoStyle.Render("Probing files for metadata..."))
+		content.WriteString("\n\n")
+	case m.statusMessage != "":
+		content.WriteString(ErrorStyle.Render(m.statusMessage))
+		content.WriteString("\n\n")
+	}
+
 	// File list
 	content.WriteString(m.renderFileList(group))
 	content.WriteString("\n")
@@ -361,9 +585,12 @@ func (m DuplicatesModel) renderFileList(group duplicates.DuplicateGroup) string
 		var line strings.Builder
 
 		// Selection indicator
-		if group.Selected[i] {
+		switch {
+		case file.Protected:
+			line.WriteString("[🔒] ")
+		case group.Selected[i]:
 			line.WriteString("[✓] ")
-		} else {
+		default:
 			line.WriteString("[ ] ")
 		}
 
@@ -387,6 +614,9 @@ func (m DuplicatesModel) renderFileList(group duplicates.DuplicateGroup) string
 		}
 
 		fmt.Fprintf(&line, " (%s)", displayPath)
+		if reason, ok := m.keepReasons[file.Path]; ok && !group.Selected[i] {
+			line.WriteString(InfoStyle.Render("  ← kept: " + reason))
+		}
 		content.WriteString(line.String())
 		content.WriteString("\n")
 
@@ -412,8 +642,14 @@ func (m DuplicatesModel) renderFileList(group duplicates.DuplicateGroup) string
 
 		metadata.WriteString(" | ")
 
+		// Codec, once known from a probe
+		if file.Codec != "" {
+			metadata.WriteString(file.Codec)
+			metadata.WriteString(" | ")
+		}
+
 		// File size
-		metadata.WriteString(formatFileSize(file.Size))
+		metadata.WriteString(utils.FormatBytes(file.Size))
 
 		metadata.WriteString(" | ")
 
@@ -511,7 +747,7 @@ func (m DuplicatesModel) renderHelp() string {
 		"",
 		"Selection:",
 		"  Space        Toggle file selection",
-		"  a            Select all files in group",
+		"  a            Select all files in group (except protected 🔒 reference files)",
 		"  c            Clear all selections in group",
 		"",
 		"Auto-Select Strategies (select all EXCEPT the one to keep):",
@@ -523,13 +759,24 @@ func (m DuplicatesModel) renderHelp() string {
 		"  6            Keep last file (alphabetically)",
 		"  7            Keep first file (in list)",
 		"  8            Keep last file (in list)",
+		"  9            Keep highest resolution file",
+		"  d            Keep longest file (by duration)",
+		"  v            Keep file with the best codec (HEVC/AV1 > H.264 > MPEG-4)",
+		"  b            Keep file with the most bits per pixel (bitrate for its resolution)",
+		"  0            Keep the file picked by the keep rules (--keep-rule)",
 		"",
 		"Actions:",
-		"  Enter        Delete all selected files from all groups (with confirmation)",
+		"  Enter        Compare contents, then delete selected files (to the trash unless --permanent)",
+		"  L            Replace selected files with hardlinks to the unselected file",
+		"  R            Replace selected files with reflink clones (btrfs/xfs) of the unselected file",
 		"  s            Skip current group",
 		"  h/?          Toggle this help",
 		"  q            Quit",
 		"",
+		"Strategies 9, d, v and b probe files with ffprobe when the filename doesn't tell.",
+		"The kept file is labelled with what made it win.",
+		"Groups are \"confirmed\" once their contents were compared, \"name-only\" until then.",
+		"",
 		fmt.Sprintf("Currently: %d files selected across %d groups", m.totalSelectedCount, len(m.groupsWithSelections)),
 		"",
 	}
@@ -1,6 +1,14 @@
 package ui
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lepinkainen/videotagger/duplicates"
+)
 
 func TestNewDuplicatesModel(t *testing.T) {
 	duplicates := map[string][]string{
@@ -33,24 +41,236 @@ func TestNewDuplicatesModelEmptyInput(t *testing.T) {
 	}
 }
 
-func TestFormatFileSize(t *testing.T) {
-	tests := []struct {
-		bytes    int64
-		expected string
-	}{
-		{100, "100 B"},
-		{1024, "1.0 KB"},
-		{1536, "1.5 KB"},
-		{1048576, "1.0 MB"},
-		{1572864, "1.5 MB"},
-		{1073741824, "1.0 GB"},
-		{2147483648, "2.0 GB"},
+func TestGroupsConfirmedSplitShowsStatus(t *testing.T) {
+	model := NewDuplicatesModelFromGroups([]duplicates.DuplicateGroup{
+		{Hash: "ABC123", Files: []duplicates.FileMetadata{{Path: "a"}, {Path: "b"}, {Path: "c"}}, Selected: []bool{false, true, true}},
+	}, duplicates.DeleteOptions{})
+	model.comparing = true
+
+	split := []duplicates.DuplicateGroup{
+		{Hash: "ABC123", Files: []duplicates.FileMetadata{{Path: "a"}, {Path: "c"}}, Selected: []bool{false, true}, Confirmed: true},
+	}
+	updated, _ := model.Update(GroupsConfirmedMsg{Groups: split, Error: duplicates.ErrSelectionChanged})
+	m := updated.(DuplicatesModel)
+
+	if m.confirmingDeletion {
+		t.Error("Expected no deletion prompt after groups changed")
+	}
+	if m.statusMessage == "" {
+		t.Error("Expected a status message asking to review the selection")
 	}
+	if len(m.groups) != 1 || m.totalSelectedCount != 1 {
+		t.Errorf("Expected the split groups to replace the old ones, got %d groups and %d selected", len(m.groups), m.totalSelectedCount)
+	}
+
+	updated, _ = m.Update(GroupsConfirmedMsg{Groups: m.groups})
+	m = updated.(DuplicatesModel)
+	if !m.confirmingDeletion || len(m.pendingDeletion) != 1 || m.pendingDeletion[0].Path != "c" {
+		t.Errorf("Expected deletion prompt for c, got confirming=%v pending=%v", m.confirmingDeletion, m.pendingDeletion)
+	}
+}
 
-	for _, tt := range tests {
-		result := formatFileSize(tt.bytes)
-		if result != tt.expected {
-			t.Errorf("formatFileSize(%d) = %s, expected %s", tt.bytes, result, tt.expected)
+func TestProtectedFileCannotBeSelected(t *testing.T) {
+	dir := t.TempDir()
+	reference, err := duplicates.NewReferenceRoots([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := NewDuplicatesModelFromGroups([]duplicates.DuplicateGroup{
+		{Hash: "ABC123", Files: []duplicates.FileMetadata{{Path: filepath.Join(dir, "a.mp4")}, {Path: "/elsewhere/b.mp4"}}, Selected: []bool{true, false}},
+	}, duplicates.DeleteOptions{Reference: reference})
+
+	if model.groups[0].Selected[0] {
+		t.Error("Expected the reference file's selection to be cleared")
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m := updated.(DuplicatesModel)
+	if m.groups[0].Selected[0] || m.statusMessage == "" {
+		t.Errorf("Expected toggling the reference file to be refused, selected=%v status=%q", m.groups[0].Selected[0], m.statusMessage)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = updated.(DuplicatesModel)
+	if m.groups[0].Selected[0] || !m.groups[0].Selected[1] || m.totalSelectedCount != 1 {
+		t.Errorf("Expected select all to skip the reference file, got %v", m.groups[0].Selected)
+	}
+}
+
+func TestHardlinkFlow(t *testing.T) {
+	dir := t.TempDir()
+	var files []duplicates.FileMetadata
+	for _, name := range []string{"keep.mp4", "dup.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("same"), 0o644); err != nil {
+			t.Fatal(err)
 		}
+		files = append(files, duplicates.FileMetadata{Path: path, Size: 4})
+	}
+
+	model := NewDuplicatesModelFromGroups([]duplicates.DuplicateGroup{
+		{Hash: "ABC123", Files: files, Selected: []bool{false, true}},
+	}, duplicates.DeleteOptions{})
+
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	updated, _ = updated.Update(cmd())
+	m := updated.(DuplicatesModel)
+	if !m.confirmingLink || len(m.pendingLinks) != 1 {
+		t.Fatalf("Expected a link prompt for one file, got confirming=%v pending=%v status=%q", m.confirmingLink, m.pendingLinks, m.statusMessage)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	updated, _ = updated.Update(cmd())
+	m = updated.(DuplicatesModel)
+	if len(m.groups) != 0 || m.statusMessage != "" {
+		t.Errorf("Expected the group to be resolved, got %d groups, status %q", len(m.groups), m.statusMessage)
+	}
+
+	keepInfo, _ := os.Stat(files[0].Path)
+	dupInfo, _ := os.Stat(files[1].Path)
+	if !os.SameFile(keepInfo, dupInfo) {
+		t.Error("Expected dup.mp4 to be a hardlink of keep.mp4")
+	}
+}
+
+func TestDeletionPartialFailureShowsStatus(t *testing.T) {
+	model := NewDuplicatesModelFromGroups([]duplicates.DuplicateGroup{
+		{
+			Hash:     "ABC123",
+			Files:    []duplicates.FileMetadata{{Path: "a.mp4"}, {Path: "b.mp4"}, {Path: "c.mp4"}},
+			Selected: []bool{false, true, true},
+		},
+	}, duplicates.DeleteOptions{})
+
+	updated, _ := model.Update(DeletionCompleteMsg{Results: []duplicates.DeletionResult{
+		{Path: "b.mp4", Status: duplicates.StatusDeleted},
+		{Path: "c.mp4", Status: duplicates.StatusFailed, Error: os.ErrPermission},
+	}})
+	m := updated.(DuplicatesModel)
+	if m.quitting || len(m.groups) != 1 {
+		t.Fatalf("Expected the group to stay for the failed file, got quitting=%v groups=%+v", m.quitting, m.groups)
+	}
+	if len(m.groups[0].Files) != 2 || m.groups[0].Files[1].Path != "c.mp4" {
+		t.Errorf("Expected the failed file to remain, got %+v", m.groups[0].Files)
+	}
+	if m.statusMessage == "" {
+		t.Error("Expected a status message naming the failure")
+	}
+}
+
+func TestLinkPartialFailureShowsStatus(t *testing.T) {
+	model := NewDuplicatesModelFromGroups([]duplicates.DuplicateGroup{
+		{
+			Hash:     "ABC123",
+			Files:    []duplicates.FileMetadata{{Path: "a.mp4"}, {Path: "b.mp4"}, {Path: "c.mp4"}},
+			Selected: []bool{false, true, true},
+		},
+	}, duplicates.DeleteOptions{})
+
+	updated, _ := model.Update(LinkCompleteMsg{Results: []duplicates.LinkResult{
+		{LinkTarget: duplicates.LinkTarget{Keep: "a.mp4", Path: "b.mp4"}, Error: duplicates.ErrProtected},
+		{LinkTarget: duplicates.LinkTarget{Keep: "a.mp4", Path: "c.mp4"}, Linked: true},
+	}})
+	m := updated.(DuplicatesModel)
+	if m.quitting || len(m.groups) != 1 {
+		t.Fatalf("Expected the group to stay for the failed file, got quitting=%v groups=%+v", m.quitting, m.groups)
+	}
+	if len(m.groups[0].Files) != 2 || m.groups[0].Files[1].Path != "b.mp4" {
+		t.Errorf("Expected only the linked file to be dropped, got %+v", m.groups[0].Files)
+	}
+	if m.statusMessage == "" {
+		t.Error("Expected a status message naming the failure")
+	}
+}
+
+func TestKeepRulesKey(t *testing.T) {
+	groups := []duplicates.DuplicateGroup{
+		{
+			Hash:     "ABC123",
+			Files:    []duplicates.FileMetadata{{Path: "a.mp4", Resolution: "1280x720"}, {Path: "b.mp4", Resolution: "1920x1080"}},
+			Selected: []bool{false, false},
+		},
+	}
+
+	model := NewDuplicatesModelFromGroups(groups, duplicates.DeleteOptions{})
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'0'}})
+	m := updated.(DuplicatesModel)
+	if m.statusMessage == "" || m.totalSelectedCount != 0 {
+		t.Errorf("Expected a hint when no keep rules are set, got status %q, %d selected", m.statusMessage, m.totalSelectedCount)
+	}
+
+	policy, err := duplicates.ParsePolicy([]string{"resolution:highest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ = m.WithKeepPolicy(policy).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'0'}})
+	m = updated.(DuplicatesModel)
+	if !m.groups[0].Selected[0] || m.groups[0].Selected[1] {
+		t.Errorf("Expected the 1080p file to be kept, got %v", m.groups[0].Selected)
+	}
+}
+
+func TestQualityStrategyLabelsKeptFile(t *testing.T) {
+	groups := []duplicates.DuplicateGroup{
+		{
+			Hash:     "ABC123",
+			Files:    []duplicates.FileMetadata{{Path: "a.mp4", Resolution: "1280x720"}, {Path: "b.mp4", Resolution: "1920x1080"}},
+			Selected: []bool{false, false},
+		},
+	}
+
+	model := NewDuplicatesModelFromGroups(groups, duplicates.DeleteOptions{})
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'9'}})
+	m := updated.(DuplicatesModel)
+	if cmd != nil || m.probing {
+		t.Fatal("Expected tagged files to need no probing")
+	}
+	if !m.groups[0].Selected[0] || m.groups[0].Selected[1] {
+		t.Errorf("Expected the 1080p file to be kept, got %v", m.groups[0].Selected)
+	}
+	if view := m.View(); !strings.Contains(view, "kept: highest resolution: 1920x1080 over 1280x720") {
+		t.Errorf("Expected the kept file to be labelled, got:\n%s", view)
+	}
+
+	// Changing the selection by hand drops the label
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = updated.(DuplicatesModel)
+	if strings.Contains(m.View(), "kept:") {
+		t.Error("Expected the label to be cleared with the selection")
+	}
+}
+
+func TestQualityStrategyProbesMissingMetadata(t *testing.T) {
+	dir := t.TempDir()
+	groups := []duplicates.DuplicateGroup{
+		{
+			Hash:     "ABC123",
+			Files:    []duplicates.FileMetadata{{Path: filepath.Join(dir, "a.mp4")}, {Path: filepath.Join(dir, "b.mp4"), Codec: "hevc"}},
+			Selected: []bool{false, false},
+		},
+	}
+
+	model := NewDuplicatesModelFromGroups(groups, duplicates.DeleteOptions{})
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	m := updated.(DuplicatesModel)
+	if cmd == nil || !m.probing {
+		t.Fatal("Expected a file without a codec to be probed")
+	}
+
+	// Keys other than ctrl+c wait for the probe
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = updated.(DuplicatesModel)
+	if m.totalSelectedCount != 0 {
+		t.Error("Expected input to be ignored while probing")
+	}
+
+	updated, _ = m.Update(cmd())
+	m = updated.(DuplicatesModel)
+	if m.probing || !m.groups[0].Files[0].Probed {
+		t.Errorf("Expected the probed metadata to be copied back, got %+v", m.groups[0].Files[0])
+	}
+	if !m.groups[0].Selected[0] || m.groups[0].Selected[1] {
+		t.Errorf("Expected the HEVC file to be kept, got %v", m.groups[0].Selected)
 	}
 }
@@ -17,6 +17,7 @@ type WorkerCompletedMsg struct {
 	WorkerID int
 	Filename string
 	NewName  string
+	Note     string // Shown instead of the new name for operations that don't rename
 	Success  bool
 	Error    error
 }
@@ -25,3 +26,6 @@ type OverallProgressMsg struct {
 	Completed int
 	Total     int
 }
+
+// AllDoneMsg tells the TUI that every worker has finished so it can exit
+type AllDoneMsg struct{}
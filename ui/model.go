@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lepinkainen/videotagger/utils"
 )
 
 // File log entry for the processed files list
@@ -15,6 +16,7 @@ type FileLogEntry struct {
 	NewName      string
 	Status       string // "✓", "❌", "🔄"
 	Error        string
+	Note         string // Result detail for operations that don't rename, e.g. "verified"
 }
 
 func (f FileLogEntry) FilterValue() string { return f.OriginalName }
@@ -26,6 +28,9 @@ func (f FileLogEntry) Description() string {
 	if f.NewName != "" {
 		return fmt.Sprintf("✓ → %s", f.NewName)
 	}
+	if f.Note != "" {
+		return fmt.Sprintf("%s %s", f.Status, f.Note)
+	}
 	return "🔄 Processing..."
 }
 
@@ -34,6 +39,8 @@ type WorkerState struct {
 	ID          int
 	CurrentFile string
 	Progress    float64
+	Bytes       int64
+	Total       int64
 	Status      string // "idle", "processing", "completed", "error"
 	Error       error
 }
@@ -58,12 +65,14 @@ type TUIModel struct {
 	// Control state
 	paused   bool
 	quitting bool
+	done     bool
 
 	// Version for display
 	Version string
 }
 
-// NewTUIModel creates a new TUI model
+// NewTUIModel creates a new TUI model.
+// Send AllDoneMsg when all work is finished to exit the program.
 func NewTUIModel(numFiles, numWorkers int, version string) TUIModel {
 	// Initialize progress bars
 	overallProg := progress.New(progress.WithDefaultGradient())
@@ -122,11 +131,16 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if worker, ok := m.workers[msg.WorkerID]; ok {
 			worker.CurrentFile = msg.Filename
 			worker.Status = "processing"
+			worker.Progress = 0
+			worker.Bytes = 0
+			worker.Total = 0
 		}
 
 	case WorkerProgressMsg:
 		if worker, ok := m.workers[msg.WorkerID]; ok {
 			worker.Progress = msg.Progress
+			worker.Bytes = msg.Bytes
+			worker.Total = msg.Total
 		}
 
 	case WorkerCompletedMsg:
@@ -141,10 +155,14 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			OriginalName: msg.Filename,
 			NewName:      msg.NewName,
 			Status:       "✓",
+			Note:         msg.Note,
 		}
 		if !msg.Success {
 			entry.Status = "❌"
-			entry.Error = msg.Error.Error()
+			entry.Error = "failed"
+			if msg.Error != nil {
+				entry.Error = msg.Error.Error()
+			}
 		}
 
 		m.fileEntries = append(m.fileEntries, entry)
@@ -156,6 +174,10 @@ func (m TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case OverallProgressMsg:
 		m.processedFiles = msg.Completed
+
+	case AllDoneMsg:
+		m.done = true
+		return m, tea.Quit
 	}
 
 	return m, nil
@@ -166,6 +188,9 @@ func (m TUIModel) View() string {
 	if m.quitting {
 		return "Shutting down...\n"
 	}
+	if m.done {
+		return ""
+	}
 
 	// Header
 	header := HeaderStyle.Render(fmt.Sprintf("VideoTagger %s", m.Version))
@@ -180,13 +205,17 @@ func (m TUIModel) View() string {
 		m.processedFiles,
 		m.totalFiles)
 
-	// Worker status
+	// Worker status, in worker order so lines don't jump between frames
 	workerViews := []string{"Worker Status:"}
-	for i, worker := range m.workers {
+	for i := range len(m.workers) {
+		worker := m.workers[i]
 		status := fmt.Sprintf("Worker %d: ", i+1)
 		if worker.Status == "processing" {
 			progBar := m.workerProgress[i].ViewAs(worker.Progress)
 			status += fmt.Sprintf("%s %s", progBar, worker.CurrentFile)
+			if worker.Total > 0 {
+				status += fmt.Sprintf(" (%s/%s)", utils.FormatBytes(worker.Bytes), utils.FormatBytes(worker.Total))
+			}
 		} else {
 			status += fmt.Sprintf("%-20s %s", worker.Status, worker.CurrentFile)
 		}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTUIModel_WorkerLifecycle(t *testing.T) {
+	model := NewTUIModel(2, 2, "test")
+
+	updated, _ := model.Update(WorkerStartedMsg{WorkerID: 1, Filename: "b.mp4"})
+	updated, _ = updated.Update(WorkerProgressMsg{WorkerID: 1, Progress: 0.5, Bytes: 512 * 1024, Total: 1024 * 1024})
+	m := updated.(TUIModel)
+
+	worker := m.workers[1]
+	if worker.Status != "processing" || worker.CurrentFile != "b.mp4" {
+		t.Errorf("Expected worker 1 processing b.mp4, got %+v", worker)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "(512.0 KB/1.0 MB)") {
+		t.Errorf("Expected byte progress in view, got:\n%s", view)
+	}
+	if strings.Index(view, "Worker 1:") > strings.Index(view, "Worker 2:") {
+		t.Error("Expected workers to be listed in order")
+	}
+
+	updated, _ = m.Update(WorkerCompletedMsg{WorkerID: 1, Filename: "b.mp4", Note: "verified 0D4A1185", Success: true})
+	updated, _ = updated.Update(WorkerCompletedMsg{WorkerID: 0, Filename: "a.mp4", Success: false, Error: errors.New("hash mismatch")})
+	m = updated.(TUIModel)
+
+	if len(m.fileEntries) != 2 {
+		t.Fatalf("Expected 2 file entries, got %d", len(m.fileEntries))
+	}
+	if got := m.fileEntries[0].Description(); got != "✓ verified 0D4A1185" {
+		t.Errorf("Unexpected description for verified file: %q", got)
+	}
+	if got := m.fileEntries[1].Description(); got != "❌ hash mismatch" {
+		t.Errorf("Unexpected description for failed file: %q", got)
+	}
+}
+
+func TestTUIModel_AllDone(t *testing.T) {
+	model := NewTUIModel(1, 1, "test")
+
+	updated, cmd := model.Update(AllDoneMsg{})
+	if cmd == nil {
+		t.Fatal("Expected quit command after AllDoneMsg")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected AllDoneMsg to quit the program")
+	}
+	if view := updated.View(); view != "" {
+		t.Errorf("Expected empty final view, got %q", view)
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var messages []WorkerProgressMsg
+	send := func(msg tea.Msg) {
+		messages = append(messages, msg.(WorkerProgressMsg))
+	}
+
+	w := NewProgressWriter(send, 3, 100)
+	for range 10 {
+		if _, err := w.Write(make([]byte, 10)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// The first write and the final one are always reported; the rest are throttled
+	if len(messages) < 2 || len(messages) > 10 {
+		t.Fatalf("Expected throttled updates, got %d", len(messages))
+	}
+
+	last := messages[len(messages)-1]
+	if last.WorkerID != 3 || last.Bytes != 100 || last.Total != 100 || last.Progress != 1.0 {
+		t.Errorf("Unexpected final progress message: %+v", last)
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// progressInterval limits how often a worker sends progress updates to the TUI
+const progressInterval = 100 * time.Millisecond
+
+// ProgressWriter is an io.Writer that counts bytes and reports them as WorkerProgressMsg updates.
+// Use it as an extra destination when hashing or copying a file.
+type ProgressWriter struct {
+	send     func(tea.Msg)
+	workerID int
+	total    int64
+	current  int64
+	lastSent time.Time
+}
+
+// NewProgressWriter creates a progress writer for a file of total bytes; send is usually tea.Program.Send
+func NewProgressWriter(send func(tea.Msg), workerID int, total int64) *ProgressWriter {
+	return &ProgressWriter{
+		send:     send,
+		workerID: workerID,
+		total:    total,
+	}
+}
+
+// Write implements io.Writer, sending at most one update per progressInterval plus one at completion
+func (w *ProgressWriter) Write(p []byte) (int, error) {
+	w.current += int64(len(p))
+
+	now := time.Now()
+	if now.Sub(w.lastSent) < progressInterval && w.current < w.total {
+		return len(p), nil
+	}
+	w.lastSent = now
+
+	progress := 1.0
+	if w.total > 0 {
+		progress = float64(w.current) / float64(w.total)
+	}
+	w.send(WorkerProgressMsg{
+		WorkerID: w.workerID,
+		Progress: progress,
+		Bytes:    w.current,
+		Total:    w.total,
+	})
+
+	return len(p), nil
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/lepinkainen/videotagger/stats"
+	"github.com/lepinkainen/videotagger/utils"
+)
+
+// RenderStatsReport renders a library statistics report as styled terminal tables
+func RenderStatsReport(report *stats.Report) string {
+	var content strings.Builder
+
+	content.WriteString(HeaderStyle.Render(fmt.Sprintf("📊 Library Statistics: %s", report.Root)))
+	content.WriteString("\n")
+
+	fmt.Fprintf(&content, "%s\n", InfoStyle.Render(fmt.Sprintf("Files: %d (%s)", report.TotalFiles, utils.FormatBytes(report.TotalBytes))))
+	fmt.Fprintf(&content, "%s\n", InfoStyle.Render(fmt.Sprintf("Tagged: %d, Untagged: %d", report.TaggedFiles, report.UntaggedFiles)))
+	fmt.Fprintf(&content, "%s\n", InfoStyle.Render(fmt.Sprintf("Duplicates: %d files in %d groups, %s reclaimable",
+		report.DuplicateFiles, report.DuplicateGroups, utils.FormatBytes(report.DuplicateWaste))))
+
+	for _, section := range report.Sections() {
+		content.WriteString("\n")
+		content.WriteString(renderBucketTable(section, report.TotalBytes))
+		content.WriteString("\n")
+	}
+
+	return content.String()
+}
+
+// renderBucketTable renders one report dimension as a bordered table
+func renderBucketTable(section stats.Section, totalBytes int64) string {
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true).Padding(0, 1)
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	numberStyle := cellStyle.Align(lipgloss.Right)
+
+	t := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("240"))).
+		Headers(section.Title, "Files", "Size", "Share").
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return headerStyle
+			case col == 0:
+				return cellStyle
+			default:
+				return numberStyle
+			}
+		})
+
+	for _, bucket := range section.Buckets {
+		t.Row(
+			bucket.Name,
+			fmt.Sprintf("%d", bucket.Files),
+			utils.FormatBytes(bucket.Bytes),
+			fmt.Sprintf("%.1f%%", stats.Share(bucket.Bytes, totalBytes)),
+		)
+	}
+
+	return t.Render()
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// MoveFile renames src to dst, copying across filesystems when a rename isn't possible.
+// It never replaces an existing dst, and fails with fs.ErrExist instead.
+func MoveFile(src, dst string) error {
+	err := renameNoReplace(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := CopyFile(src, dst); err != nil {
+		return fmt.Errorf("failed to copy across filesystems: %w", err)
+	}
+	return os.Remove(src)
+}
+
+// linkNoReplace moves src to dst by hardlinking it there and removing src, since creating a
+// link fails if dst exists. Where links aren't possible, such as for directories, it checks
+// for dst and renames, which can still race with another process creating dst.
+func linkNoReplace(src, dst string) error {
+	err := os.Link(src, dst)
+	switch {
+	case err == nil:
+		return os.Remove(src)
+	case errors.Is(err, fs.ErrExist), errors.Is(err, syscall.EXDEV):
+		return err
+	}
+
+	if _, err := os.Lstat(dst); err == nil {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: fs.ErrExist}
+	}
+	return os.Rename(src, dst)
+}
+
+// CopyFile copies contents, permissions and modification time, syncing before returning.
+// It refuses to overwrite an existing dst, and removes its partial copy if it fails.
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fi.Mode().Perm())
+	if err != nil {
+		return err // dst isn't ours to remove
+	}
+
+	// From here on dst is the partial copy this call created
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(dst, fi.ModTime(), fi.ModTime())
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return nil
+}
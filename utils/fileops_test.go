@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp4")
+	dst := filepath.Join(dir, "dst.mp4")
+	if err := os.WriteFile(src, []byte("contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile() error = %v", err)
+	}
+
+	fi, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if !fi.ModTime().Equal(modTime) {
+		t.Errorf("Expected modification time %v, got %v", modTime, fi.ModTime())
+	}
+	if err := CopyFile(src, dst); err == nil {
+		t.Error("copyFile must not overwrite an existing file")
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp4")
+	dst := filepath.Join(dir, "dst.mp4")
+	if err := os.WriteFile(src, []byte("contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveFile(src, dst); err != nil {
+		t.Fatalf("MoveFile() error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be gone, got %v", src, err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "contents" {
+		t.Errorf("Expected moved contents, got %q, %v", data, err)
+	}
+}
+
+func TestMoveFileKeepsExistingDestination(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp4")
+	dst := filepath.Join(dir, "dst.mp4")
+	if err := os.WriteFile(src, []byte("contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveFile(src, dst); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Expected fs.ErrExist, got %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "existing" {
+		t.Errorf("Expected %s to be left alone, got %q, %v", dst, data, err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("Expected %s to stay, got %v", src, err)
+	}
+}
+
+func TestCopyFileKeepsExistingDestination(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "dst.mp4")
+	if err := os.WriteFile(dst, []byte("existing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing source fails before dst is touched; an existing dst fails without removing it
+	if err := CopyFile(filepath.Join(dir, "missing.mp4"), dst); err == nil {
+		t.Error("Expected a missing source to fail")
+	}
+	if err := CopyFile(dst, dst); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Expected fs.ErrExist, got %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "existing" {
+		t.Errorf("Expected %s to be left alone, got %q, %v", dst, data, err)
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{100, "100 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{1048576, "1.0 MB"},
+		{1572864, "1.5 MB"},
+		{1073741824, "1.0 GB"},
+		{2147483648, "2.0 GB"},
+		{1649267441664, "1.50 TB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.expected {
+			t.Errorf("FormatBytes(%d) = %s, expected %s", tt.bytes, got, tt.expected)
+		}
+	}
+}
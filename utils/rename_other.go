@@ -0,0 +1,8 @@
+//go:build !linux
+
+package utils
+
+// renameNoReplace renames src to dst by linking, failing with fs.ErrExist if dst exists
+func renameNoReplace(src, dst string) error {
+	return linkNoReplace(src, dst)
+}
@@ -0,0 +1,111 @@
+package video
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"slices"
+)
+
+// sampleSize is how much of each end of a file the sampled hash reads
+const sampleSize = 64 << 10
+
+// FindDuplicatesByContent scans directories for video files, tagged or not, and groups them
+// by CRC32 of their contents. Reading every file is avoided in stages: only files sharing a
+// size are compared, then only those whose first and last 64 KiB also match are hashed in full.
+// Groups are keyed like FindDuplicatesByHash, so the results feed the same duplicate workflow.
+func FindDuplicatesByContent(directories ...string) (map[string][]string, error) {
+	files, err := findInRoots(directories, FindAllVideoFilesRecursively)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stage 1: files with a size no other file has can't be duplicates
+	bySize := make(map[int64][]string)
+	for _, path := range files {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Size() == 0 {
+			continue // Gone since the scan, or empty
+		}
+		bySize[fi.Size()] = append(bySize[fi.Size()], path)
+	}
+
+	// Sizes and hashes are visited in order so the same files always get the same keys
+	duplicates := make(map[string][]string)
+	for _, size := range slices.Sorted(maps.Keys(bySize)) {
+		paths := bySize[size]
+		if len(paths) < 2 {
+			continue
+		}
+
+		// Stage 2: compare the ends of the files, where differing downloads and encodes
+		// almost always differ
+		samples := groupBy(paths, func(path string) (uint32, error) {
+			return sampleHash(path, size)
+		})
+		for _, sample := range slices.Sorted(maps.Keys(samples)) {
+			// Stage 3: hash the remaining collisions in full
+			full := groupBy(samples[sample], CalculateCRC32)
+			for _, crc := range slices.Sorted(maps.Keys(full)) {
+				// The same CRC32 for different contents is a collision, not a duplicate; the
+				// smallest size keeps the plain key
+				key := fmt.Sprintf("%08X", crc)
+				if _, taken := duplicates[key]; taken {
+					key = fmt.Sprintf("%s-%d", key, size)
+				}
+				if _, taken := duplicates[key]; taken {
+					key = fmt.Sprintf("%s-%08X", key, sample)
+				}
+				duplicates[key] = full[crc]
+			}
+		}
+	}
+
+	return duplicates, nil
+}
+
+// groupBy groups paths by a hash of each file, keeping only groups of two or more.
+// Files that can't be read are left out.
+func groupBy(paths []string, hash func(path string) (uint32, error)) map[uint32][]string {
+	groups := make(map[uint32][]string)
+	for _, path := range paths {
+		sum, err := hash(path)
+		if err != nil {
+			continue
+		}
+		groups[sum] = append(groups[sum], path)
+	}
+
+	for sum, group := range groups {
+		if len(group) < 2 {
+			delete(groups, sum)
+		}
+	}
+	return groups
+}
+
+// sampleHash returns the CRC32 of the first and last sampleSize bytes of a file of the
+// given size; small files are read whole
+func sampleHash(path string, size int64) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := crc32.NewIEEE()
+	if size <= 2*sampleSize {
+		_, err := io.Copy(h, f)
+		return h.Sum32(), err
+	}
+
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, sampleSize)); err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(h, io.NewSectionReader(f, size-sampleSize, sampleSize)); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
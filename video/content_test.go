@@ -0,0 +1,135 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindDuplicatesByContent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	large := bytes.Repeat([]byte("frame data "), 30000) // Larger than both samples together
+	middleChanged := slices.Clone(large)
+	middleChanged[len(large)/2] ^= 0xFF
+
+	original := write("download.mp4", large)
+	copied := write("sorted/Movie (2020).mp4", large)
+	write("sorted/re-encode.mp4", middleChanged) // Same size and ends, different middle
+	write("other.mkv", large[:len(large)-1])     // Different size
+	write("notes.txt", large)                    // Not a video
+	write("empty1.mp4", nil)
+	write("empty2.mp4", nil)
+
+	duplicates, err := FindDuplicatesByContent(dir)
+	if err != nil {
+		t.Fatalf("FindDuplicatesByContent() error = %v", err)
+	}
+
+	if len(duplicates) != 1 {
+		t.Fatalf("Expected one duplicate group, got %v", duplicates)
+	}
+	for hash, paths := range duplicates {
+		crc, _ := CalculateCRC32(original)
+		if hash != formatTestCRC(crc) {
+			t.Errorf("Expected group keyed by CRC32 %s, got %s", formatTestCRC(crc), hash)
+		}
+		slices.Sort(paths)
+		if !slices.Equal(paths, []string{original, copied}) {
+			t.Errorf("Expected %v, got %v", []string{original, copied}, paths)
+		}
+	}
+}
+
+func TestFindDuplicatesByContentCollidingKeys(t *testing.T) {
+	dir := t.TempDir()
+	small := []byte("small clip contents")
+	large := forgeCRC32([]byte("a larger clip with other contents"), crc32.ChecksumIEEE(small))
+	for name, data := range map[string][]byte{"a1.mp4": small, "a2.mp4": small, "b1.mp4": large, "b2.mp4": large} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := formatTestCRC(crc32.ChecksumIEEE(small))
+	want := map[string]string{key: "a1.mp4", fmt.Sprintf("%s-%d", key, len(large)): "b1.mp4"}
+
+	// Map order changes between calls, so repeat to catch keys that depend on it
+	for range 20 {
+		duplicates, err := FindDuplicatesByContent(dir)
+		if err != nil {
+			t.Fatalf("FindDuplicatesByContent() error = %v", err)
+		}
+		if len(duplicates) != len(want) {
+			t.Fatalf("Expected %d groups, got %v", len(want), duplicates)
+		}
+		for key, first := range want {
+			paths := slices.Sorted(slices.Values(duplicates[key]))
+			if len(paths) != 2 || filepath.Base(paths[0]) != first {
+				t.Fatalf("Expected group %s to start with %s, got %v", key, first, duplicates)
+			}
+		}
+	}
+}
+
+// forgeCRC32 appends four bytes to data that give it the CRC32 target
+func forgeCRC32(data []byte, target uint32) []byte {
+	var index [256]byte
+	for i, v := range crc32.IEEETable {
+		index[v>>24] = byte(i)
+	}
+
+	// Run the table lookups backwards from the target register
+	reg := ^target
+	for range 4 {
+		i := index[reg>>24]
+		reg = (reg^crc32.IEEETable[i])<<8 | uint32(i)
+	}
+	return binary.LittleEndian.AppendUint32(slices.Clone(data), reg^^crc32.ChecksumIEEE(data))
+}
+
+func TestSampleHash(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte{1, 2, 3, 4}, 3*sampleSize/4*4)
+	changed := slices.Clone(data)
+	changed[len(data)/2] = 9
+
+	a := filepath.Join(dir, "a.mp4")
+	b := filepath.Join(dir, "b.mp4")
+	_ = os.WriteFile(a, data, 0644)
+	_ = os.WriteFile(b, changed, 0644)
+
+	hashA, err := sampleHash(a, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, _ := sampleHash(b, int64(len(data)))
+	if hashA != hashB {
+		t.Error("A change outside the sampled ends shouldn't change the sampled hash")
+	}
+
+	changed[0] = 9
+	_ = os.WriteFile(b, changed, 0644)
+	if hashB, _ = sampleHash(b, int64(len(data))); hashA == hashB {
+		t.Error("A change in the first bytes should change the sampled hash")
+	}
+}
+
+func formatTestCRC(crc uint32) string {
+	return fmt.Sprintf("%08X", crc)
+}
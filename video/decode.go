@@ -0,0 +1,227 @@
+package video
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DecodeProblem classifies what a full decode found wrong with a file
+type DecodeProblem string
+
+const (
+	// DecodeTruncated means the stream ends early: the decoded duration falls short of the
+	// declared duration or the demuxer hit an unexpected end of file
+	DecodeTruncated DecodeProblem = "truncated"
+	// DecodeCorrupt means the decoder reported damaged data within the stream
+	DecodeCorrupt DecodeProblem = "corrupt"
+)
+
+// maxDecodeErrors limits how many decoder messages are kept for a badly damaged file
+const maxDecodeErrors = 50
+
+// Truncation is reported when the decoded duration is short by more than the larger of
+// these, which leaves room for containers that round their declared duration
+const (
+	truncationMinGapSecs = 2.0
+	truncationTolerance  = 0.02
+)
+
+// truncationMarkers are demuxer messages that mean the file ends before its data does
+var truncationMarkers = []string{
+	"moov atom not found",
+	"partial file",
+	"truncat",
+	"ended prematurely",
+	"ends prematurely",
+	"unexpected end of file",
+}
+
+// decoderPrefixRegex matches the "[h264 @ 0x55d0c8a3c2c0] " prefix ffmpeg puts on component messages;
+// newer versions omit the address for some components
+var decoderPrefixRegex = regexp.MustCompile(`^\[([^\]@]+?)(?: @ 0x[0-9a-fA-F]+)?\]\s*`)
+
+// progressLineRegex matches the key=value lines written by ffmpeg -progress
+var progressLineRegex = regexp.MustCompile(`^([a-z0-9_]+)=(\S*)$`)
+
+// DecodeError is a message ffmpeg logged while decoding, with the approximate position
+// it was logged at (the last progress update before it)
+type DecodeError struct {
+	TimeSecs float64 `json:"timeSecs"`
+	Message  string  `json:"message"`
+}
+
+// DecodeResult contains the outcome of decoding a whole file
+type DecodeResult struct {
+	DeclaredDurationSecs float64       `json:"declaredDurationSecs"`
+	DecodedDurationSecs  float64       `json:"decodedDurationSecs"`
+	Problem              DecodeProblem `json:"problem,omitempty"`
+	Errors               []DecodeError `json:"errors,omitempty"`
+	ErrorCount           int           `json:"errorCount"` // Includes messages beyond the kept Errors
+
+	path string // The file decoded, whose name ffmpeg puts in front of some messages
+}
+
+// OK reports whether the file decoded without problems
+func (r *DecodeResult) OK() bool {
+	return r.Problem == ""
+}
+
+// DecodeCheck decodes every frame of a file with ffmpeg into the null muxer, which finds damage a
+// hash can't: files that were already broken when they were tagged. It extends the shallow
+// ValidateVideoIntegrity probe, reporting decoder errors with their positions and classifying
+// the file as truncated or corrupt. progress, if not nil, receives the decoded and declared
+// durations as decoding advances.
+// An error is returned only when the check itself couldn't run.
+func DecodeCheck(filePath string, progress func(decodedSecs, declaredSecs float64)) (*DecodeResult, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+
+	result := &DecodeResult{path: filePath}
+
+	// A file ffprobe can't open won't decode either; its message is enough to classify it.
+	// Unreadable files and unsupported codecs say nothing about the file's contents.
+	if err := ValidateVideoIntegrity(filePath); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, ErrUnreadable) || errors.Is(err, ErrUnsupportedCodec) {
+			return nil, err
+		}
+
+		var integrityErr *IntegrityError
+		if errors.As(err, &integrityErr) {
+			result.addError(0, integrityErr.Detail)
+		} else {
+			result.addError(0, err.Error())
+		}
+		result.classify()
+		if errors.Is(err, ErrTruncated) || errors.Is(err, ErrMissingIndex) {
+			result.Problem = DecodeTruncated
+		}
+		return result, nil
+	}
+
+	// An unknown declared duration only disables the duration comparison
+	if minutes, err := GetVideoDuration(filePath); err == nil {
+		result.DeclaredDurationSecs = minutes * 60
+	}
+
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-nostdin", "-v", "error",
+		"-progress", "pipe:2", "-nostats",
+		"-i", filePath, "-f", "null", "-")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	parseDecodeOutput(stderr, result, func(decoded float64) {
+		if progress != nil {
+			progress(decoded, result.DeclaredDurationSecs)
+		}
+	})
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || result.ErrorCount == 0 {
+			return nil, fmt.Errorf("ffmpeg decode failed: %w", err)
+		}
+		// A non-zero exit with decoder messages is a damaged file, classified below
+	}
+
+	result.classify()
+	return result, nil
+}
+
+// parseDecodeOutput reads ffmpeg's stderr, where -progress key=value blocks are interleaved with
+// error messages, recording the decoded duration and each message with the position before it
+func parseDecodeOutput(r io.Reader, result *DecodeResult, onProgress func(decodedSecs float64)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := progressLineRegex.FindStringSubmatch(line); m != nil {
+			switch m[1] {
+			case "out_time_us", "out_time_ms": // out_time_ms is also in microseconds
+				if us, err := strconv.ParseInt(m[2], 10, 64); err == nil && us >= 0 {
+					result.DecodedDurationSecs = float64(us) / 1e6
+				}
+			case "progress":
+				onProgress(result.DecodedDurationSecs)
+			}
+			continue
+		}
+
+		result.addError(result.DecodedDurationSecs, line)
+	}
+}
+
+// addError records a decoder message, keeping the first maxDecodeErrors of them
+func (r *DecodeResult) addError(timeSecs float64, message string) {
+	r.ErrorCount++
+	if len(r.Errors) >= maxDecodeErrors {
+		return
+	}
+
+	// "[h264 @ 0x55d0c8a3c2c0] error while decoding" becomes "h264: error while decoding"
+	if m := decoderPrefixRegex.FindStringSubmatch(message); m != nil {
+		message = strings.TrimSpace(m[1]) + ": " + message[len(m[0]):]
+	}
+
+	r.Errors = append(r.Errors, DecodeError{TimeSecs: timeSecs, Message: message})
+}
+
+// classify sets Problem from the durations and messages. Truncation wins over corruption
+// because a cut-off stream usually also makes the decoder complain about its last frames.
+func (r *DecodeResult) classify() {
+	gap := r.DeclaredDurationSecs - r.DecodedDurationSecs
+	shortDecode := r.DeclaredDurationSecs > 0 &&
+		gap > max(truncationMinGapSecs, r.DeclaredDurationSecs*truncationTolerance)
+
+	switch {
+	case shortDecode || r.hasTruncationMarker():
+		r.Problem = DecodeTruncated
+	case r.ErrorCount > 0:
+		r.Problem = DecodeCorrupt
+	default:
+		r.Problem = ""
+	}
+}
+
+// hasTruncationMarker reports whether any kept message says the data ends early
+func (r *DecodeResult) hasTruncationMarker() bool {
+	for _, e := range r.Errors {
+		message := strings.ToLower(messageText(r.path, e.Message))
+		for _, marker := range truncationMarkers {
+			if strings.Contains(message, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Summary describes the problem in one line for logs, e.g.
+// "truncated: decoded 612.0s of 1450.2s" or "corrupt: 14 decoder errors, first at 312.5s"
+func (r *DecodeResult) Summary() string {
+	switch {
+	case r.OK():
+		return "decoded cleanly"
+	case r.Problem == DecodeTruncated && r.DeclaredDurationSecs > 0:
+		return fmt.Sprintf("%s: decoded %.1fs of %.1fs", r.Problem, r.DecodedDurationSecs, r.DeclaredDurationSecs)
+	case len(r.Errors) > 0:
+		return fmt.Sprintf("%s: %d decoder errors, first at %.1fs: %s", r.Problem, r.ErrorCount, r.Errors[0].TimeSecs, r.Errors[0].Message)
+	default:
+		return string(r.Problem)
+	}
+}
@@ -0,0 +1,135 @@
+package video
+
+import (
+	"strings"
+	"testing"
+)
+
+// Output of "ffmpeg -v error -progress pipe:2 -nostats -i clip.mp4 -f null -" for a file with
+// damaged h264 slices in the middle
+const corruptDecodeOutput = `frame=120
+fps=0.00
+stream_0_0_q=-0.0
+bitrate=N/A
+total_size=N/A
+out_time_us=4004000
+out_time_ms=4004000
+out_time=00:00:04.004000
+dup_frames=0
+drop_frames=0
+speed=8.01x
+progress=continue
+[h264 @ 0x55d0c8a3c2c0] error while decoding MB 45 30, bytestream -7
+[h264 @ 0x55d0c8a3c2c0] concealing 1290 DC, 1290 AC, 1290 MV errors in P frame
+frame=300
+out_time_us=10010000
+out_time_ms=10010000
+out_time=00:00:10.010000
+progress=end
+`
+
+// Output for an mp4 cut off during download
+const truncatedDecodeOutput = `frame=60
+out_time_us=2002000
+progress=continue
+[mov,mp4,m4a,3gp,3g2,mj2 @ 0x5612f0d4b740] stream 0, offset 0x2f1a3c: partial file
+[in#0/mov,mp4,m4a,3gp,3g2,mj2 @ 0x5612f0d4a9c0] Error during demuxing: Invalid data found when processing input
+frame=75
+out_time_us=2502500
+out_time=N/A
+progress=end
+`
+
+func TestParseDecodeOutput(t *testing.T) {
+	result := &DecodeResult{}
+	var progressCalls []float64
+	parseDecodeOutput(strings.NewReader(corruptDecodeOutput), result, func(decoded float64) {
+		progressCalls = append(progressCalls, decoded)
+	})
+
+	if result.DecodedDurationSecs != 10.01 {
+		t.Errorf("Expected decoded duration 10.01s, got %v", result.DecodedDurationSecs)
+	}
+	if len(progressCalls) != 2 || progressCalls[0] != 4.004 {
+		t.Errorf("Expected progress at 4.004s and 10.01s, got %v", progressCalls)
+	}
+
+	if result.ErrorCount != 2 || len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 decoder errors, got %d (%v)", result.ErrorCount, result.Errors)
+	}
+	first := result.Errors[0]
+	if first.TimeSecs != 4.004 {
+		t.Errorf("Expected error at 4.004s, got %v", first.TimeSecs)
+	}
+	if first.Message != "h264: error while decoding MB 45 30, bytestream -7" {
+		t.Errorf("Expected decoder prefix to be simplified, got %q", first.Message)
+	}
+}
+
+func TestDecodeResultClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		declared float64
+		expected DecodeProblem
+	}{
+		{"Clean decode", "out_time_us=600000000\nprogress=end\n", 600.2, ""},
+		{"No declared duration", "out_time_us=5000000\nprogress=end\n", 0, ""},
+		{"Bitstream errors", corruptDecodeOutput, 10.0, DecodeCorrupt},
+		{"Partial file message", truncatedDecodeOutput, 0, DecodeTruncated},
+		{"Short decode without errors", "out_time_us=300000000\nprogress=end\n", 600, DecodeTruncated},
+		{"Short decode within tolerance", "out_time_us=598500000\nprogress=end\n", 600, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &DecodeResult{DeclaredDurationSecs: tt.declared}
+			parseDecodeOutput(strings.NewReader(tt.output), result, func(float64) {})
+			result.classify()
+
+			if result.Problem != tt.expected {
+				t.Errorf("Expected problem %q, got %q (%s)", tt.expected, result.Problem, result.Summary())
+			}
+			if result.OK() != (tt.expected == "") {
+				t.Errorf("OK() = %v for problem %q", result.OK(), result.Problem)
+			}
+		})
+	}
+}
+
+func TestDecodeResult_TruncationMarkerInPath(t *testing.T) {
+	path := "/videos/Show.truncated.mkv"
+	result := &DecodeResult{path: path}
+	result.addError(0, path+": Invalid data found when processing input")
+	result.classify()
+
+	if result.Problem != DecodeCorrupt {
+		t.Errorf("Expected a marker only in the path to be ignored, got %q", result.Problem)
+	}
+}
+
+func TestDecodeResult_ErrorLimit(t *testing.T) {
+	result := &DecodeResult{}
+	for range maxDecodeErrors + 10 {
+		result.addError(1, "[hevc @ 0x1] Could not find ref with POC 12")
+	}
+
+	if result.ErrorCount != maxDecodeErrors+10 {
+		t.Errorf("Expected every error to be counted, got %d", result.ErrorCount)
+	}
+	if len(result.Errors) != maxDecodeErrors {
+		t.Errorf("Expected %d kept errors, got %d", maxDecodeErrors, len(result.Errors))
+	}
+}
+
+func TestDecodeResult_Summary(t *testing.T) {
+	truncated := &DecodeResult{DeclaredDurationSecs: 1450.2, DecodedDurationSecs: 612, Problem: DecodeTruncated}
+	if got := truncated.Summary(); got != "truncated: decoded 612.0s of 1450.2s" {
+		t.Errorf("Unexpected truncated summary %q", got)
+	}
+
+	corrupt := &DecodeResult{Problem: DecodeCorrupt, ErrorCount: 14, Errors: []DecodeError{{TimeSecs: 312.5, Message: "h264: error while decoding MB 1 2"}}}
+	if got := corrupt.Summary(); got != "corrupt: 14 decoder errors, first at 312.5s: h264: error while decoding MB 1 2" {
+		t.Errorf("Unexpected corrupt summary %q", got)
+	}
+}
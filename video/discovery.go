@@ -26,10 +26,27 @@ func FindVideoFilesRecursively(directory string) ([]string, error) {
 	return files, err
 }
 
-// FindDuplicatesByHash scans a directory for video files and groups them by CRC32 hash
-func FindDuplicatesByHash(directory string) (map[string][]string, error) {
-	hashToFiles := make(map[string][]string)
+// FindAllVideoFilesRecursively scans a directory for all video files, tagged or not
+func FindAllVideoFilesRecursively(directory string) ([]string, error) {
+	var files []string
+	var err error
+
+	// Use fd if available for better performance, otherwise fall back to filepath.WalkDir
+	if isFdAvailable() {
+		files, err = findAllVideoFilesWithFd(directory)
+		if err != nil {
+			// If fd fails, fall back to the standard method
+			files, err = findAllVideoFilesWithWalkDir(directory)
+		}
+	} else {
+		files, err = findAllVideoFilesWithWalkDir(directory)
+	}
+
+	return files, err
+}
 
+// FindTaggedFilesRecursively scans a directory for tagged video files (those with a hash in the name)
+func FindTaggedFilesRecursively(directory string) ([]string, error) {
 	var files []string
 	var err error
 
@@ -44,6 +61,14 @@ func FindDuplicatesByHash(directory string) (map[string][]string, error) {
 		files, err = findTaggedFilesWithWalkDir(directory)
 	}
 
+	return files, err
+}
+
+// FindDuplicatesByHash scans directories for video files and groups them by CRC32 hash
+func FindDuplicatesByHash(directories ...string) (map[string][]string, error) {
+	hashToFiles := make(map[string][]string)
+
+	files, err := findInRoots(directories, FindTaggedFilesRecursively)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +91,48 @@ func FindDuplicatesByHash(directory string) (map[string][]string, error) {
 	return duplicates, nil
 }
 
+// findInRoots runs find on each directory in turn. A file reachable from several
+// overlapping roots, such as a directory and one of its subdirectories or the same
+// directory through a symlink, is listed once.
+func findInRoots(directories []string, find func(string) ([]string, error)) ([]string, error) {
+	var files []string
+	seen := make(map[string]struct{})
+
+	for _, directory := range directories {
+		found, err := find(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range found {
+			key := resolvedPath(path)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			files = append(files, path)
+		}
+	}
+
+	return files, nil
+}
+
+// resolvedPath returns a path's absolute form with symlinks in its directory evaluated.
+// The file itself isn't resolved: a symlinked file is a separate entry from its target.
+func resolvedPath(path string) string {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return filepath.Join(dir, name)
+}
+
 // isFdAvailable checks if the 'fd' command is available in PATH
 func isFdAvailable() bool {
 	_, err := exec.LookPath("fd")
@@ -126,6 +193,46 @@ func findUnprocessedFilesWithWalkDir(directory string) ([]string, error) {
 	return files, err
 }
 
+// findAllVideoFilesWithWalkDir uses filepath.WalkDir to find every video file
+func findAllVideoFilesWithWalkDir(directory string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(directory, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && IsVideoFile(path) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// findAllVideoFilesWithFd uses the 'fd' command to efficiently find every video file
+func findAllVideoFilesWithFd(directory string) ([]string, error) {
+	extPattern := "\\." + strings.Join(VideoExtensionsNoDot(), "|\\.")
+
+	cmd := exec.Command("fd", extPattern, "--type", "f", "--case-sensitive", "false", directory)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	var files []string
+	for _, line := range lines {
+		if line != "" && IsVideoFile(line) {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
 // findUnprocessedFilesWithFd uses the 'fd' command to efficiently find unprocessed video files
 func findUnprocessedFilesWithFd(directory string) ([]string, error) {
 	// Find all video files and filter out processed ones
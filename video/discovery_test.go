@@ -175,6 +175,66 @@ func TestFindDuplicatesByHash_ProcessedFilesWithDuplicates(t *testing.T) {
 	}
 }
 
+func TestFindDuplicatesByHash_MultipleRoots(t *testing.T) {
+	archive := t.TempDir()
+	incoming := t.TempDir()
+	nested := filepath.Join(archive, "nested")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{
+		filepath.Join(archive, "video_[1920x1080][45min][DEADBEEF].mp4"),
+		filepath.Join(nested, "video_[1920x1080][45min][DEADBEEF].mp4"),
+		filepath.Join(incoming, "download_[1920x1080][45min][DEADBEEF].mp4"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("test content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The nested root overlaps the archive root, so its file must not be listed twice
+	duplicates, err := FindDuplicatesByHash(archive, incoming, nested)
+	if err != nil {
+		t.Fatalf("FindDuplicatesByHash() error = %v", err)
+	}
+	if got := duplicates["DEADBEEF"]; len(got) != 3 {
+		t.Errorf("Expected 3 files across roots, got %d: %v", len(got), got)
+	}
+}
+
+func TestFindDuplicatesByHash_SymlinkedRoot(t *testing.T) {
+	archive := t.TempDir()
+	nested := filepath.Join(archive, "nested")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "archive")
+	if err := os.Symlink(archive, link); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{
+		filepath.Join(archive, "video_[1920x1080][45min][DEADBEEF].mp4"),
+		filepath.Join(nested, "video_[1920x1080][45min][DEADBEEF].mp4"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("test content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The second root reaches the nested file again through the symlink
+	duplicates, err := FindDuplicatesByHash(archive, filepath.Join(link, "nested"))
+	if err != nil {
+		t.Fatalf("FindDuplicatesByHash() error = %v", err)
+	}
+	if got := duplicates["DEADBEEF"]; len(got) != 2 {
+		t.Errorf("Expected each file once, got %d: %v", len(got), got)
+	}
+}
+
 func TestFindDuplicatesByHash_MixedProcessedUnprocessed(t *testing.T) {
 	// Test FindDuplicatesByHash with mix of processed and unprocessed files
 	testDir := t.TempDir()
@@ -563,3 +623,77 @@ func TestFindUnprocessedFilesWithFd(t *testing.T) {
 		t.Errorf("Expected to find both unprocessed files, got: %v", files)
 	}
 }
+
+func TestFindAllVideoFilesWithWalkDir(t *testing.T) {
+	testDir := t.TempDir()
+
+	testFiles := []string{
+		"video1.mp4",
+		"subfolder/video2.MKV",
+		"tagged_[1920x1080][45min][12345678].mp4",
+		"document.txt",
+	}
+
+	for _, file := range testFiles {
+		fullPath := filepath.Join(testDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", fullPath, err)
+		}
+	}
+
+	files, err := findAllVideoFilesWithWalkDir(testDir)
+	if err != nil {
+		t.Fatalf("findAllVideoFilesWithWalkDir() error = %v", err)
+	}
+
+	// Both tagged and untagged videos are returned, non-videos are not
+	if len(files) != 3 {
+		t.Errorf("Expected 3 files, got %d: %v", len(files), files)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, ".txt") {
+			t.Errorf("Non-video file should not be returned: %s", file)
+		}
+	}
+}
+
+func TestFindTaggedFilesRecursively(t *testing.T) {
+	testDir := t.TempDir()
+
+	testFiles := []string{
+		"untagged.mp4",
+		"a/tagged_[1920x1080][45min][12345678].mp4",
+		"a/b/deep_[1280x720][10min][ABCDEF12].mkv",
+		"notes_[1920x1080][45min][12345678].txt",
+	}
+
+	for _, file := range testFiles {
+		fullPath := filepath.Join(testDir, file)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("test content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", fullPath, err)
+		}
+	}
+
+	files, err := FindTaggedFilesRecursively(testDir)
+	if err != nil {
+		t.Fatalf("FindTaggedFilesRecursively() error = %v", err)
+	}
+
+	// Only tagged videos are returned, including those in nested directories
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files, got %d: %v", len(files), files)
+	}
+
+	for _, file := range files {
+		if !IsProcessed(filepath.Base(file)) || !IsVideoFile(file) {
+			t.Errorf("Unexpected file returned: %s", file)
+		}
+	}
+}
@@ -28,17 +28,17 @@ func DefaultReencodeOptions() *ReencodeOptions {
 
 // ReencodeResult holds the results of a re-encoding operation
 type ReencodeResult struct {
-	OriginalPath   string
-	OriginalCodec  string
-	OriginalSize   int64
-	NewPath        string
-	NewSize        int64
-	SizeSavings    int64
-	SavingsPercent float64
-	WasReencoded   bool
-	WasSkipped     bool
-	SkipReason     string
-	Error          error
+	OriginalPath   string  `json:"originalPath"`
+	OriginalCodec  string  `json:"originalCodec,omitempty"`
+	OriginalSize   int64   `json:"originalSize"`
+	NewPath        string  `json:"newPath,omitempty"`
+	NewSize        int64   `json:"newSize"`
+	SizeSavings    int64   `json:"sizeSavings"`
+	SavingsPercent float64 `json:"savingsPercent"`
+	WasReencoded   bool    `json:"wasReencoded"`
+	WasSkipped     bool    `json:"wasSkipped"`
+	SkipReason     string  `json:"skipReason,omitempty"`
+	Error          error   `json:"-"`
 }
 
 // IsH265 checks if a video file is already encoded with H.265/HEVC
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/corona10/goimagehash"
 )
@@ -29,6 +30,50 @@ func CalculateCRC32(filename string) (uint32, error) {
 	return h.Sum32(), nil
 }
 
+// VerifyFile recalculates the CRC32 of a tagged video and compares it with the hash in its filename
+func VerifyFile(videoFile string) *VerifyResult {
+	return VerifyFileWithProgress(videoFile, nil)
+}
+
+// VerifyFileWithProgress is VerifyFile that also writes the hashed bytes to progressWriter, if not nil
+func VerifyFileWithProgress(videoFile string, progressWriter io.Writer) *VerifyResult {
+	result := &VerifyResult{Path: videoFile}
+
+	if !IsVideoFile(videoFile) {
+		result.WasSkipped = true
+		result.SkipReason = "not a video file"
+		return result
+	}
+
+	expectedHash, ok := ExtractHashFromFilename(filepath.Base(videoFile))
+	if !ok {
+		result.WasSkipped = true
+		result.SkipReason = "no hash in filename"
+		return result
+	}
+
+	return VerifyHash(videoFile, expectedHash, progressWriter)
+}
+
+// VerifyHash recalculates the CRC32 of any file and compares it with expectedHash (8 hex digits).
+// Hashed bytes are also written to progressWriter, if not nil.
+func VerifyHash(path, expectedHash string, progressWriter io.Writer) *VerifyResult {
+	result := &VerifyResult{
+		Path:         path,
+		ExpectedHash: strings.ToUpper(expectedHash),
+	}
+
+	actualHash, err := calculateFileHash(path, progressWriter)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	result.ActualHash = fmt.Sprintf("%08X", actualHash)
+	result.Verified = result.ActualHash == result.ExpectedHash
+	return result
+}
+
 // CalculateVideoPerceptualHash extracts a frame from video and calculates perceptual hash
 func CalculateVideoPerceptualHash(videoFile string) (*goimagehash.ImageHash, error) {
 	// Create temporary file for extracted frame
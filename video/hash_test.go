@@ -1,6 +1,7 @@
 package video
 
 import (
+	"fmt"
 	"hash/crc32"
 	"os"
 	"path/filepath"
@@ -308,3 +309,53 @@ func TestCalculateCRC32_Sensitivity(t *testing.T) {
 		t.Errorf("CRC32 values should be different for different content: both got %08X", crc1)
 	}
 }
+
+func TestVerifyFile(t *testing.T) {
+	testDir := t.TempDir()
+	content := []byte("hello world")
+	crc := crc32.ChecksumIEEE(content)
+
+	writeFile := func(name string) string {
+		path := filepath.Join(testDir, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name             string
+		file             string
+		expectVerified   bool
+		expectSkipped    bool
+		expectMismatch   bool
+		expectActualHash string
+	}{
+		{"Matching hash", writeFile(fmt.Sprintf("match_[1920x1080][1min][%08X].mp4", crc)), true, false, false, fmt.Sprintf("%08X", crc)},
+		{"Lowercase hash", writeFile(fmt.Sprintf("lower_[1920x1080][1min][%08x].mp4", crc)), true, false, false, fmt.Sprintf("%08X", crc)},
+		{"Mismatched hash", writeFile("bad_[1920x1080][1min][12345678].mp4"), false, false, true, fmt.Sprintf("%08X", crc)},
+		{"Untagged file", writeFile("plain.mp4"), false, true, false, ""},
+		{"Not a video", writeFile("notes_[1920x1080][1min][12345678].txt"), false, true, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := VerifyFile(tt.file)
+			if result.Error != nil {
+				t.Fatalf("Unexpected error: %v", result.Error)
+			}
+			if result.Verified != tt.expectVerified {
+				t.Errorf("Expected Verified=%v, got %v", tt.expectVerified, result.Verified)
+			}
+			if result.WasSkipped != tt.expectSkipped {
+				t.Errorf("Expected WasSkipped=%v, got %v", tt.expectSkipped, result.WasSkipped)
+			}
+			if result.Mismatch() != tt.expectMismatch {
+				t.Errorf("Expected Mismatch()=%v, got %v", tt.expectMismatch, result.Mismatch())
+			}
+			if result.ActualHash != tt.expectActualHash {
+				t.Errorf("Expected ActualHash=%q, got %q", tt.expectActualHash, result.ActualHash)
+			}
+		})
+	}
+}
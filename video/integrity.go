@@ -0,0 +1,133 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Integrity problems found by ValidateVideoIntegrity, for use with errors.Is
+var (
+	// ErrMissingIndex means the container index is missing, typically an mp4 whose moov atom
+	// was never written because the download or recording stopped early
+	ErrMissingIndex = errors.New("missing index")
+	// ErrTruncated means the file ends before the data it declares
+	ErrTruncated = errors.New("truncated")
+	// ErrInvalidData means the demuxer found data it can't parse
+	ErrInvalidData = errors.New("invalid data")
+	// ErrUnsupportedCodec means the file may be intact but this ffmpeg build can't handle its codec
+	ErrUnsupportedCodec = errors.New("unsupported codec")
+	// ErrUnreadable means the file couldn't be read at all because of permissions or I/O errors
+	ErrUnreadable = errors.New("unreadable")
+)
+
+// IntegrityError describes why a file failed the integrity check. Kind is one of the
+// sentinel errors above; Err is the underlying cause, such as an *fs.PathError.
+type IntegrityError struct {
+	Path   string
+	Kind   error
+	Detail string // First relevant line of ffprobe's output
+	Err    error
+}
+
+func (e *IntegrityError) Error() string {
+	switch {
+	case e.Detail != "":
+		return fmt.Sprintf("%s: %s", e.Kind, e.Detail)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	default:
+		return e.Kind.Error()
+	}
+}
+
+// Unwrap makes both the kind and the cause visible to errors.Is and errors.As
+func (e *IntegrityError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// ErrorClass names the problem for logs and machine-readable output
+func (e *IntegrityError) ErrorClass() string {
+	switch e.Kind {
+	case ErrMissingIndex:
+		return "missing_index"
+	case ErrTruncated:
+		return "truncated"
+	case ErrInvalidData:
+		return "invalid_data"
+	case ErrUnsupportedCodec:
+		return "unsupported_codec"
+	case ErrUnreadable:
+		if errors.Is(e.Err, fs.ErrPermission) {
+			return "permission"
+		}
+		return "io"
+	default:
+		return "error"
+	}
+}
+
+// IsDamaged reports whether err says the file itself is damaged, as opposed to
+// unreadable or in a format this ffmpeg build doesn't support
+func IsDamaged(err error) bool {
+	return errors.Is(err, ErrMissingIndex) || errors.Is(err, ErrTruncated) || errors.Is(err, ErrInvalidData)
+}
+
+// probeMarker maps a phrase in ffprobe's output to the problem it indicates
+type probeMarker struct {
+	phrase string
+	kind   error
+	cause  error
+}
+
+// probeMarkers are checked in order, so the specific messages come before the generic
+// "Invalid data found when processing input" that ffprobe adds after most of them
+var probeMarkers = []probeMarker{
+	{"Permission denied", ErrUnreadable, fs.ErrPermission},
+	{"Input/output error", ErrUnreadable, nil},
+	{"moov atom not found", ErrMissingIndex, nil},
+	{"partial file", ErrTruncated, nil},
+	{"ended prematurely", ErrTruncated, nil},
+	{"truncated", ErrTruncated, nil},
+	{"Unsupported codec", ErrUnsupportedCodec, nil},
+	{"Decoder not found", ErrUnsupportedCodec, nil},
+	{"codec not currently supported", ErrUnsupportedCodec, nil},
+	{"Invalid data found", ErrInvalidData, nil},
+	{"corrupt", ErrInvalidData, nil},
+	{"Invalid argument", ErrInvalidData, nil},
+}
+
+// classifyProbeOutput turns ffprobe's error output into an *IntegrityError, or returns
+// nil when none of the known problems is mentioned
+func classifyProbeOutput(path, output string) *IntegrityError {
+	for _, marker := range probeMarkers {
+		for line := range strings.Lines(output) {
+			if strings.Contains(messageText(path, line), marker.phrase) {
+				return &IntegrityError{
+					Path:   path,
+					Kind:   marker.kind,
+					Detail: strings.TrimSpace(line),
+					Err:    marker.cause,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// messageText returns what an ffprobe or ffmpeg stderr line says, without the "path: " or
+// "[component @ 0x…] " prefix, so words in the file's name aren't taken for a message
+func messageText(path, line string) string {
+	line = strings.TrimSpace(line)
+	if rest, ok := strings.CutPrefix(line, path+": "); ok {
+		return rest
+	}
+	if prefix := decoderPrefixRegex.FindString(line); prefix != "" {
+		return line[len(prefix):]
+	}
+	return line
+}
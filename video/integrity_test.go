@@ -0,0 +1,195 @@
+package video
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/lepinkainen/videotagger/logging"
+)
+
+// Output of "ffprobe -v error -show_entries format=duration ..." for files with known problems
+
+// An mp4 whose download stopped before the moov atom at its end was written
+const moovMissingProbeOutput = `[mov,mp4,m4a,3gp,3g2,mj2 @ 0x55f1c0e3a2c0] moov atom not found
+/videos/clip.mp4: Invalid data found when processing input
+`
+
+// An mp4 with the moov atom at the start, cut off part way through its media data
+const partialFileProbeOutput = `[mov,mp4,m4a,3gp,3g2,mj2 @ 0x5612f0d4b740] stream 0, offset 0x2f1a3c: partial file
+/videos/clip.mp4: Invalid data found when processing input
+`
+
+// A Matroska file cut off in its first cluster
+const matroskaTruncatedProbeOutput = `[matroska,webm @ 0x7f8b5c004a00] File ended prematurely at pos. 1048576 (0x100000)
+/videos/clip.mkv: End of file
+`
+
+// A file of random bytes with a video extension
+const invalidDataProbeOutput = `/videos/clip.avi: Invalid data found when processing input
+`
+
+// A stream whose codec this ffmpeg build was compiled without
+const unsupportedCodecProbeOutput = `[matroska,webm @ 0x5581d2f6c5c0] Unsupported codec with id 0 for input stream 0
+/videos/clip.mkv: Invalid data found when processing input
+`
+
+// A file the user isn't allowed to read
+const permissionProbeOutput = `/videos/clip.mp4: Permission denied
+`
+
+// A file on a failing disk
+const ioErrorProbeOutput = `/videos/clip.mp4: Input/output error
+`
+
+func TestClassifyProbeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		kind   error
+		class  string
+		detail string
+	}{
+		{"Missing moov atom", moovMissingProbeOutput, ErrMissingIndex, "missing_index", "[mov,mp4,m4a,3gp,3g2,mj2 @ 0x55f1c0e3a2c0] moov atom not found"},
+		{"Partial mp4", partialFileProbeOutput, ErrTruncated, "truncated", "[mov,mp4,m4a,3gp,3g2,mj2 @ 0x5612f0d4b740] stream 0, offset 0x2f1a3c: partial file"},
+		{"Truncated matroska", matroskaTruncatedProbeOutput, ErrTruncated, "truncated", "[matroska,webm @ 0x7f8b5c004a00] File ended prematurely at pos. 1048576 (0x100000)"},
+		{"Invalid data", invalidDataProbeOutput, ErrInvalidData, "invalid_data", "/videos/clip.avi: Invalid data found when processing input"},
+		{"Unsupported codec", unsupportedCodecProbeOutput, ErrUnsupportedCodec, "unsupported_codec", "[matroska,webm @ 0x5581d2f6c5c0] Unsupported codec with id 0 for input stream 0"},
+		{"Permission denied", permissionProbeOutput, ErrUnreadable, "permission", "/videos/clip.mp4: Permission denied"},
+		{"I/O error", ioErrorProbeOutput, ErrUnreadable, "io", "/videos/clip.mp4: Input/output error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integrityErr := classifyProbeOutput("/videos/clip", tt.output)
+			if integrityErr == nil {
+				t.Fatal("Expected the output to be classified")
+			}
+
+			var err error = integrityErr
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("Expected errors.Is(err, %v), got %v", tt.kind, err)
+			}
+			if integrityErr.Detail != tt.detail {
+				t.Errorf("Expected detail %q, got %q", tt.detail, integrityErr.Detail)
+			}
+			if got := logging.ClassifyError(err); got != tt.class {
+				t.Errorf("Expected class %q, got %q", tt.class, got)
+			}
+		})
+	}
+}
+
+func TestClassifyProbeOutput_MarkerInPath(t *testing.T) {
+	path := "/videos/Permission denied/Show.truncated.corrupt-rip.mp4"
+	output := path + ": Invalid data found when processing input\n"
+
+	integrityErr := classifyProbeOutput(path, output)
+	if integrityErr == nil {
+		t.Fatal("Expected the output to be classified")
+	}
+	if !errors.Is(integrityErr, ErrInvalidData) {
+		t.Errorf("Expected ErrInvalidData, not a marker from the path, got %v", integrityErr.Kind)
+	}
+
+	if err := classifyProbeOutput(path, path+": End of file\n"); err != nil {
+		t.Errorf("Expected a marker only in the path to be ignored, got %v", err)
+	}
+}
+
+func TestClassifyProbeOutput_Unknown(t *testing.T) {
+	if err := classifyProbeOutput("/videos/clip.mp4", "something unexpected happened\n"); err != nil {
+		t.Errorf("Expected unknown output to stay unclassified, got %v", err)
+	}
+}
+
+func TestIntegrityError_Unwrap(t *testing.T) {
+	var err error = &IntegrityError{
+		Path: "/videos/clip.mp4",
+		Kind: ErrUnreadable,
+		Err:  &fs.PathError{Op: "stat", Path: "/videos/clip.mp4", Err: fs.ErrPermission},
+	}
+	wrapped := errors.Join(errors.New("context"), err)
+
+	if !errors.Is(wrapped, ErrUnreadable) || !errors.Is(wrapped, fs.ErrPermission) {
+		t.Error("Expected both the kind and the cause to match through wrapping")
+	}
+
+	var pathErr *fs.PathError
+	if !errors.As(wrapped, &pathErr) {
+		t.Error("Expected the cause to be reachable with errors.As")
+	}
+
+	var integrityErr *IntegrityError
+	if !errors.As(wrapped, &integrityErr) || integrityErr.Path != "/videos/clip.mp4" {
+		t.Error("Expected errors.As to find the IntegrityError")
+	}
+}
+
+func TestIsDamaged(t *testing.T) {
+	tests := []struct {
+		kind     error
+		expected bool
+	}{
+		{ErrMissingIndex, true},
+		{ErrTruncated, true},
+		{ErrInvalidData, true},
+		{ErrUnsupportedCodec, false},
+		{ErrUnreadable, false},
+	}
+
+	for _, tt := range tests {
+		err := &IntegrityError{Kind: tt.kind}
+		if got := IsDamaged(err); got != tt.expected {
+			t.Errorf("IsDamaged(%v) = %v, expected %v", tt.kind, got, tt.expected)
+		}
+	}
+	if IsDamaged(errors.New("ffprobe error")) {
+		t.Error("Unclassified errors are not damage")
+	}
+}
+
+// TestValidateVideoIntegrity_CapturedOutput runs ValidateVideoIntegrity against a stand-in
+// ffprobe that prints captured output, checking the error returned to callers
+func TestValidateVideoIntegrity_CapturedOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Stand-in ffprobe is a shell script")
+	}
+
+	binDir := t.TempDir()
+	script := "#!/bin/sh\ncat \"$PROBE_OUTPUT\" >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(binDir, "ffprobe"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write stand-in ffprobe: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		t.Skipf("Stand-in ffprobe not runnable: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output.txt")
+	if err := os.WriteFile(outputFile, []byte(moovMissingProbeOutput), 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	t.Setenv("PROBE_OUTPUT", outputFile)
+
+	video := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(video, []byte("not really a video"), 0644); err != nil {
+		t.Fatalf("Failed to write video: %v", err)
+	}
+
+	err := ValidateVideoIntegrity(video)
+	if !errors.Is(err, ErrMissingIndex) {
+		t.Fatalf("Expected ErrMissingIndex, got %v", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Error("Expected the ffprobe exit status to be kept as the cause")
+	}
+	if !IsDamaged(err) {
+		t.Error("A missing index is damage")
+	}
+}
@@ -1,6 +1,7 @@
 package video
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -66,6 +67,106 @@ func GetVideoCodec(videoFile string) (string, error) {
 	return codec, nil
 }
 
+// ProbeInfo holds the stream and container details reported by a single ffprobe call
+type ProbeInfo struct {
+	Resolution   string
+	Codec        string
+	DurationMins float64
+	FrameRate    float64 // Average frames per second, 0 if unknown
+	BitRate      int64   // Video stream bits per second, or the container's when the stream has none
+}
+
+// ProbeVideo extracts resolution, codec, duration, frame rate and bit rate with one ffprobe invocation
+func ProbeVideo(videoFile string) (*ProbeInfo, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-select_streams", "v:0",
+		"-show_entries", "stream=width,height,codec_name,avg_frame_rate,bit_rate:format=duration,bit_rate", "-of", "json", "--", videoFile)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+
+	return parseProbeOutput(output)
+}
+
+// parseProbeOutput decodes the JSON emitted by ProbeVideo's ffprobe call
+func parseProbeOutput(output []byte) (*ProbeInfo, error) {
+	var probe struct {
+		Streams []struct {
+			Width        int    `json:"width"`
+			Height       int    `json:"height"`
+			CodecName    string `json:"codec_name"`
+			AvgFrameRate string `json:"avg_frame_rate"`
+			BitRate      string `json:"bit_rate"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	if len(probe.Streams) == 0 {
+		return nil, fmt.Errorf("no video stream found")
+	}
+
+	info := &ProbeInfo{Codec: probe.Streams[0].CodecName}
+	if probe.Streams[0].Width > 0 && probe.Streams[0].Height > 0 {
+		info.Resolution = fmt.Sprintf("%dx%d", probe.Streams[0].Width, probe.Streams[0].Height)
+	}
+
+	if probe.Format.Duration != "" {
+		durationSecs, err := strconv.ParseFloat(probe.Format.Duration, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		info.DurationMins = durationSecs / 60
+	}
+
+	info.FrameRate = parseFrameRate(probe.Streams[0].AvgFrameRate)
+	for _, bitRate := range []string{probe.Streams[0].BitRate, probe.Format.BitRate} {
+		if rate, err := strconv.ParseInt(bitRate, 10, 64); err == nil && rate > 0 {
+			info.BitRate = rate
+			break
+		}
+	}
+
+	return info, nil
+}
+
+// parseFrameRate parses an ffprobe rate such as "30000/1001", returning 0 if it can't
+func parseFrameRate(rate string) float64 {
+	num, den, found := strings.Cut(rate, "/")
+	numerator, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if !found {
+		return numerator
+	}
+	denominator, err := strconv.ParseFloat(den, 64)
+	if err != nil || denominator == 0 {
+		return 0
+	}
+	return numerator / denominator
+}
+
+// BitsPerPixel returns the average bits spent on each pixel of each frame, a rough measure of
+// encoding quality that doesn't depend on resolution or length. It is 0 if unknown.
+func (p *ProbeInfo) BitsPerPixel() float64 {
+	width, height, ok := strings.Cut(p.Resolution, "x")
+	if !ok || p.FrameRate <= 0 || p.BitRate <= 0 {
+		return 0
+	}
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w == 0 || h == 0 {
+		return 0
+	}
+	return float64(p.BitRate) / (float64(w) * float64(h) * p.FrameRate)
+}
+
 // GetFileSize returns the size of a file in bytes
 func GetFileSize(filePath string) (int64, error) {
 	fi, err := os.Stat(filePath)
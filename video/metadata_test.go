@@ -447,3 +447,66 @@ func TestGetFileSizeZeroFile(t *testing.T) {
 		t.Errorf("Expected size 0 for empty file, got %d", size)
 	}
 }
+
+func TestParseProbeOutput(t *testing.T) {
+	output := []byte(`{
+		"streams": [{"codec_name": "h264", "width": 1920, "height": 1080}],
+		"format": {"duration": "2700.500000"}
+	}`)
+
+	info, err := parseProbeOutput(output)
+	if err != nil {
+		t.Fatalf("parseProbeOutput() error = %v", err)
+	}
+
+	if info.Resolution != "1920x1080" {
+		t.Errorf("Expected resolution 1920x1080, got %s", info.Resolution)
+	}
+	if info.Codec != "h264" {
+		t.Errorf("Expected codec h264, got %s", info.Codec)
+	}
+	if info.DurationMins < 45 || info.DurationMins > 45.01 {
+		t.Errorf("Expected duration ~45 minutes, got %f", info.DurationMins)
+	}
+}
+
+func TestParseProbeOutput_BitsPerPixel(t *testing.T) {
+	output := []byte(`{
+		"streams": [{"codec_name": "hevc", "width": 1920, "height": 1080, "avg_frame_rate": "30000/1001"}],
+		"format": {"duration": "60.0", "bit_rate": "6220800"}
+	}`)
+
+	info, err := parseProbeOutput(output)
+	if err != nil {
+		t.Fatalf("parseProbeOutput() error = %v", err)
+	}
+
+	if info.FrameRate < 29.97 || info.FrameRate > 29.98 {
+		t.Errorf("Expected frame rate ~29.97, got %f", info.FrameRate)
+	}
+	if info.BitRate != 6220800 {
+		t.Errorf("Expected the container bit rate when the stream has none, got %d", info.BitRate)
+	}
+	if bpp := info.BitsPerPixel(); bpp < 0.1 || bpp > 0.1001 {
+		t.Errorf("Expected ~0.1 bits per pixel, got %f", bpp)
+	}
+
+	info.FrameRate = 0
+	if bpp := info.BitsPerPixel(); bpp != 0 {
+		t.Errorf("Expected 0 bits per pixel without a frame rate, got %f", bpp)
+	}
+}
+
+func TestParseProbeOutput_NoVideoStream(t *testing.T) {
+	_, err := parseProbeOutput([]byte(`{"streams": [], "format": {"duration": "10.0"}}`))
+	if err == nil {
+		t.Error("Expected error when no video stream is present")
+	}
+}
+
+func TestProbeVideoNonExistentFile(t *testing.T) {
+	_, err := ProbeVideo("/path/to/nonexistent/video.mp4")
+	if err == nil {
+		t.Error("Expected error for non-existent file")
+	}
+}
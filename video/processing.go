@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/lepinkainen/videotagger/logging"
+	"github.com/lepinkainen/videotagger/parity"
+	"github.com/lepinkainen/videotagger/utils"
 )
 
 // validateVideoFile performs all file validation checks and returns structured results
@@ -74,13 +79,26 @@ func generateTaggedFilename(originalPath string, metadata *VideoMetadata, crc ui
 	return fmt.Sprintf("%s_[%s][%.0fmin][%08X]%s", baseName, metadata.Resolution, metadata.DurationMins, crc, ext)
 }
 
-// renameVideoFile performs the actual file rename operation
+// renameVideoFile performs the actual file rename operation. A parity file next to the video
+// is renamed with it, so parity repair can still find the file it protects.
 func renameVideoFile(oldPath, newPath string) error {
-	return os.Rename(oldPath, newPath)
+	oldParity, newParity := parity.Path(oldPath), parity.Path(newPath)
+	if _, err := os.Lstat(oldParity); err != nil {
+		return os.Rename(oldPath, newPath)
+	}
+
+	if err := utils.MoveFile(oldParity, newParity); err != nil {
+		return fmt.Errorf("failed to rename parity file: %w", err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		_ = utils.MoveFile(newParity, oldParity)
+		return err
+	}
+	return nil
 }
 
 // processVideoFileCore handles the core logic of processing a video file without side effects
-func processVideoFileCore(videoFile string, progressWriter io.Writer) *ProcessingResult {
+func processVideoFileCore(videoFile string, progressWriter io.Writer, opts ProcessOptions) *ProcessingResult {
 	result := &ProcessingResult{
 		OriginalPath: videoFile,
 	}
@@ -115,7 +133,11 @@ func processVideoFileCore(videoFile string, progressWriter io.Writer) *Processin
 
 	// Validate video file integrity before processing
 	if integrityErr := ValidateVideoIntegrity(videoFile); integrityErr != nil {
-		result.Error = fmt.Errorf("video integrity check failed: %w", integrityErr)
+		if IsDamaged(integrityErr) {
+			result.Error = fmt.Errorf("video is damaged: %w", integrityErr)
+		} else {
+			result.Error = fmt.Errorf("video integrity check failed: %w", integrityErr)
+		}
 		return result
 	}
 
@@ -127,16 +149,21 @@ func processVideoFileCore(videoFile string, progressWriter io.Writer) *Processin
 	}
 	result.Metadata = metadata
 
-	// Calculate file hash with optional progress tracking
-	crc, err := calculateFileHash(videoFile, progressWriter)
-	if err != nil {
-		result.Error = err
-		return result
+	// Use a trusted checksum if one is known for this exact size, otherwise hash with optional progress tracking
+	if crc, ok := knownCRC(opts, videoFile, validationResult.FileInfo.Size()); ok {
+		result.CRC32 = crc
+		result.CRCSource = CRCSourceSFV
+	} else {
+		crc, err := calculateFileHash(videoFile, progressWriter)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		result.CRC32 = crc
 	}
-	result.CRC32 = crc
 
 	// Generate new filename
-	newFilename := generateTaggedFilename(videoFile, metadata, crc)
+	newFilename := generateTaggedFilename(videoFile, metadata, result.CRC32)
 	result.NewPath = newFilename
 
 	// Attempt to rename the file
@@ -150,56 +177,92 @@ func processVideoFileCore(videoFile string, progressWriter io.Writer) *Processin
 }
 
 // ProcessVideoFile handles the processing of a single video file with console output
-func ProcessVideoFile(videoFile string) {
+// and returns the result so callers can decide on an exit status
+func ProcessVideoFile(videoFile string) *ProcessingResult {
+	return ProcessVideoFileWithLogger(videoFile, logging.ForOp("tag"))
+}
+
+// knownCRC consults opts.KnownCRC, if set
+func knownCRC(opts ProcessOptions, videoFile string, size int64) (uint32, bool) {
+	if opts.KnownCRC == nil {
+		return 0, false
+	}
+	return opts.KnownCRC(videoFile, size)
+}
+
+// ProcessVideoFileWithLogger processes a single video file, reporting events through logger,
+// and returns the result. A progress bar is drawn only when the terminal renderer is active.
+func ProcessVideoFileWithLogger(videoFile string, logger *slog.Logger) *ProcessingResult {
+	return ProcessVideoFileWithOptions(videoFile, logger, ProcessOptions{})
+}
+
+// ProcessVideoFileWithOptions is ProcessVideoFileWithLogger with optional tagging behaviour
+func ProcessVideoFileWithOptions(videoFile string, logger *slog.Logger, opts ProcessOptions) *ProcessingResult {
+	start := time.Now()
+
 	// Get file info upfront for progress tracking
 	fileInfo, err := os.Stat(videoFile)
 	if err != nil {
-		fmt.Printf("%s\n", errorStyle.Render(fmt.Sprintf("❌ Error accessing %s: %v", videoFile, err)))
-		return
+		logging.Failure(logger, "Error accessing file", videoFile, err)
+		return &ProcessingResult{OriginalPath: videoFile, Error: err}
 	}
-	fileSize := fileInfo.Size()
 
-	// Create a custom progress bar with lipgloss styling
-	prog := progress.New(progress.WithDefaultGradient())
-	fmt.Printf("%s\n", processingStyle.Render(fmt.Sprintf("📊 Processing: %s", videoFile)))
+	logging.Start(logger, "Processing", videoFile)
 
-	// Create a progress writer for visual feedback
-	progressWriter := &progressWriter{
-		total: fileSize,
-		prog:  prog,
-		done:  make(chan bool),
+	// Create a progress writer for visual feedback on the terminal
+	var pw *progressWriter
+	var progressOut io.Writer
+	if logging.Interactive() && !fileInfo.IsDir() {
+		pw = newProgressWriter(fileInfo.Size(), progress.New(progress.WithDefaultGradient()))
+		progressOut = pw
+		go pw.render()
 	}
-	go progressWriter.render()
 
 	// Process the file with progress tracking
-	result := processVideoFileCore(videoFile, progressWriter)
-	progressWriter.done <- true
+	result := processVideoFileCore(videoFile, progressOut, opts)
+	if pw != nil {
+		pw.stop()
+	}
 
 	// Safety check for nil result
 	if result == nil {
-		fmt.Printf("❌ Error: processVideoFileCore returned nil for %s\n", videoFile)
-		return
+		err := fmt.Errorf("processVideoFileCore returned nil")
+		logging.Failure(logger, "Error processing", videoFile, err)
+		return &ProcessingResult{OriginalPath: videoFile, Error: err}
 	}
 
-	// Handle the result with appropriate console output
+	// Handle the result with appropriate output
 	if result.Error != nil {
-		fmt.Printf("%s\n", errorStyle.Render(fmt.Sprintf("❌ Error processing %s: %v", videoFile, result.Error)))
-		return
+		msg := "Error processing"
+		if IsDamaged(result.Error) {
+			msg = "Damaged video"
+		}
+		logging.Failure(logger, msg, videoFile, result.Error, logging.KeyDuration, time.Since(start))
+		return result
 	}
 
 	if result.WasSkipped {
 		switch result.SkipReason {
-		case "is a directory":
-			fmt.Printf("%s is a directory.\n", videoFile)
-		case "is not a video file":
-			fmt.Printf("%s is not a video file, skipping\n", videoFile)
 		case "already processed":
-			// Silently skip already processed files
+			// Already processed files are only interesting when debugging
+			logger.Debug("Already processed", logging.KeyPath, videoFile, logging.KeyStatus, logging.StatusSkipped)
+		default:
+			logging.Skipped(logger, "Skipping", videoFile, "reason", result.SkipReason)
 		}
-		return
+		return result
 	}
 
 	if result.WasRenamed {
-		fmt.Printf("\n%s\n", successStyle.Render(fmt.Sprintf("✅ %s", filepath.Base(result.NewPath))))
+		attrs := []any{
+			"original", videoFile,
+			"crc32", fmt.Sprintf("%08X", result.CRC32),
+			logging.KeyDuration, time.Since(start),
+		}
+		if result.CRCSource != "" {
+			attrs = append(attrs, "crc_source", result.CRCSource)
+		}
+		logging.Success(logger, "Tagged", result.NewPath, attrs...)
 	}
+
+	return result
 }
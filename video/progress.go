@@ -5,15 +5,30 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // progressWriter wraps progress bar for io.Writer interface
 type progressWriter struct {
-	total   int64
-	current int64
-	prog    progress.Model
-	done    chan bool
+	total    int64
+	current  int64
+	prog     progress.Model
+	done     chan bool
+	finished chan struct{}
+}
+
+func newProgressWriter(total int64, prog progress.Model) *progressWriter {
+	return &progressWriter{
+		total:    total,
+		prog:     prog,
+		done:     make(chan bool),
+		finished: make(chan struct{}),
+	}
+}
+
+// stop ends rendering and waits until the final bar and line break are printed
+func (pw *progressWriter) stop() {
+	pw.done <- true
+	<-pw.finished
 }
 
 func (pw *progressWriter) Write(p []byte) (int, error) {
@@ -25,12 +40,13 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 func (pw *progressWriter) render() {
 	ticker := time.NewTicker(50 * time.Millisecond)
 	defer ticker.Stop()
+	defer close(pw.finished)
 
 	for {
 		select {
 		case <-pw.done:
-			// Show 100% progress and clear the line for success message
-			fmt.Printf("\r%s", pw.prog.ViewAs(1.0))
+			// Show 100% progress and end the line for the result message
+			fmt.Printf("\r%s\n", pw.prog.ViewAs(1.0))
 			return
 		case <-ticker.C:
 			if pw.current > 0 {
@@ -40,18 +56,3 @@ func (pw *progressWriter) render() {
 		}
 	}
 }
-
-// Styling definitions
-var (
-	processingStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("39")).
-			Bold(true)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Bold(true)
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("46")).
-			Bold(true)
-)
@@ -0,0 +1,304 @@
+package video
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RepairStep is one recovery method tried by RepairVideo. Every step copies the streams
+// without re-encoding, so a repair never loses quality.
+type RepairStep string
+
+const (
+	// RepairRemux copies the streams into the same container, skipping damaged packets.
+	// For mp4 and mov this also writes a fresh index at the start of the file.
+	RepairRemux RepairStep = "remux"
+	// RepairReindex is RepairRemux with timestamps regenerated, for files whose damage
+	// left packets out of order or without timestamps
+	RepairReindex RepairStep = "reindex"
+	// RepairMatroska copies the streams into Matroska, which tolerates gaps and damaged
+	// packets that other containers reject
+	RepairMatroska RepairStep = "matroska"
+)
+
+// repairSuffix marks a repaired copy written next to the original
+const repairSuffix = ".repaired"
+
+// RepairOptions controls what happens to the original once a repair succeeds
+type RepairOptions struct {
+	Replace      bool // Replace the original with the repaired file
+	KeepOriginal bool // With Replace, keep the original as .bak
+}
+
+// RepairAttempt records the outcome of one recovery step
+type RepairAttempt struct {
+	Step  RepairStep `json:"step"`
+	Error string     `json:"error,omitempty"`
+}
+
+// RepairResult contains the outcome of repairing one file
+type RepairResult struct {
+	OriginalPath         string          `json:"originalPath"`
+	RepairedPath         string          `json:"repairedPath,omitempty"`
+	BackupPath           string          `json:"backupPath,omitempty"`
+	Step                 RepairStep      `json:"step,omitempty"` // The step that produced the repaired file
+	Attempts             []RepairAttempt `json:"attempts,omitempty"`
+	Problem              DecodeProblem   `json:"problem,omitempty"`              // What the check before repairing found
+	DeclaredDurationSecs float64         `json:"declaredDurationSecs,omitempty"` // 0 when unknown
+	SalvagedDurationSecs float64         `json:"salvagedDurationSecs,omitempty"`
+	WasRepaired          bool            `json:"wasRepaired"`
+	Replaced             bool            `json:"replaced"`
+	WasSkipped           bool            `json:"wasSkipped"`
+	SkipReason           string          `json:"skipReason,omitempty"`
+	Error                error           `json:"-"`
+}
+
+// SalvagedRatio returns the share of the declared duration the repaired file holds,
+// or 0 when the declared duration is unknown
+func (r *RepairResult) SalvagedRatio() float64 {
+	if r.DeclaredDurationSecs <= 0 {
+		return 0
+	}
+	return min(r.SalvagedDurationSecs/r.DeclaredDurationSecs, 1)
+}
+
+// RepairSteps returns the recovery steps to try for a file, in order
+func RepairSteps(path string) []RepairStep {
+	if isMatroska(path) {
+		// A same-container remux already is a Matroska remux
+		return []RepairStep{RepairRemux, RepairReindex}
+	}
+	return []RepairStep{RepairRemux, RepairReindex, RepairMatroska}
+}
+
+// RepairVideo decodes the file with DecodeCheck and skips it when it decodes cleanly.
+// A damaged file gets each recovery step in turn, writing the result into a new file next
+// to the original, and keeps the first result that passes ValidateVideoIntegrity.
+// The original is only touched when opts.Replace is set.
+func RepairVideo(path string, opts RepairOptions) *RepairResult {
+	result := &RepairResult{OriginalPath: path}
+
+	if _, err := os.Stat(path); err != nil {
+		result.Error = err
+		return result
+	}
+
+	// Remuxing an intact file gains nothing, and replacing it would drop its tags
+	check, err := DecodeCheck(path, nil)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to check the file before repairing it: %w", err)
+		return result
+	}
+	if check.OK() {
+		result.WasSkipped = true
+		result.SkipReason = "decoded cleanly, nothing to repair"
+		return result
+	}
+	result.Problem = check.Problem
+
+	result.DeclaredDurationSecs = declaredDurationSecs(path)
+
+	for _, step := range RepairSteps(path) {
+		output := repairOutputPath(path, step)
+		salvaged, err := runRepairStep(step, path, output)
+		if err != nil {
+			result.Attempts = append(result.Attempts, RepairAttempt{Step: step, Error: err.Error()})
+			if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrExist) {
+				result.Error = err
+				return result
+			}
+			continue
+		}
+
+		result.Attempts = append(result.Attempts, RepairAttempt{Step: step})
+		result.Step = step
+		result.RepairedPath = output
+		result.SalvagedDurationSecs = salvaged
+		result.WasRepaired = true
+		break
+	}
+
+	if !result.WasRepaired {
+		result.Error = errors.New("no recovery step produced a playable file")
+		if errors.Is(ValidateVideoIntegrity(path), ErrMissingIndex) {
+			// ffmpeg needs the index to find the streams at all
+			result.Error = errors.New("no recovery step produced a playable file: the index is missing, " +
+				"which can only be rebuilt from an intact reference file recorded by the same device")
+		}
+		return result
+	}
+
+	if opts.Replace {
+		if err := replaceWithRepaired(result, opts.KeepOriginal); err != nil {
+			result.Error = fmt.Errorf("repaired into %s but failed to replace the original: %w", result.RepairedPath, err)
+		}
+	}
+
+	return result
+}
+
+// declaredDurationSecs returns the duration the damaged file claims, falling back to the
+// filename tag when the container can't be probed, or 0 when neither is known
+func declaredDurationSecs(path string) float64 {
+	if minutes, err := GetVideoDuration(path); err == nil && minutes > 0 {
+		return minutes * 60
+	}
+	if _, minutes, _, ok := ExtractMetadataFromFilename(filepath.Base(path)); ok {
+		return float64(minutes) * 60
+	}
+	return 0
+}
+
+// runRepairStep writes output with one recovery step and validates it, returning the
+// duration of the repaired file. A failed output is removed.
+func runRepairStep(step RepairStep, input, output string) (float64, error) {
+	if _, err := os.Stat(output); err == nil {
+		return 0, fmt.Errorf("output of an earlier repair is in the way, remove it first: %s: %w", output, fs.ErrExist)
+	}
+
+	cmd := exec.Command("ffmpeg", repairArgs(step, input, output)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(output)
+		if errors.Is(err, exec.ErrNotFound) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("ffmpeg failed: %w: %s", err, extractFirstLine(string(out)))
+	}
+
+	if err := ValidateVideoIntegrity(output); err != nil {
+		_ = os.Remove(output)
+		return 0, fmt.Errorf("repaired file is still damaged: %w", err)
+	}
+
+	minutes, err := GetVideoDuration(output)
+	if err != nil || minutes <= 0 {
+		_ = os.Remove(output)
+		return 0, errors.New("repaired file has no playable content")
+	}
+
+	return minutes * 60, nil
+}
+
+// repairArgs builds the ffmpeg arguments for a recovery step
+func repairArgs(step RepairStep, input, output string) []string {
+	inputFlags := "+discardcorrupt"
+	if step == RepairReindex {
+		inputFlags = "+discardcorrupt+genpts+igndts"
+	}
+
+	args := []string{"-hide_banner", "-nostdin", "-v", "error",
+		"-err_detect", "ignore_err", "-fflags", inputFlags,
+		"-i", input,
+		"-map", "0:v?", "-map", "0:a?"}
+
+	matroska := step == RepairMatroska || isMatroska(output)
+	if matroska {
+		// Only Matroska accepts every subtitle format as a stream copy
+		args = append(args, "-map", "0:s?")
+	}
+
+	args = append(args, "-c", "copy", "-ignore_unknown")
+
+	if step == RepairReindex {
+		args = append(args, "-avoid_negative_ts", "make_zero")
+	}
+	if isMP4Family(output) {
+		args = append(args, "-movflags", "+faststart")
+	}
+	if step == RepairMatroska {
+		args = append(args, "-f", "matroska")
+	}
+
+	// -n: never overwrite an existing file
+	return append(args, "-n", output)
+}
+
+// repairOutputPath returns where a recovery step writes its result: the original name
+// without its tags, so a repaired file isn't mistaken for the tagged original, plus
+// ".repaired" and the extension of the step's container
+func repairOutputPath(path string, step RepairStep) string {
+	base, ext := untaggedBase(path)
+	if step == RepairMatroska {
+		ext = ".mkv"
+	}
+	return base + repairSuffix + ext
+}
+
+// IsRepairedCopy reports whether path is a file written by RepairVideo
+func IsRepairedCopy(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), repairSuffix)
+}
+
+// untaggedBase splits path into the name without extension and filename tags, and the extension
+func untaggedBase(path string) (string, string) {
+	ext := filepath.Ext(path)
+	if loc := wasProcessedRegex.FindStringIndex(path); loc != nil {
+		return path[:loc[0]], ext
+	}
+	return strings.TrimSuffix(path, ext), ext
+}
+
+// replaceWithRepaired moves the repaired file into the original's place. The tags are
+// dropped from the name, since the repaired contents no longer match the tagged hash.
+func replaceWithRepaired(result *RepairResult, keepOriginal bool) error {
+	base, _ := untaggedBase(result.OriginalPath)
+	target := base + filepath.Ext(result.RepairedPath)
+
+	if target != result.OriginalPath {
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s: %w", target, fs.ErrExist)
+		}
+	}
+
+	if keepOriginal {
+		backup := result.OriginalPath + ".bak"
+		if _, err := os.Stat(backup); err == nil {
+			return fmt.Errorf("%s: %w", backup, fs.ErrExist)
+		}
+		if err := os.Rename(result.OriginalPath, backup); err != nil {
+			return fmt.Errorf("failed to back up original: %w", err)
+		}
+		if err := os.Rename(result.RepairedPath, target); err != nil {
+			_ = os.Rename(backup, result.OriginalPath)
+			return err
+		}
+		result.BackupPath = backup
+	} else {
+		if err := os.Rename(result.RepairedPath, target); err != nil {
+			return err
+		}
+		if target != result.OriginalPath {
+			if err := os.Remove(result.OriginalPath); err != nil {
+				return fmt.Errorf("repaired file is at %s but the original couldn't be removed: %w", target, err)
+			}
+		}
+	}
+
+	result.RepairedPath = target
+	result.Replaced = true
+	return nil
+}
+
+// isMatroska reports whether path has a Matroska or WebM extension
+func isMatroska(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mkv", ".webm":
+		return true
+	}
+	return false
+}
+
+// isMP4Family reports whether path uses the ISO media container, whose index ffmpeg can
+// write at the start of the file
+func isMP4Family(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".m4v", ".mov":
+		return true
+	}
+	return false
+}
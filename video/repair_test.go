@@ -0,0 +1,241 @@
+package video
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRepairSteps(t *testing.T) {
+	if got := RepairSteps("/videos/clip.mp4"); !slices.Equal(got, []RepairStep{RepairRemux, RepairReindex, RepairMatroska}) {
+		t.Errorf("Unexpected steps for mp4: %v", got)
+	}
+	if got := RepairSteps("/videos/clip.MKV"); slices.Contains(got, RepairMatroska) {
+		t.Errorf("Matroska files shouldn't get a separate Matroska remux: %v", got)
+	}
+}
+
+func TestRepairOutputPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		step     RepairStep
+		expected string
+	}{
+		{"/videos/clip.mp4", RepairRemux, "/videos/clip.repaired.mp4"},
+		{"/videos/clip.mp4", RepairMatroska, "/videos/clip.repaired.mkv"},
+		{"/videos/clip_[1920x1080][45min][ABCD1234].mp4", RepairReindex, "/videos/clip.repaired.mp4"},
+		{"/videos/clip.mkv", RepairRemux, "/videos/clip.repaired.mkv"},
+	}
+
+	for _, tt := range tests {
+		if got := repairOutputPath(tt.path, tt.step); got != tt.expected {
+			t.Errorf("repairOutputPath(%q, %s) = %q, expected %q", tt.path, tt.step, got, tt.expected)
+		}
+		if !IsRepairedCopy(tt.expected) {
+			t.Errorf("Expected %q to be recognised as a repaired copy", tt.expected)
+		}
+	}
+
+	if IsRepairedCopy("/videos/clip.mp4") {
+		t.Error("An ordinary file is not a repaired copy")
+	}
+}
+
+func TestRepairArgs(t *testing.T) {
+	args := strings.Join(repairArgs(RepairRemux, "in.mp4", "out.mp4"), " ")
+	for _, expected := range []string{"-err_detect ignore_err", "-fflags +discardcorrupt", "-c copy", "-movflags +faststart", "-n out.mp4"} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("Expected remux args to contain %q: %s", expected, args)
+		}
+	}
+	if strings.Contains(args, "0:s?") {
+		t.Errorf("Subtitles can't always be copied into mp4: %s", args)
+	}
+
+	args = strings.Join(repairArgs(RepairReindex, "in.mkv", "out.mkv"), " ")
+	for _, expected := range []string{"+genpts+igndts", "-avoid_negative_ts make_zero", "-map 0:s?"} {
+		if !strings.Contains(args, expected) {
+			t.Errorf("Expected reindex args to contain %q: %s", expected, args)
+		}
+	}
+	if strings.Contains(args, "faststart") {
+		t.Errorf("faststart only applies to mp4: %s", args)
+	}
+
+	args = strings.Join(repairArgs(RepairMatroska, "in.avi", "out.mkv"), " ")
+	if !strings.Contains(args, "-f matroska") {
+		t.Errorf("Expected Matroska output format: %s", args)
+	}
+}
+
+func TestRepairResult_SalvagedRatio(t *testing.T) {
+	r := &RepairResult{SalvagedDurationSecs: 450}
+	if r.SalvagedRatio() != 0 {
+		t.Error("Unknown declared duration should give 0")
+	}
+
+	r.DeclaredDurationSecs = 600
+	if r.SalvagedRatio() != 0.75 {
+		t.Errorf("Expected 0.75, got %v", r.SalvagedRatio())
+	}
+
+	// Remuxing can make the container a little longer than it claimed
+	r.SalvagedDurationSecs = 601
+	if r.SalvagedRatio() != 1 {
+		t.Errorf("Expected ratio to be capped at 1, got %v", r.SalvagedRatio())
+	}
+}
+
+func TestReplaceWithRepaired(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "clip_[1920x1080][45min][ABCD1234].mp4")
+	repaired := filepath.Join(dir, "clip.repaired.mkv")
+	writeTestFile(t, original, "damaged")
+	writeTestFile(t, repaired, "repaired")
+
+	result := &RepairResult{OriginalPath: original, RepairedPath: repaired}
+	if err := replaceWithRepaired(result, true); err != nil {
+		t.Fatalf("replaceWithRepaired() error = %v", err)
+	}
+
+	expected := filepath.Join(dir, "clip.mkv")
+	if result.RepairedPath != expected || !result.Replaced {
+		t.Errorf("Expected repaired file at %q, got %+v", expected, result)
+	}
+	if content, _ := os.ReadFile(expected); string(content) != "repaired" {
+		t.Errorf("Unexpected repaired content %q", content)
+	}
+	if content, _ := os.ReadFile(original + ".bak"); string(content) != "damaged" {
+		t.Errorf("Expected original kept as .bak, got %q", content)
+	}
+	if _, err := os.Stat(original); !os.IsNotExist(err) {
+		t.Error("Original should have been moved aside")
+	}
+}
+
+func TestReplaceWithRepaired_SameName(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "clip.mp4")
+	repaired := filepath.Join(dir, "clip.repaired.mp4")
+	writeTestFile(t, original, "damaged")
+	writeTestFile(t, repaired, "repaired")
+
+	result := &RepairResult{OriginalPath: original, RepairedPath: repaired}
+	if err := replaceWithRepaired(result, false); err != nil {
+		t.Fatalf("replaceWithRepaired() error = %v", err)
+	}
+
+	if content, _ := os.ReadFile(original); string(content) != "repaired" {
+		t.Errorf("Expected original to hold the repaired content, got %q", content)
+	}
+	if _, err := os.Stat(repaired); !os.IsNotExist(err) {
+		t.Error("Repaired copy should have been moved")
+	}
+}
+
+// TestRepairVideo runs a repair against stand-in ffmpeg and ffprobe binaries: ffmpeg fails the
+// first step and copies the input on the second, ffprobe reports the original as 600s long
+// and anything repaired as 450s long
+func TestRepairVideo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Stand-in tools are shell scripts")
+	}
+
+	binDir := t.TempDir()
+	ffmpeg := `#!/bin/sh
+prev=""
+for arg; do
+	[ "$prev" = "-i" ] && in="$arg"
+	prev="$arg"; out="$arg"
+done
+case "$*" in *genpts*) ;; *) echo "Invalid data found when processing input" >&2; exit 1;; esac
+cat "$in" > "$out"
+`
+	ffprobe := `#!/bin/sh
+for arg; do in="$arg"; done
+case "$in" in *.repaired.*) echo 450.0;; *) echo 600.0;; esac
+`
+	writeTestScript(t, filepath.Join(binDir, "ffmpeg"), ffmpeg)
+	writeTestScript(t, filepath.Join(binDir, "ffprobe"), ffprobe)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	writeTestFile(t, path, "damaged")
+
+	result := RepairVideo(path, RepairOptions{})
+	if result.Error != nil {
+		t.Fatalf("RepairVideo() error = %v", result.Error)
+	}
+
+	if result.Step != RepairReindex || len(result.Attempts) != 2 || result.Attempts[0].Error == "" {
+		t.Errorf("Expected remux to fail and reindex to succeed, got %+v", result.Attempts)
+	}
+	if result.DeclaredDurationSecs != 600 || result.SalvagedDurationSecs != 450 {
+		t.Errorf("Expected 450s salvaged of 600s, got %v of %v", result.SalvagedDurationSecs, result.DeclaredDurationSecs)
+	}
+	if result.Replaced {
+		t.Error("Original must not be replaced without Replace")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "damaged" {
+		t.Error("Original was modified")
+	}
+	if content, _ := os.ReadFile(result.RepairedPath); string(content) != "damaged" {
+		t.Errorf("Expected repaired copy at %s", result.RepairedPath)
+	}
+
+	// A second run refuses to overwrite the first run's output
+	if again := RepairVideo(path, RepairOptions{}); again.Error == nil {
+		t.Error("Expected an existing repaired file to stop the repair")
+	}
+}
+
+// TestRepairVideo_SkipsIntactFile checks that a file which decodes cleanly is left alone,
+// even with Replace set
+func TestRepairVideo_SkipsIntactFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Stand-in tools are shell scripts")
+	}
+
+	binDir := t.TempDir()
+	ffmpeg := `#!/bin/sh
+case "$*" in *"-f null"*) printf 'out_time_us=600000000\nprogress=end\n' >&2; exit 0;; esac
+echo "unexpected remux" >&2; exit 1
+`
+	ffprobe := `#!/bin/sh
+echo 600.0
+`
+	writeTestScript(t, filepath.Join(binDir, "ffmpeg"), ffmpeg)
+	writeTestScript(t, filepath.Join(binDir, "ffprobe"), ffprobe)
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path := filepath.Join(t.TempDir(), "clip_[1920x1080][10min][ABCD1234].mp4")
+	writeTestFile(t, path, "intact")
+
+	result := RepairVideo(path, RepairOptions{Replace: true})
+	if result.Error != nil {
+		t.Fatalf("RepairVideo() error = %v", result.Error)
+	}
+	if !result.WasSkipped || result.WasRepaired || result.Replaced || len(result.Attempts) != 0 {
+		t.Errorf("Expected the intact file to be skipped, got %+v", result)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "intact" {
+		t.Error("Intact original was modified")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func writeTestScript(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,104 @@
+package video
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// durationDriftMins is how far the probed duration may differ from the tag before it counts as drift.
+// Tags are rounded to whole minutes, so a remux that shifts the duration by a few frames must not
+// flip a 44.5 minute file from [45min] to [44min].
+const durationDriftMins = 1.0
+
+// MetadataDrift compares the resolution and duration tags in a filename with the probed file
+type MetadataDrift struct {
+	TaggedResolution   string  `json:"taggedResolution"`
+	ActualResolution   string  `json:"actualResolution"`
+	TaggedDurationMins int     `json:"taggedDurationMins"`
+	ActualDurationMins float64 `json:"actualDurationMins"`
+}
+
+// ResolutionDrifted reports whether the file's resolution differs from its tag
+func (d *MetadataDrift) ResolutionDrifted() bool {
+	return d.TaggedResolution != d.ActualResolution
+}
+
+// DurationDrifted reports whether the file's duration differs from its tag by more than a minute
+func (d *MetadataDrift) DurationDrifted() bool {
+	return math.Abs(d.ActualDurationMins-float64(d.TaggedDurationMins)) > durationDriftMins
+}
+
+// Drifted reports whether any tag is out of date
+func (d *MetadataDrift) Drifted() bool {
+	return d.ResolutionDrifted() || d.DurationDrifted()
+}
+
+// CheckMetadata re-probes a tagged file and compares its resolution and duration with
+// the tags written into its name when it was processed
+func CheckMetadata(path string) (*MetadataDrift, error) {
+	resolution, durationMins, _, ok := ExtractMetadataFromFilename(filepath.Base(path))
+	if !ok {
+		return nil, fmt.Errorf("no metadata tags in filename")
+	}
+
+	metadata, err := extractVideoMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MetadataDrift{
+		TaggedResolution:   resolution,
+		ActualResolution:   metadata.Resolution,
+		TaggedDurationMins: durationMins,
+		ActualDurationMins: metadata.DurationMins,
+	}, nil
+}
+
+// retaggedFilename replaces the resolution and duration tags in a tagged path, keeping the hash
+func retaggedFilename(path string, metadata *VideoMetadata) (string, error) {
+	dir, name := filepath.Split(path)
+	m := wasProcessedRegex.FindStringSubmatchIndex(name)
+	if m == nil {
+		return "", fmt.Errorf("no metadata tags in filename")
+	}
+
+	// Groups 1 and 2 are the resolution and the duration in minutes
+	newName := name[:m[2]] + metadata.Resolution + name[m[3]:m[4]] +
+		fmt.Sprintf("%.0f", metadata.DurationMins) + name[m[5]:]
+
+	return dir + newName, nil
+}
+
+// RetagFile renames a tagged file so its drifted resolution and duration tags match the probed
+// values; a duration within tolerance keeps its tag. The hash tag is kept, as the contents don't
+// change. Returns the new path, or the old one if the tags were already current.
+func RetagFile(path string, drift *MetadataDrift) (string, error) {
+	metadata := &VideoMetadata{
+		Resolution:   drift.ActualResolution,
+		DurationMins: float64(drift.TaggedDurationMins),
+	}
+	if drift.DurationDrifted() {
+		metadata.DurationMins = drift.ActualDurationMins
+	}
+
+	newPath, err := retaggedFilename(path, metadata)
+	if err != nil {
+		return "", err
+	}
+	if newPath == path {
+		return path, nil
+	}
+
+	// os.Rename silently replaces an existing file on most platforms
+	if _, err := os.Stat(newPath); err == nil {
+		return "", fmt.Errorf("cannot retag, %s already exists", filepath.Base(newPath))
+	}
+
+	if err := renameVideoFile(path, newPath); err != nil {
+		return "", fmt.Errorf("failed to rename: %w", err)
+	}
+
+	return newPath, nil
+}
@@ -0,0 +1,124 @@
+package video
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMetadataDrift(t *testing.T) {
+	tests := []struct {
+		name            string
+		drift           MetadataDrift
+		resolutionDrift bool
+		durationDrift   bool
+		expectedDrifted bool
+	}{
+		{"Current", MetadataDrift{"1920x1080", "1920x1080", 45, 45.2}, false, false, false},
+		{"Rounding change", MetadataDrift{"1920x1080", "1920x1080", 45, 44.4}, false, false, false},
+		{"Downscaled", MetadataDrift{"1920x1080", "1280x720", 45, 45}, true, false, true},
+		{"Cut short", MetadataDrift{"1920x1080", "1920x1080", 45, 30}, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.drift.ResolutionDrifted(); got != tt.resolutionDrift {
+				t.Errorf("ResolutionDrifted() = %v, expected %v", got, tt.resolutionDrift)
+			}
+			if got := tt.drift.DurationDrifted(); got != tt.durationDrift {
+				t.Errorf("DurationDrifted() = %v, expected %v", got, tt.durationDrift)
+			}
+			if got := tt.drift.Drifted(); got != tt.expectedDrifted {
+				t.Errorf("Drifted() = %v, expected %v", got, tt.expectedDrifted)
+			}
+		})
+	}
+}
+
+func TestRetaggedFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		metadata VideoMetadata
+		expected string
+	}{
+		{
+			"Resolution",
+			"/videos/show_[1920x1080][45min][ABCD1234].mkv",
+			VideoMetadata{Resolution: "1280x720", DurationMins: 45},
+			"/videos/show_[1280x720][45min][ABCD1234].mkv",
+		},
+		{
+			"Duration rounded",
+			"movie_[640x480][90min][0000FFFF].mp4",
+			VideoMetadata{Resolution: "640x480", DurationMins: 87.6},
+			"movie_[640x480][88min][0000FFFF].mp4",
+		},
+		{
+			"Earlier brackets untouched",
+			"/videos/[Group] Show [1080p]_[1920x1080][45min][ABCD1234].mkv",
+			VideoMetadata{Resolution: "3840x2160", DurationMins: 45},
+			"/videos/[Group] Show [1080p]_[3840x2160][45min][ABCD1234].mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := retaggedFilename(tt.path, &tt.metadata)
+			if err != nil {
+				t.Fatalf("retaggedFilename() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("retaggedFilename() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := retaggedFilename("untagged.mp4", &VideoMetadata{Resolution: "1x1"}); err == nil {
+		t.Error("Expected error for untagged filename")
+	}
+}
+
+func TestRetagFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "show_[1920x1080][45min][ABCD1234].mkv")
+	if err := os.WriteFile(path, []byte("video"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// Only the drifted resolution changes; a duration within tolerance keeps its tag
+	drift := &MetadataDrift{TaggedResolution: "1920x1080", ActualResolution: "1280x720", TaggedDurationMins: 45, ActualDurationMins: 45.6}
+	newPath, err := RetagFile(path, drift)
+	if err != nil {
+		t.Fatalf("RetagFile() error = %v", err)
+	}
+
+	expected := filepath.Join(dir, "show_[1280x720][45min][ABCD1234].mkv")
+	if newPath != expected {
+		t.Errorf("RetagFile() = %q, expected %q", newPath, expected)
+	}
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("Renamed file not found: %v", err)
+	}
+}
+
+func TestRetagFile_ExistingTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "show_[1920x1080][45min][ABCD1234].mkv")
+	target := filepath.Join(dir, "show_[1280x720][45min][ABCD1234].mkv")
+	for _, p := range []string{path, target} {
+		if err := os.WriteFile(p, []byte(filepath.Base(p)), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	drift := &MetadataDrift{TaggedResolution: "1920x1080", ActualResolution: "1280x720", TaggedDurationMins: 45, ActualDurationMins: 45}
+	if _, err := RetagFile(path, drift); err == nil {
+		t.Fatal("Expected error when the retagged name already exists")
+	}
+
+	content, _ := os.ReadFile(target)
+	if string(content) != filepath.Base(target) {
+		t.Error("Existing file was overwritten")
+	}
+}
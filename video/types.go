@@ -1,11 +1,15 @@
 package video
 
-import "os"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
 
 // VideoMetadata contains the extracted metadata for a video file
 type VideoMetadata struct {
-	Resolution   string
-	DurationMins float64
+	Resolution   string  `json:"resolution"`
+	DurationMins float64 `json:"durationMins"`
 }
 
 // FileValidationResult contains the result of file validation
@@ -18,12 +22,75 @@ type FileValidationResult struct {
 
 // ProcessingResult represents the result of processing a video file
 type ProcessingResult struct {
-	OriginalPath string
-	NewPath      string
-	WasSkipped   bool
-	SkipReason   string
-	Error        error
-	Metadata     *VideoMetadata
-	CRC32        uint32
-	WasRenamed   bool
+	OriginalPath string         `json:"originalPath"`
+	NewPath      string         `json:"newPath,omitempty"`
+	WasSkipped   bool           `json:"wasSkipped"`
+	SkipReason   string         `json:"skipReason,omitempty"`
+	Error        error          `json:"-"`
+	Metadata     *VideoMetadata `json:"metadata,omitempty"`
+	CRC32        uint32         `json:"crc32"`
+	CRCSource    string         `json:"crcSource,omitempty"` // CRCSourceSFV when taken from a trusted SFV file
+	WasRenamed   bool           `json:"wasRenamed"`
+}
+
+// CRCSourceSFV marks a CRC32 taken from a trusted SFV file instead of hashing the contents
+const CRCSourceSFV = "sfv"
+
+// ProcessOptions configures optional tagging behaviour
+type ProcessOptions struct {
+	// KnownCRC returns a trusted CRC32 for a file of the given size, skipping the rehash.
+	// It may be nil.
+	KnownCRC func(path string, size int64) (uint32, bool)
+}
+
+// MarshalJSON encodes the CRC32 as the same 8-digit hex string used in tagged filenames
+func (r ProcessingResult) MarshalJSON() ([]byte, error) {
+	type plain ProcessingResult
+	crc := ""
+	if r.NewPath != "" {
+		crc = fmt.Sprintf("%08X", r.CRC32)
+	}
+	return json.Marshal(struct {
+		plain
+		CRC32 string `json:"crc32,omitempty"`
+	}{plain(r), crc})
+}
+
+// VerifyResult represents the result of checking a tagged file against the hash in its name
+type VerifyResult struct {
+	Path         string `json:"path"`
+	ExpectedHash string `json:"expectedHash,omitempty"`
+	ActualHash   string `json:"actualHash,omitempty"`
+	Verified     bool   `json:"verified"`
+	WasSkipped   bool   `json:"wasSkipped"`
+	SkipReason   string `json:"skipReason,omitempty"`
+	Error        error  `json:"-"`
+
+	Decode    *DecodeResult  `json:"decode,omitempty"`    // Set when a full decode check was requested
+	Metadata  *MetadataDrift `json:"metadata,omitempty"`  // Set when the filename tags were re-probed
+	RenamedTo string         `json:"renamedTo,omitempty"` // New path after out-of-date tags were fixed
+
+	QuarantinedTo string `json:"quarantinedTo,omitempty"` // Where the file was moved after failing
+}
+
+// Mismatch reports whether the file was hashed and the hash differs from its filename
+func (r *VerifyResult) Mismatch() bool {
+	return !r.Verified && !r.WasSkipped && r.Error == nil
+}
+
+// Drifted reports whether the resolution or duration tags in the filename are out of date
+func (r *VerifyResult) Drifted() bool {
+	return r.Metadata != nil && r.Metadata.Drifted()
+}
+
+// Corrupt reports whether a decode check found the file truncated or damaged
+func (r *VerifyResult) Corrupt() bool {
+	return r.Decode != nil && !r.Decode.OK()
+}
+
+// SimilarPair represents two perceptually similar videos
+type SimilarPair struct {
+	Path     string `json:"path"`
+	Other    string `json:"other"`
+	Distance int    `json:"distance"`
 }
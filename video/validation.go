@@ -1,7 +1,9 @@
 package video
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -81,11 +83,16 @@ func ExtractMetadataFromFilename(filename string) (resolution string, durationMi
 }
 
 // ValidateVideoIntegrity checks if a video file is corrupted or invalid
-// Returns an error if the file is corrupted or cannot be read
+// Returns an error if the file is corrupted or cannot be read. Known problems are returned
+// as an *IntegrityError matching one of ErrMissingIndex, ErrTruncated, ErrInvalidData,
+// ErrUnsupportedCodec or ErrUnreadable with errors.Is.
 func ValidateVideoIntegrity(filePath string) error {
 	// First check if file exists and is readable
 	if _, err := os.Stat(filePath); err != nil {
-		return fmt.Errorf("file not accessible: %w", err)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("file not accessible: %w", err)
+		}
+		return &IntegrityError{Path: filePath, Kind: ErrUnreadable, Err: err}
 	}
 
 	// Use ffprobe to check file integrity without extracting metadata
@@ -94,16 +101,10 @@ func ValidateVideoIntegrity(filePath string) error {
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
-		// Check for common corruption indicators
 		outputStr := string(output)
-		if strings.Contains(outputStr, "moov atom not found") {
-			return fmt.Errorf("video file is corrupted (missing metadata): %s", extractFirstLine(outputStr))
-		}
-		if strings.Contains(outputStr, "Invalid data found") ||
-			strings.Contains(outputStr, "corrupt") ||
-			strings.Contains(outputStr, "truncated") ||
-			strings.Contains(outputStr, "Invalid argument") {
-			return fmt.Errorf("video file is corrupted or invalid: %s", extractFirstLine(outputStr))
+		if integrityErr := classifyProbeOutput(filePath, outputStr); integrityErr != nil {
+			integrityErr.Err = errors.Join(integrityErr.Err, err)
+			return integrityErr
 		}
 
 		// Return generic ffprobe error with output
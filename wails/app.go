@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -36,6 +37,7 @@ type Preview struct {
 type App struct {
 	ctx                  context.Context
 	groups               []duplicates.DuplicateGroup
+	reference            duplicates.ReferenceRoots
 	totalSelectedCount   int
 	groupsWithSelections map[int]int
 	previewCache         map[string]string
@@ -66,7 +68,7 @@ func (a *App) SelectDirectory() (string, error) {
 }
 
 // ConfirmDeleteSelected asks the user to confirm file deletion using a native dialog.
-func (a *App) ConfirmDeleteSelected(count int) (bool, error) {
+func (a *App) ConfirmDeleteSelected(count int, permanent bool) (bool, error) {
 	if a.ctx == nil {
 		return false, fmt.Errorf("app context not ready")
 	}
@@ -74,10 +76,15 @@ func (a *App) ConfirmDeleteSelected(count int) (bool, error) {
 		return false, nil
 	}
 
+	message := fmt.Sprintf("Move %d selected file(s) to the trash?", count)
+	if permanent {
+		message = fmt.Sprintf("Permanently delete %d selected file(s)? This cannot be undone.", count)
+	}
+
 	result, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
 		Type:          runtime.QuestionDialog,
 		Title:         "Confirm deletion",
-		Message:       fmt.Sprintf("Delete %d selected file(s)? This cannot be undone.", count),
+		Message:       message,
 		Buttons:       []string{"Cancel", "Delete"},
 		DefaultButton: "Cancel",
 		CancelButton:  "Cancel",
@@ -89,19 +96,28 @@ func (a *App) ConfirmDeleteSelected(count int) (bool, error) {
 	return result == "Delete", nil
 }
 
-// ScanDirectory finds duplicates in the provided directory.
-func (a *App) ScanDirectory(directory string) (AppState, error) {
-	directory = strings.TrimSpace(directory)
-	if directory == "" {
+// ScanDirectories finds duplicates across the provided directories. Files in the
+// reference directories, which are scanned too, are protected from deletion.
+func (a *App) ScanDirectories(directories, reference []string) (AppState, error) {
+	directories = trimPaths(directories)
+	reference = trimPaths(reference)
+	if len(directories) == 0 && len(reference) == 0 {
 		return AppState{}, fmt.Errorf("directory is required")
 	}
 
-	duplicatesMap, err := video.FindDuplicatesByHash(directory)
+	roots, err := duplicates.NewReferenceRoots(reference)
+	if err != nil {
+		return AppState{}, err
+	}
+
+	duplicatesMap, err := video.FindDuplicatesByHash(append(directories, reference...)...)
 	if err != nil {
 		return AppState{}, err
 	}
 
 	a.groups = duplicates.BuildGroups(duplicatesMap)
+	a.reference = roots
+	a.reference.Mark(a.groups)
 	a.previewMu.Lock()
 	a.previewCache = make(map[string]string)
 	a.previewMu.Unlock()
@@ -123,7 +139,10 @@ func (a *App) ToggleSelection(groupIndex, fileIndex int) (AppState, error) {
 		return AppState{}, err
 	}
 
-	a.groups[groupIndex].Selected[fileIndex] = !a.groups[groupIndex].Selected[fileIndex]
+	group := &a.groups[groupIndex]
+	if err := duplicates.SetSelected(group, fileIndex, !group.Selected[fileIndex]); err != nil {
+		return AppState{}, err
+	}
 	return a.recalculate()
 }
 
@@ -133,9 +152,7 @@ func (a *App) SelectAllInGroup(groupIndex int) (AppState, error) {
 		return AppState{}, err
 	}
 
-	for i := range a.groups[groupIndex].Selected {
-		a.groups[groupIndex].Selected[i] = true
-	}
+	duplicates.SelectAll(&a.groups[groupIndex])
 
 	return a.recalculate()
 }
@@ -154,38 +171,119 @@ func (a *App) ClearSelectionInGroup(groupIndex int) (AppState, error) {
 }
 
 // ApplyAutoSelect applies an auto-selection strategy to the group.
+// Files missing metadata the strategy needs are probed first.
 func (a *App) ApplyAutoSelect(groupIndex, strategy int) (AppState, error) {
 	if err := a.validateGroupIndex(groupIndex); err != nil {
 		return AppState{}, err
 	}
 
-	if strategy < int(duplicates.KeepNewest) || strategy > int(duplicates.KeepLastPosition) {
+	if strategy < int(duplicates.KeepNewest) || strategy > int(duplicates.KeepHighestBitsPerPixel) {
 		return AppState{}, fmt.Errorf("unknown auto-select strategy: %d", strategy)
 	}
 
-	duplicates.ApplyAutoSelectStrategy(&a.groups[groupIndex], duplicates.AutoSelectStrategy(strategy))
+	keep := duplicates.AutoSelectStrategy(strategy)
+	duplicates.ProbeMissing(a.groups[groupIndex:groupIndex+1], keep.Missing)
+	duplicates.ApplyAutoSelectStrategy(&a.groups[groupIndex], keep)
 	return a.recalculate()
 }
 
-// DeleteSelected removes all selected files across groups.
-func (a *App) DeleteSelected() (AppState, error) {
-	selected := duplicates.CollectSelectedFiles(a.groups)
-	if len(selected) == 0 {
+// ApplyKeepRules selects every file in the group except the one the keep rules pick.
+// Rules are in order of priority and written as for duplicates --keep-rule.
+func (a *App) ApplyKeepRules(groupIndex int, rules []string) (AppState, error) {
+	if err := a.validateGroupIndex(groupIndex); err != nil {
+		return AppState{}, err
+	}
+
+	policy, err := duplicates.ParsePolicy(rules)
+	if err != nil {
+		return AppState{}, err
+	}
+	if len(policy) == 0 {
+		return AppState{}, fmt.Errorf("no keep rules given")
+	}
+
+	duplicates.ProbeMissing(a.groups[groupIndex:groupIndex+1], policy.Missing)
+	policy.Apply(&a.groups[groupIndex])
+	return a.recalculate()
+}
+
+// DeleteSelected moves all selected files across groups to the trash,
+// or removes them when permanent is set.
+func (a *App) DeleteSelected(permanent bool) (AppState, error) {
+	if len(duplicates.CollectSelectedFiles(a.groups)) == 0 {
 		return a.recalculate()
 	}
 
-	failedPath, err := duplicates.DeleteFiles(selected)
+	// Compare contents first; if groups split, the user has to review before deleting
+	confirmed, err := duplicates.ConfirmSelected(a.groups)
+	if errors.Is(err, duplicates.ErrSelectionChanged) {
+		a.groups = confirmed
+		return AppState{}, err
+	}
 	if err != nil {
-		return AppState{}, fmt.Errorf("failed to delete %s: %w", failedPath, err)
+		return AppState{}, err
 	}
+	a.groups = confirmed
+
+	results := duplicates.DeleteFiles(duplicates.CollectSelected(a.groups), duplicates.DeleteOptions{
+		Reference: a.reference,
+		Permanent: permanent,
+	})
+	a.groups = duplicates.ApplyDeletion(a.groups, results)
 
-	a.groups = duplicates.ApplyDeletion(a.groups, selected)
+	complete := true
 	a.previewMu.Lock()
-	for _, path := range selected {
-		delete(a.previewCache, path)
+	for _, result := range results {
+		if result.Gone() {
+			delete(a.previewCache, result.Path)
+		} else {
+			complete = false
+		}
 	}
 	a.previewMu.Unlock()
 
+	// Groups already reflect what happened; the frontend reloads them after an error
+	if !complete {
+		return AppState{}, fmt.Errorf("not everything was deleted: %s", duplicates.SummarizeDeletion(results))
+	}
+	return a.recalculate()
+}
+
+// LinkSelected replaces all selected files with hardlinks ("hardlink") or reflink clones
+// ("reflink") of the unselected file in their group, so every path keeps existing.
+func (a *App) LinkSelected(mode string) (AppState, error) {
+	linkMode, err := duplicates.ParseLinkMode(mode)
+	if err != nil {
+		return AppState{}, err
+	}
+	if len(duplicates.CollectSelectedFiles(a.groups)) == 0 {
+		return a.recalculate()
+	}
+
+	// Compare contents first; if groups split, the user has to review before linking
+	confirmed, err := duplicates.ConfirmSelected(a.groups)
+	if errors.Is(err, duplicates.ErrSelectionChanged) {
+		a.groups = confirmed
+		return AppState{}, err
+	}
+	if err != nil {
+		return AppState{}, err
+	}
+	a.groups = confirmed
+
+	targets := duplicates.CollectLinkTargets(a.groups)
+	if len(targets) == 0 {
+		return AppState{}, fmt.Errorf("nothing to link: leave the file to keep unselected in each group")
+	}
+
+	results := duplicates.LinkFiles(targets, linkMode, a.reference)
+	a.groups = duplicates.ApplyLinks(a.groups, results)
+	for _, result := range results {
+		if !result.Linked {
+			return AppState{}, fmt.Errorf("not everything was linked: %s", duplicates.SummarizeLinks(results))
+		}
+	}
+
 	return a.recalculate()
 }
 
@@ -224,6 +322,17 @@ func (a *App) GetPreview(path string) (Preview, error) {
 	return Preview{Type: "image", Data: encoded}, nil
 }
 
+// trimPaths drops surrounding whitespace and empty entries from a list of paths.
+func trimPaths(paths []string) []string {
+	var trimmed []string
+	for _, path := range paths {
+		if path = strings.TrimSpace(path); path != "" {
+			trimmed = append(trimmed, path)
+		}
+	}
+	return trimmed
+}
+
 func (a *App) validateGroupIndex(groupIndex int) error {
 	if groupIndex < 0 || groupIndex >= len(a.groups) {
 		return fmt.Errorf("group index out of range")
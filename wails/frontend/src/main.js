@@ -15,6 +15,8 @@ const state = {
 const elements = {
   directoryInput: document.getElementById('directoryInput'),
   browseBtn: document.getElementById('browseBtn'),
+  referenceInput: document.getElementById('referenceInput'),
+  referenceBrowseBtn: document.getElementById('referenceBrowseBtn'),
   scanBtn: document.getElementById('scanBtn'),
   statusText: document.getElementById('statusText'),
   groupCount: document.getElementById('groupCount'),
@@ -26,6 +28,11 @@ const elements = {
   selectAllBtn: document.getElementById('selectAllBtn'),
   clearBtn: document.getElementById('clearBtn'),
   deleteBtn: document.getElementById('deleteBtn'),
+  permanentInput: document.getElementById('permanentInput'),
+  hardlinkBtn: document.getElementById('hardlinkBtn'),
+  reflinkBtn: document.getElementById('reflinkBtn'),
+  keepRulesInput: document.getElementById('keepRulesInput'),
+  keepRulesBtn: document.getElementById('keepRulesBtn'),
   previewPane: document.getElementById('previewPane'),
   previewMeta: document.getElementById('previewMeta'),
   previewHint: document.getElementById('previewHint')
@@ -101,6 +108,10 @@ function escapeHtml(value) {
     .replaceAll("'", '&#039;');
 }
 
+function splitPaths(value) {
+  return value.split(';').map((path) => path.trim()).filter(Boolean);
+}
+
 function renderGroups() {
   elements.groupList.innerHTML = '';
   state.groups.forEach((group, index) => {
@@ -111,7 +122,7 @@ function renderGroups() {
     card.style.animationDelay = `${index * 30}ms`;
     card.innerHTML = `
       <strong>${escapeHtml(group.hash)}</strong>
-      <span>${group.files.length} files - ${selectedCount} selected</span>
+      <span>${group.files.length} files - ${selectedCount} selected - ${group.confirmed ? 'confirmed' : 'name-only'}</span>
     `;
     elements.groupList.appendChild(card);
   });
@@ -127,29 +138,32 @@ function renderFiles() {
   }
 
   elements.groupTitle.textContent = `Group ${state.selectedGroupIndex + 1}`;
-  elements.groupMeta.textContent = `Hash ${group.hash} - ${group.files.length} files`;
+  elements.groupMeta.textContent = `Hash ${group.hash} - ${group.files.length} files - ${group.confirmed ? 'confirmed' : 'name-only'}`;
 
   group.files.forEach((file, index) => {
     const baseName = file.path.split(/[/\\]/).pop();
     const metadata = [
       file.resolution || '???',
       file.durationMins ? `${file.durationMins}min` : '?min',
+      file.codec,
       formatFileSize(file.size || 0),
       formatModTime(file.modTime)
-    ].join(' | ');
+    ].filter(Boolean).join(' | ');
 
     const card = document.createElement('div');
+    const protectedNote = file.protected ? '<p>Reference copy - always kept</p>' : '';
     const selectedClass = group.selected?.[index] ? ' selected' : '';
     const activeClass = index === state.selectedFileIndex ? ' active' : '';
     card.className = `file-card${selectedClass}${activeClass}`;
     card.dataset.fileIndex = `${index}`;
     card.style.animationDelay = `${index * 20}ms`;
     card.innerHTML = `
-      <button class="checkbox${group.selected?.[index] ? ' selected' : ''}" data-action="toggle" data-file-index="${index}" type="button">X</button>
+      <button class="checkbox${group.selected?.[index] ? ' selected' : ''}" data-action="toggle" data-file-index="${index}" type="button"${file.protected ? ' disabled' : ''}>${file.protected ? '&#128274;' : 'X'}</button>
       <div class="file-info">
         <h3>${escapeHtml(baseName)}</h3>
         <p>${escapeHtml(file.path)}</p>
         <p>${metadata}</p>
+        ${protectedNote}
       </div>
     `;
     elements.fileList.appendChild(card);
@@ -207,6 +221,8 @@ function updateActions() {
   elements.selectAllBtn.disabled = !hasGroups;
   elements.clearBtn.disabled = !hasGroups;
   elements.deleteBtn.disabled = state.totalSelectedCount === 0;
+  elements.hardlinkBtn.disabled = state.totalSelectedCount === 0;
+  elements.reflinkBtn.disabled = state.totalSelectedCount === 0;
 
   document.querySelectorAll('[data-strategy]').forEach((button) => {
     button.disabled = !hasGroups;
@@ -227,15 +243,16 @@ async function scanDirectory() {
     return;
   }
 
-  const directory = elements.directoryInput.value.trim();
-  if (!directory) {
+  const directories = splitPaths(elements.directoryInput.value);
+  const reference = splitPaths(elements.referenceInput.value);
+  if (!directories.length && !reference.length) {
     setStatus('Enter a folder to scan.', true);
     return;
   }
 
-  setStatus(`Scanning ${directory}...`);
+  setStatus(`Scanning ${[...directories, ...reference].join(', ')}...`);
   try {
-    const appState = await backend.ScanDirectory(directory);
+    const appState = await backend.ScanDirectories(directories, reference);
     applyState(appState);
     setStatus(state.groups.length ? 'Duplicates loaded.' : 'No duplicates found.');
     await loadPreview();
@@ -293,6 +310,18 @@ async function applyAutoSelect(strategy) {
   await runGroupAction('ApplyAutoSelect', state.selectedGroupIndex, strategy);
 }
 
+async function applyKeepRules() {
+  const rules = elements.keepRulesInput.value
+    .split('\n')
+    .map((rule) => rule.trim())
+    .filter(Boolean);
+  if (rules.length === 0) {
+    setStatus('Enter at least one keep rule, e.g. resolution:highest.', true);
+    return;
+  }
+  await runGroupAction('ApplyKeepRules', state.selectedGroupIndex, rules);
+}
+
 async function deleteSelected() {
   if (!backend) {
     return;
@@ -304,9 +333,10 @@ async function deleteSelected() {
     return;
   }
 
+  const permanent = elements.permanentInput.checked;
   let confirmed = false;
   try {
-    confirmed = await backend.ConfirmDeleteSelected(selectedCount);
+    confirmed = await backend.ConfirmDeleteSelected(selectedCount, permanent);
   } catch (error) {
     setStatus(`Could not open confirmation dialog: ${error}`, true);
     return;
@@ -317,11 +347,40 @@ async function deleteSelected() {
     return;
   }
 
-  setStatus('Deleting selected files...');
-  const ok = await runGroupAction('DeleteSelected');
+  setStatus('Comparing and deleting selected files...');
+  const ok = await runGroupAction('DeleteSelected', permanent);
   if (ok) {
-    setStatus('Deletion complete.');
+    setStatus(permanent ? 'Deletion complete.' : 'Moved to the trash.');
+    return;
   }
+
+  // Groups may have been split after comparing contents, or some files were left, so show what changed
+  const status = elements.statusText.textContent;
+  await runGroupAction('GetState');
+  setStatus(status, true);
+}
+
+async function linkSelected(mode) {
+  if (!backend) {
+    return;
+  }
+
+  if (!state.totalSelectedCount) {
+    setStatus('No files selected.', true);
+    return;
+  }
+
+  setStatus(`Comparing and replacing selected files with ${mode}s...`);
+  const ok = await runGroupAction('LinkSelected', mode);
+  if (ok) {
+    setStatus('Linking complete.');
+    return;
+  }
+
+  // Groups may have been split, or some files linked before a failure
+  const status = elements.statusText.textContent;
+  await runGroupAction('GetState');
+  setStatus(status, true);
 }
 
 function bindEvents() {
@@ -333,7 +392,20 @@ function bindEvents() {
     try {
       const directory = await backend.SelectDirectory();
       if (directory) {
-        elements.directoryInput.value = directory;
+        elements.directoryInput.value = [...splitPaths(elements.directoryInput.value), directory].join('; ');
+      }
+    } catch (error) {
+      setStatus(String(error), true);
+    }
+  });
+  elements.referenceBrowseBtn.addEventListener('click', async () => {
+    if (!backend) {
+      return;
+    }
+    try {
+      const directory = await backend.SelectDirectory();
+      if (directory) {
+        elements.referenceInput.value = directory;
       }
     } catch (error) {
       setStatus(String(error), true);
@@ -381,6 +453,9 @@ function bindEvents() {
   elements.selectAllBtn.addEventListener('click', () => runGroupAction('SelectAllInGroup', state.selectedGroupIndex));
   elements.clearBtn.addEventListener('click', () => runGroupAction('ClearSelectionInGroup', state.selectedGroupIndex));
   elements.deleteBtn.addEventListener('click', deleteSelected);
+  elements.hardlinkBtn.addEventListener('click', () => linkSelected('hardlink'));
+  elements.reflinkBtn.addEventListener('click', () => linkSelected('reflink'));
+  elements.keepRulesBtn.addEventListener('click', applyKeepRules);
 
   document.querySelectorAll('[data-strategy]').forEach((button) => {
     button.addEventListener('click', () => {
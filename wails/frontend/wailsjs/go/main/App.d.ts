@@ -4,17 +4,21 @@ import {main} from '../models';
 
 export function ApplyAutoSelect(arg1:number,arg2:number):Promise<main.AppState>;
 
+export function ApplyKeepRules(arg1:number,arg2:Array<string>):Promise<main.AppState>;
+
 export function ClearSelectionInGroup(arg1:number):Promise<main.AppState>;
 
-export function ConfirmDeleteSelected(arg1:number):Promise<boolean>;
+export function ConfirmDeleteSelected(arg1:number,arg2:boolean):Promise<boolean>;
 
-export function DeleteSelected():Promise<main.AppState>;
+export function DeleteSelected(arg1:boolean):Promise<main.AppState>;
 
 export function GetPreview(arg1:string):Promise<main.Preview>;
 
 export function GetState():Promise<main.AppState>;
 
-export function ScanDirectory(arg1:string):Promise<main.AppState>;
+export function LinkSelected(arg1:string):Promise<main.AppState>;
+
+export function ScanDirectories(arg1:Array<string>,arg2:Array<string>):Promise<main.AppState>;
 
 export function SelectAllInGroup(arg1:number):Promise<main.AppState>;
 
@@ -6,16 +6,20 @@ export function ApplyAutoSelect(arg1, arg2) {
   return window['go']['main']['App']['ApplyAutoSelect'](arg1, arg2);
 }
 
+export function ApplyKeepRules(arg1, arg2) {
+  return window['go']['main']['App']['ApplyKeepRules'](arg1, arg2);
+}
+
 export function ClearSelectionInGroup(arg1) {
   return window['go']['main']['App']['ClearSelectionInGroup'](arg1);
 }
 
-export function ConfirmDeleteSelected(arg1) {
-  return window['go']['main']['App']['ConfirmDeleteSelected'](arg1);
+export function ConfirmDeleteSelected(arg1, arg2) {
+  return window['go']['main']['App']['ConfirmDeleteSelected'](arg1, arg2);
 }
 
-export function DeleteSelected() {
-  return window['go']['main']['App']['DeleteSelected']();
+export function DeleteSelected(arg1) {
+  return window['go']['main']['App']['DeleteSelected'](arg1);
 }
 
 export function GetPreview(arg1) {
@@ -26,8 +30,12 @@ export function GetState() {
   return window['go']['main']['App']['GetState']();
 }
 
-export function ScanDirectory(arg1) {
-  return window['go']['main']['App']['ScanDirectory'](arg1);
+export function LinkSelected(arg1) {
+  return window['go']['main']['App']['LinkSelected'](arg1);
+}
+
+export function ScanDirectories(arg1, arg2) {
+  return window['go']['main']['App']['ScanDirectories'](arg1, arg2);
 }
 
 export function SelectAllInGroup(arg1) {
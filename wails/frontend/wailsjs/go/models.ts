@@ -6,6 +6,10 @@ export namespace duplicates {
 	    modTime: number;
 	    resolution: string;
 	    durationMins: number;
+	    codec: string;
+	    bitsPerPixel: number;
+	    probed: boolean;
+	    protected: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new FileMetadata(source);
@@ -18,6 +22,10 @@ export namespace duplicates {
 	        this.modTime = source["modTime"];
 	        this.resolution = source["resolution"];
 	        this.durationMins = source["durationMins"];
+	        this.codec = source["codec"];
+	        this.bitsPerPixel = source["bitsPerPixel"];
+	        this.probed = source["probed"];
+	        this.protected = source["protected"];
 	    }
 	}
 	export class DuplicateGroup {
@@ -25,6 +33,7 @@ export namespace duplicates {
 	    files: FileMetadata[];
 	    selected: boolean[];
 	    deletedFiles: FileMetadata[];
+	    confirmed: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new DuplicateGroup(source);
@@ -36,6 +45,7 @@ export namespace duplicates {
 	        this.files = this.convertValues(source["files"], FileMetadata);
 	        this.selected = source["selected"];
 	        this.deletedFiles = this.convertValues(source["deletedFiles"], FileMetadata);
+	        this.confirmed = source["confirmed"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {